@@ -26,6 +26,15 @@ const (
 	DownstreamCommandType_DOWNSTREAM_COMMAND_TYPE_UNSPECIFIED DownstreamCommandType = 0
 	DownstreamCommandType_DOWNSTREAM_COMMAND_TYPE_PLAY        DownstreamCommandType = 1
 	DownstreamCommandType_DOWNSTREAM_COMMAND_TYPE_STOP        DownstreamCommandType = 2
+	DownstreamCommandType_DOWNSTREAM_COMMAND_TYPE_RECONCILE   DownstreamCommandType = 3
+	// Upload the pre-event buffer of the stream, then its live media for a while
+	DownstreamCommandType_DOWNSTREAM_COMMAND_TYPE_TRIGGER DownstreamCommandType = 4
+	// Steer the camera, see the ptz field
+	DownstreamCommandType_DOWNSTREAM_COMMAND_TYPE_PTZ DownstreamCommandType = 5
+	// Take a JPEG snapshot of the camera
+	DownstreamCommandType_DOWNSTREAM_COMMAND_TYPE_SNAPSHOT DownstreamCommandType = 6
+	// Reload the configuration of the agent, the stream is ignored
+	DownstreamCommandType_DOWNSTREAM_COMMAND_TYPE_RELOAD DownstreamCommandType = 7
 )
 
 // Enum value maps for DownstreamCommandType.
@@ -34,11 +43,21 @@ var (
 		0: "DOWNSTREAM_COMMAND_TYPE_UNSPECIFIED",
 		1: "DOWNSTREAM_COMMAND_TYPE_PLAY",
 		2: "DOWNSTREAM_COMMAND_TYPE_STOP",
+		3: "DOWNSTREAM_COMMAND_TYPE_RECONCILE",
+		4: "DOWNSTREAM_COMMAND_TYPE_TRIGGER",
+		5: "DOWNSTREAM_COMMAND_TYPE_PTZ",
+		6: "DOWNSTREAM_COMMAND_TYPE_SNAPSHOT",
+		7: "DOWNSTREAM_COMMAND_TYPE_RELOAD",
 	}
 	DownstreamCommandType_value = map[string]int32{
 		"DOWNSTREAM_COMMAND_TYPE_UNSPECIFIED": 0,
 		"DOWNSTREAM_COMMAND_TYPE_PLAY":        1,
 		"DOWNSTREAM_COMMAND_TYPE_STOP":        2,
+		"DOWNSTREAM_COMMAND_TYPE_RECONCILE":   3,
+		"DOWNSTREAM_COMMAND_TYPE_TRIGGER":     4,
+		"DOWNSTREAM_COMMAND_TYPE_PTZ":         5,
+		"DOWNSTREAM_COMMAND_TYPE_SNAPSHOT":    6,
+		"DOWNSTREAM_COMMAND_TYPE_RELOAD":      7,
 	}
 )
 
@@ -69,6 +88,223 @@ func (DownstreamCommandType) EnumDescriptor() ([]byte, []int) {
 	return file_hub_proto_rawDescGZIP(), []int{0}
 }
 
+type PTZAction int32
+
+const (
+	PTZAction_PTZ_ACTION_UNSPECIFIED  PTZAction = 0
+	PTZAction_PTZ_ACTION_MOVE         PTZAction = 1
+	PTZAction_PTZ_ACTION_STOP         PTZAction = 2
+	PTZAction_PTZ_ACTION_GOTO_PRESET  PTZAction = 3
+	PTZAction_PTZ_ACTION_LIST_PRESETS PTZAction = 4
+)
+
+// Enum value maps for PTZAction.
+var (
+	PTZAction_name = map[int32]string{
+		0: "PTZ_ACTION_UNSPECIFIED",
+		1: "PTZ_ACTION_MOVE",
+		2: "PTZ_ACTION_STOP",
+		3: "PTZ_ACTION_GOTO_PRESET",
+		4: "PTZ_ACTION_LIST_PRESETS",
+	}
+	PTZAction_value = map[string]int32{
+		"PTZ_ACTION_UNSPECIFIED":  0,
+		"PTZ_ACTION_MOVE":         1,
+		"PTZ_ACTION_STOP":         2,
+		"PTZ_ACTION_GOTO_PRESET":  3,
+		"PTZ_ACTION_LIST_PRESETS": 4,
+	}
+)
+
+func (x PTZAction) Enum() *PTZAction {
+	p := new(PTZAction)
+	*p = x
+	return p
+}
+
+func (x PTZAction) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PTZAction) Descriptor() protoreflect.EnumDescriptor {
+	return file_hub_proto_enumTypes[1].Descriptor()
+}
+
+func (PTZAction) Type() protoreflect.EnumType {
+	return &file_hub_proto_enumTypes[1]
+}
+
+func (x PTZAction) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PTZAction.Descriptor instead.
+func (PTZAction) EnumDescriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{1}
+}
+
+type UpstreamReplyStatus int32
+
+const (
+	UpstreamReplyStatus_UPSTREAM_REPLY_STATUS_UNSPECIFIED    UpstreamReplyStatus = 0
+	UpstreamReplyStatus_UPSTREAM_REPLY_STATUS_OK             UpstreamReplyStatus = 1
+	UpstreamReplyStatus_UPSTREAM_REPLY_STATUS_NO_SUCH_CAMERA UpstreamReplyStatus = 2
+	UpstreamReplyStatus_UPSTREAM_REPLY_STATUS_CAMERA_ERROR   UpstreamReplyStatus = 3
+)
+
+// Enum value maps for UpstreamReplyStatus.
+var (
+	UpstreamReplyStatus_name = map[int32]string{
+		0: "UPSTREAM_REPLY_STATUS_UNSPECIFIED",
+		1: "UPSTREAM_REPLY_STATUS_OK",
+		2: "UPSTREAM_REPLY_STATUS_NO_SUCH_CAMERA",
+		3: "UPSTREAM_REPLY_STATUS_CAMERA_ERROR",
+	}
+	UpstreamReplyStatus_value = map[string]int32{
+		"UPSTREAM_REPLY_STATUS_UNSPECIFIED":    0,
+		"UPSTREAM_REPLY_STATUS_OK":             1,
+		"UPSTREAM_REPLY_STATUS_NO_SUCH_CAMERA": 2,
+		"UPSTREAM_REPLY_STATUS_CAMERA_ERROR":   3,
+	}
+)
+
+func (x UpstreamReplyStatus) Enum() *UpstreamReplyStatus {
+	p := new(UpstreamReplyStatus)
+	*p = x
+	return p
+}
+
+func (x UpstreamReplyStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (UpstreamReplyStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_hub_proto_enumTypes[2].Descriptor()
+}
+
+func (UpstreamReplyStatus) Type() protoreflect.EnumType {
+	return &file_hub_proto_enumTypes[2]
+}
+
+func (x UpstreamReplyStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use UpstreamReplyStatus.Descriptor instead.
+func (UpstreamReplyStatus) EnumDescriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{2}
+}
+
+type CameraState int32
+
+const (
+	CameraState_CAMERA_STATE_UNSPECIFIED CameraState = 0
+	CameraState_CAMERA_STATE_OFF         CameraState = 1
+	CameraState_CAMERA_STATE_IDLE        CameraState = 2
+	CameraState_CAMERA_STATE_PLAYING     CameraState = 3
+	CameraState_CAMERA_STATE_PAUSING     CameraState = 4
+	CameraState_CAMERA_STATE_RESUMING    CameraState = 5
+)
+
+// Enum value maps for CameraState.
+var (
+	CameraState_name = map[int32]string{
+		0: "CAMERA_STATE_UNSPECIFIED",
+		1: "CAMERA_STATE_OFF",
+		2: "CAMERA_STATE_IDLE",
+		3: "CAMERA_STATE_PLAYING",
+		4: "CAMERA_STATE_PAUSING",
+		5: "CAMERA_STATE_RESUMING",
+	}
+	CameraState_value = map[string]int32{
+		"CAMERA_STATE_UNSPECIFIED": 0,
+		"CAMERA_STATE_OFF":         1,
+		"CAMERA_STATE_IDLE":        2,
+		"CAMERA_STATE_PLAYING":     3,
+		"CAMERA_STATE_PAUSING":     4,
+		"CAMERA_STATE_RESUMING":    5,
+	}
+)
+
+func (x CameraState) Enum() *CameraState {
+	p := new(CameraState)
+	*p = x
+	return p
+}
+
+func (x CameraState) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CameraState) Descriptor() protoreflect.EnumDescriptor {
+	return file_hub_proto_enumTypes[3].Descriptor()
+}
+
+func (CameraState) Type() protoreflect.EnumType {
+	return &file_hub_proto_enumTypes[3]
+}
+
+func (x CameraState) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CameraState.Descriptor instead.
+func (CameraState) EnumDescriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{3}
+}
+
+type CameraEventKind int32
+
+const (
+	CameraEventKind_CAMERA_EVENT_KIND_UNSPECIFIED CameraEventKind = 0
+	CameraEventKind_CAMERA_EVENT_KIND_MOTION      CameraEventKind = 1
+	CameraEventKind_CAMERA_EVENT_KIND_TAMPER      CameraEventKind = 2
+	CameraEventKind_CAMERA_EVENT_KIND_INPUT       CameraEventKind = 3
+)
+
+// Enum value maps for CameraEventKind.
+var (
+	CameraEventKind_name = map[int32]string{
+		0: "CAMERA_EVENT_KIND_UNSPECIFIED",
+		1: "CAMERA_EVENT_KIND_MOTION",
+		2: "CAMERA_EVENT_KIND_TAMPER",
+		3: "CAMERA_EVENT_KIND_INPUT",
+	}
+	CameraEventKind_value = map[string]int32{
+		"CAMERA_EVENT_KIND_UNSPECIFIED": 0,
+		"CAMERA_EVENT_KIND_MOTION":      1,
+		"CAMERA_EVENT_KIND_TAMPER":      2,
+		"CAMERA_EVENT_KIND_INPUT":       3,
+	}
+)
+
+func (x CameraEventKind) Enum() *CameraEventKind {
+	p := new(CameraEventKind)
+	*p = x
+	return p
+}
+
+func (x CameraEventKind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CameraEventKind) Descriptor() protoreflect.EnumDescriptor {
+	return file_hub_proto_enumTypes[4].Descriptor()
+}
+
+func (CameraEventKind) Type() protoreflect.EnumType {
+	return &file_hub_proto_enumTypes[4]
+}
+
+func (x CameraEventKind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CameraEventKind.Descriptor instead.
+func (CameraEventKind) EnumDescriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{4}
+}
+
 type DownstreamMediaFrameType int32
 
 const (
@@ -76,6 +312,10 @@ const (
 	DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_RTP         DownstreamMediaFrameType = 1
 	DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_RTCP        DownstreamMediaFrameType = 2
 	DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_SDP         DownstreamMediaFrameType = 3
+	// Starts a streaming session of the stream, before its SDP banner
+	DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_OPEN DownstreamMediaFrameType = 4
+	// Ends the streaming session of the stream
+	DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_CLOSE DownstreamMediaFrameType = 5
 )
 
 // Enum value maps for DownstreamMediaFrameType.
@@ -85,12 +325,16 @@ var (
 		1: "DOWNSTREAM_MEDIA_FRAME_TYPE_RTP",
 		2: "DOWNSTREAM_MEDIA_FRAME_TYPE_RTCP",
 		3: "DOWNSTREAM_MEDIA_FRAME_TYPE_SDP",
+		4: "DOWNSTREAM_MEDIA_FRAME_TYPE_OPEN",
+		5: "DOWNSTREAM_MEDIA_FRAME_TYPE_CLOSE",
 	}
 	DownstreamMediaFrameType_value = map[string]int32{
 		"DOWNSTREAM_MEDIA_FRAME_TYPE_UNSPECIFIED": 0,
 		"DOWNSTREAM_MEDIA_FRAME_TYPE_RTP":         1,
 		"DOWNSTREAM_MEDIA_FRAME_TYPE_RTCP":        2,
 		"DOWNSTREAM_MEDIA_FRAME_TYPE_SDP":         3,
+		"DOWNSTREAM_MEDIA_FRAME_TYPE_OPEN":        4,
+		"DOWNSTREAM_MEDIA_FRAME_TYPE_CLOSE":       5,
 	}
 )
 
@@ -105,11 +349,11 @@ func (x DownstreamMediaFrameType) String() string {
 }
 
 func (DownstreamMediaFrameType) Descriptor() protoreflect.EnumDescriptor {
-	return file_hub_proto_enumTypes[1].Descriptor()
+	return file_hub_proto_enumTypes[5].Descriptor()
 }
 
 func (DownstreamMediaFrameType) Type() protoreflect.EnumType {
-	return &file_hub_proto_enumTypes[1]
+	return &file_hub_proto_enumTypes[5]
 }
 
 func (x DownstreamMediaFrameType) Number() protoreflect.EnumNumber {
@@ -118,7 +362,62 @@ func (x DownstreamMediaFrameType) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use DownstreamMediaFrameType.Descriptor instead.
 func (DownstreamMediaFrameType) EnumDescriptor() ([]byte, []int) {
-	return file_hub_proto_rawDescGZIP(), []int{1}
+	return file_hub_proto_rawDescGZIP(), []int{5}
+}
+
+type RegistrationKind int32
+
+const (
+	RegistrationKind_REGISTRATION_KIND_UNSPECIFIED RegistrationKind = 0
+	// The complete set of streams of the agent, any other stream is removed
+	RegistrationKind_REGISTRATION_KIND_SNAPSHOT RegistrationKind = 1
+	// Changes to the set of streams of the agent
+	RegistrationKind_REGISTRATION_KIND_DELTA RegistrationKind = 2
+	// Nothing changed, the streams of the agent are still there
+	RegistrationKind_REGISTRATION_KIND_HEARTBEAT RegistrationKind = 3
+)
+
+// Enum value maps for RegistrationKind.
+var (
+	RegistrationKind_name = map[int32]string{
+		0: "REGISTRATION_KIND_UNSPECIFIED",
+		1: "REGISTRATION_KIND_SNAPSHOT",
+		2: "REGISTRATION_KIND_DELTA",
+		3: "REGISTRATION_KIND_HEARTBEAT",
+	}
+	RegistrationKind_value = map[string]int32{
+		"REGISTRATION_KIND_UNSPECIFIED": 0,
+		"REGISTRATION_KIND_SNAPSHOT":    1,
+		"REGISTRATION_KIND_DELTA":       2,
+		"REGISTRATION_KIND_HEARTBEAT":   3,
+	}
+)
+
+func (x RegistrationKind) Enum() *RegistrationKind {
+	p := new(RegistrationKind)
+	*p = x
+	return p
+}
+
+func (x RegistrationKind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RegistrationKind) Descriptor() protoreflect.EnumDescriptor {
+	return file_hub_proto_enumTypes[6].Descriptor()
+}
+
+func (RegistrationKind) Type() protoreflect.EnumType {
+	return &file_hub_proto_enumTypes[6]
+}
+
+func (x RegistrationKind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RegistrationKind.Descriptor instead.
+func (RegistrationKind) EnumDescriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{6}
 }
 
 type Status struct {
@@ -269,18 +568,24 @@ func (*None) Descriptor() ([]byte, []int) {
 	return file_hub_proto_rawDescGZIP(), []int{2}
 }
 
-// What should be done
-type DownstreamControlRequest struct {
+type PTZCommand struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	StreamID string                `protobuf:"bytes,1,opt,name=streamID,proto3" json:"streamID,omitempty"`
-	Command  DownstreamCommandType `protobuf:"varint,2,opt,name=command,proto3,enum=cams.api.hub.DownstreamCommandType" json:"command,omitempty"`
+	Action PTZAction `protobuf:"varint,1,opt,name=action,proto3,enum=cams.api.hub.PTZAction" json:"action,omitempty"`
+	// For a MOVE, the velocities in [-1,1]
+	Pan  float32 `protobuf:"fixed32,2,opt,name=pan,proto3" json:"pan,omitempty"`
+	Tilt float32 `protobuf:"fixed32,3,opt,name=tilt,proto3" json:"tilt,omitempty"`
+	Zoom float32 `protobuf:"fixed32,4,opt,name=zoom,proto3" json:"zoom,omitempty"`
+	// For a MOVE, how long (in milliseconds) the camera moves unless stopped before
+	Timeout uint32 `protobuf:"varint,5,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	// For a GOTO_PRESET, the token of the preset
+	Preset string `protobuf:"bytes,6,opt,name=preset,proto3" json:"preset,omitempty"`
 }
 
-func (x *DownstreamControlRequest) Reset() {
-	*x = DownstreamControlRequest{}
+func (x *PTZCommand) Reset() {
+	*x = PTZCommand{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_hub_proto_msgTypes[3]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -288,13 +593,13 @@ func (x *DownstreamControlRequest) Reset() {
 	}
 }
 
-func (x *DownstreamControlRequest) String() string {
+func (x *PTZCommand) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DownstreamControlRequest) ProtoMessage() {}
+func (*PTZCommand) ProtoMessage() {}
 
-func (x *DownstreamControlRequest) ProtoReflect() protoreflect.Message {
+func (x *PTZCommand) ProtoReflect() protoreflect.Message {
 	mi := &file_hub_proto_msgTypes[3]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -306,36 +611,64 @@ func (x *DownstreamControlRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DownstreamControlRequest.ProtoReflect.Descriptor instead.
-func (*DownstreamControlRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use PTZCommand.ProtoReflect.Descriptor instead.
+func (*PTZCommand) Descriptor() ([]byte, []int) {
 	return file_hub_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *DownstreamControlRequest) GetStreamID() string {
+func (x *PTZCommand) GetAction() PTZAction {
 	if x != nil {
-		return x.StreamID
+		return x.Action
 	}
-	return ""
+	return PTZAction_PTZ_ACTION_UNSPECIFIED
 }
 
-func (x *DownstreamControlRequest) GetCommand() DownstreamCommandType {
+func (x *PTZCommand) GetPan() float32 {
 	if x != nil {
-		return x.Command
+		return x.Pan
 	}
-	return DownstreamCommandType_DOWNSTREAM_COMMAND_TYPE_UNSPECIFIED
+	return 0
 }
 
-type DownstreamMediaFrame struct {
+func (x *PTZCommand) GetTilt() float32 {
+	if x != nil {
+		return x.Tilt
+	}
+	return 0
+}
+
+func (x *PTZCommand) GetZoom() float32 {
+	if x != nil {
+		return x.Zoom
+	}
+	return 0
+}
+
+func (x *PTZCommand) GetTimeout() uint32 {
+	if x != nil {
+		return x.Timeout
+	}
+	return 0
+}
+
+func (x *PTZCommand) GetPreset() string {
+	if x != nil {
+		return x.Preset
+	}
+	return ""
+}
+
+type PTZPreset struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Type    DownstreamMediaFrameType `protobuf:"varint,2,opt,name=type,proto3,enum=cams.api.hub.DownstreamMediaFrameType" json:"type,omitempty"`
-	Payload []byte                   `protobuf:"bytes,3,opt,name=payload,proto3" json:"payload,omitempty"`
+	Token string `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	Name  string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
 }
 
-func (x *DownstreamMediaFrame) Reset() {
-	*x = DownstreamMediaFrame{}
+func (x *PTZPreset) Reset() {
+	*x = PTZPreset{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_hub_proto_msgTypes[4]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -343,13 +676,13 @@ func (x *DownstreamMediaFrame) Reset() {
 	}
 }
 
-func (x *DownstreamMediaFrame) String() string {
+func (x *PTZPreset) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DownstreamMediaFrame) ProtoMessage() {}
+func (*PTZPreset) ProtoMessage() {}
 
-func (x *DownstreamMediaFrame) ProtoReflect() protoreflect.Message {
+func (x *PTZPreset) ProtoReflect() protoreflect.Message {
 	mi := &file_hub_proto_msgTypes[4]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -361,35 +694,47 @@ func (x *DownstreamMediaFrame) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DownstreamMediaFrame.ProtoReflect.Descriptor instead.
-func (*DownstreamMediaFrame) Descriptor() ([]byte, []int) {
+// Deprecated: Use PTZPreset.ProtoReflect.Descriptor instead.
+func (*PTZPreset) Descriptor() ([]byte, []int) {
 	return file_hub_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *DownstreamMediaFrame) GetType() DownstreamMediaFrameType {
+func (x *PTZPreset) GetToken() string {
 	if x != nil {
-		return x.Type
+		return x.Token
 	}
-	return DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_UNSPECIFIED
+	return ""
 }
 
-func (x *DownstreamMediaFrame) GetPayload() []byte {
+func (x *PTZPreset) GetName() string {
 	if x != nil {
-		return x.Payload
+		return x.Name
 	}
-	return nil
+	return ""
 }
 
-type RegisterRequest struct {
+// What should be done
+type DownstreamControlRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Id *StreamId `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	StreamID string                `protobuf:"bytes,1,opt,name=streamID,proto3" json:"streamID,omitempty"`
+	Command  DownstreamCommandType `protobuf:"varint,2,opt,name=command,proto3,enum=cams.api.hub.DownstreamCommandType" json:"command,omitempty"`
+	// Echoed in the reply of the agent
+	RequestID string `protobuf:"bytes,3,opt,name=requestID,proto3" json:"requestID,omitempty"`
+	// For a RECONCILE command, the complete set of streams expected to be playing.
+	// Any other stream of the agent is expected to be stopped.
+	Playing []string `protobuf:"bytes,4,rep,name=playing,proto3" json:"playing,omitempty"`
+	// For a TRIGGER command, how long (in seconds) the live media are uploaded
+	// after the pre-event buffer. The agent applies its default if zero.
+	Duration uint32 `protobuf:"varint,5,opt,name=duration,proto3" json:"duration,omitempty"`
+	// For a PTZ command, what to do
+	Ptz *PTZCommand `protobuf:"bytes,6,opt,name=ptz,proto3" json:"ptz,omitempty"`
 }
 
-func (x *RegisterRequest) Reset() {
-	*x = RegisterRequest{}
+func (x *DownstreamControlRequest) Reset() {
+	*x = DownstreamControlRequest{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_hub_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -397,13 +742,13 @@ func (x *RegisterRequest) Reset() {
 	}
 }
 
-func (x *RegisterRequest) String() string {
+func (x *DownstreamControlRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RegisterRequest) ProtoMessage() {}
+func (*DownstreamControlRequest) ProtoMessage() {}
 
-func (x *RegisterRequest) ProtoReflect() protoreflect.Message {
+func (x *DownstreamControlRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_hub_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -415,28 +760,72 @@ func (x *RegisterRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RegisterRequest.ProtoReflect.Descriptor instead.
-func (*RegisterRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use DownstreamControlRequest.ProtoReflect.Descriptor instead.
+func (*DownstreamControlRequest) Descriptor() ([]byte, []int) {
 	return file_hub_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *RegisterRequest) GetId() *StreamId {
+func (x *DownstreamControlRequest) GetStreamID() string {
 	if x != nil {
-		return x.Id
+		return x.StreamID
+	}
+	return ""
+}
+
+func (x *DownstreamControlRequest) GetCommand() DownstreamCommandType {
+	if x != nil {
+		return x.Command
+	}
+	return DownstreamCommandType_DOWNSTREAM_COMMAND_TYPE_UNSPECIFIED
+}
+
+func (x *DownstreamControlRequest) GetRequestID() string {
+	if x != nil {
+		return x.RequestID
+	}
+	return ""
+}
+
+func (x *DownstreamControlRequest) GetPlaying() []string {
+	if x != nil {
+		return x.Playing
 	}
 	return nil
 }
 
-type PlayRequest struct {
+func (x *DownstreamControlRequest) GetDuration() uint32 {
+	if x != nil {
+		return x.Duration
+	}
+	return 0
+}
+
+func (x *DownstreamControlRequest) GetPtz() *PTZCommand {
+	if x != nil {
+		return x.Ptz
+	}
+	return nil
+}
+
+// The outcome of a DownstreamControlRequest
+type UpstreamControlReply struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Id *StreamId `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	RequestID string              `protobuf:"bytes,1,opt,name=requestID,proto3" json:"requestID,omitempty"`
+	Status    UpstreamReplyStatus `protobuf:"varint,2,opt,name=status,proto3,enum=cams.api.hub.UpstreamReplyStatus" json:"status,omitempty"`
+	Message   string              `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	// For a PTZ command listing the presets
+	Presets []*PTZPreset `protobuf:"bytes,4,rep,name=presets,proto3" json:"presets,omitempty"`
+	// For a SNAPSHOT command, the JPEG image
+	Snapshot []byte `protobuf:"bytes,5,opt,name=snapshot,proto3" json:"snapshot,omitempty"`
+	// For a SNAPSHOT command, Unix timestamp (in milliseconds) of the image
+	Taken int64 `protobuf:"varint,6,opt,name=taken,proto3" json:"taken,omitempty"`
 }
 
-func (x *PlayRequest) Reset() {
-	*x = PlayRequest{}
+func (x *UpstreamControlReply) Reset() {
+	*x = UpstreamControlReply{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_hub_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -444,13 +833,13 @@ func (x *PlayRequest) Reset() {
 	}
 }
 
-func (x *PlayRequest) String() string {
+func (x *UpstreamControlReply) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PlayRequest) ProtoMessage() {}
+func (*UpstreamControlReply) ProtoMessage() {}
 
-func (x *PlayRequest) ProtoReflect() protoreflect.Message {
+func (x *UpstreamControlReply) ProtoReflect() protoreflect.Message {
 	mi := &file_hub_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -462,43 +851,1757 @@ func (x *PlayRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PlayRequest.ProtoReflect.Descriptor instead.
-func (*PlayRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use UpstreamControlReply.ProtoReflect.Descriptor instead.
+func (*UpstreamControlReply) Descriptor() ([]byte, []int) {
 	return file_hub_proto_rawDescGZIP(), []int{6}
 }
 
-func (x *PlayRequest) GetId() *StreamId {
+func (x *UpstreamControlReply) GetRequestID() string {
 	if x != nil {
-		return x.Id
+		return x.RequestID
 	}
-	return nil
+	return ""
 }
 
-type PauseRequest struct {
-	state         protoimpl.MessageState
+func (x *UpstreamControlReply) GetStatus() UpstreamReplyStatus {
+	if x != nil {
+		return x.Status
+	}
+	return UpstreamReplyStatus_UPSTREAM_REPLY_STATUS_UNSPECIFIED
+}
+
+func (x *UpstreamControlReply) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *UpstreamControlReply) GetPresets() []*PTZPreset {
+	if x != nil {
+		return x.Presets
+	}
+	return nil
+}
+
+func (x *UpstreamControlReply) GetSnapshot() []byte {
+	if x != nil {
+		return x.Snapshot
+	}
+	return nil
+}
+
+func (x *UpstreamControlReply) GetTaken() int64 {
+	if x != nil {
+		return x.Taken
+	}
+	return 0
+}
+
+type StreamStats struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RtpPackets  uint64 `protobuf:"varint,1,opt,name=rtpPackets,proto3" json:"rtpPackets,omitempty"`
+	RtpBytes    uint64 `protobuf:"varint,2,opt,name=rtpBytes,proto3" json:"rtpBytes,omitempty"`
+	RtcpPackets uint64 `protobuf:"varint,3,opt,name=rtcpPackets,proto3" json:"rtcpPackets,omitempty"`
+	Restarts    uint64 `protobuf:"varint,4,opt,name=restarts,proto3" json:"restarts,omitempty"`
+	// Unix timestamp (in milliseconds) of the last packet received from the camera
+	LastPacket int64 `protobuf:"varint,5,opt,name=lastPacket,proto3" json:"lastPacket,omitempty"`
+}
+
+func (x *StreamStats) Reset() {
+	*x = StreamStats{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hub_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StreamStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamStats) ProtoMessage() {}
+
+func (x *StreamStats) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamStats.ProtoReflect.Descriptor instead.
+func (*StreamStats) Descriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *StreamStats) GetRtpPackets() uint64 {
+	if x != nil {
+		return x.RtpPackets
+	}
+	return 0
+}
+
+func (x *StreamStats) GetRtpBytes() uint64 {
+	if x != nil {
+		return x.RtpBytes
+	}
+	return 0
+}
+
+func (x *StreamStats) GetRtcpPackets() uint64 {
+	if x != nil {
+		return x.RtcpPackets
+	}
+	return 0
+}
+
+func (x *StreamStats) GetRestarts() uint64 {
+	if x != nil {
+		return x.Restarts
+	}
+	return 0
+}
+
+func (x *StreamStats) GetLastPacket() int64 {
+	if x != nil {
+		return x.LastPacket
+	}
+	return 0
+}
+
+// The state of a camera, as seen by its agent
+type CameraStatus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	StreamID string      `protobuf:"bytes,1,opt,name=streamID,proto3" json:"streamID,omitempty"`
+	State    CameraState `protobuf:"varint,2,opt,name=state,proto3,enum=cams.api.hub.CameraState" json:"state,omitempty"`
+	// The last error that aborted the stream of the camera, cleared once the
+	// stream flows again
+	LastError string       `protobuf:"bytes,3,opt,name=lastError,proto3" json:"lastError,omitempty"`
+	Stats     *StreamStats `protobuf:"bytes,4,opt,name=stats,proto3" json:"stats,omitempty"`
+}
+
+func (x *CameraStatus) Reset() {
+	*x = CameraStatus{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hub_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CameraStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CameraStatus) ProtoMessage() {}
+
+func (x *CameraStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CameraStatus.ProtoReflect.Descriptor instead.
+func (*CameraStatus) Descriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *CameraStatus) GetStreamID() string {
+	if x != nil {
+		return x.StreamID
+	}
+	return ""
+}
+
+func (x *CameraStatus) GetState() CameraState {
+	if x != nil {
+		return x.State
+	}
+	return CameraState_CAMERA_STATE_UNSPECIFIED
+}
+
+func (x *CameraStatus) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+func (x *CameraStatus) GetStats() *StreamStats {
+	if x != nil {
+		return x.Stats
+	}
+	return nil
+}
+
+// An event notified by a camera, normalised by its agent
+type CameraEvent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	StreamID string          `protobuf:"bytes,1,opt,name=streamID,proto3" json:"streamID,omitempty"`
+	Kind     CameraEventKind `protobuf:"varint,2,opt,name=kind,proto3,enum=cams.api.hub.CameraEventKind" json:"kind,omitempty"`
+	// Does the event start or end a condition (e.g. motion detected or over)
+	Active bool `protobuf:"varint,3,opt,name=active,proto3" json:"active,omitempty"`
+	// Unix timestamp (in milliseconds) of the event, by the clock of the camera
+	Time int64 `protobuf:"varint,4,opt,name=time,proto3" json:"time,omitempty"`
+	// The ONVIF topic of the event, as notified by the camera
+	Topic string `protobuf:"bytes,5,opt,name=topic,proto3" json:"topic,omitempty"`
+	// What produced the event in the camera (e.g. a video source, an input)
+	Source map[string]string `protobuf:"bytes,6,rep,name=source,proto3" json:"source,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// Unix timestamp (in milliseconds) of the reception of the event by the hub
+	Received int64 `protobuf:"varint,7,opt,name=received,proto3" json:"received,omitempty"`
+}
+
+func (x *CameraEvent) Reset() {
+	*x = CameraEvent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hub_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CameraEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CameraEvent) ProtoMessage() {}
+
+func (x *CameraEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CameraEvent.ProtoReflect.Descriptor instead.
+func (*CameraEvent) Descriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *CameraEvent) GetStreamID() string {
+	if x != nil {
+		return x.StreamID
+	}
+	return ""
+}
+
+func (x *CameraEvent) GetKind() CameraEventKind {
+	if x != nil {
+		return x.Kind
+	}
+	return CameraEventKind_CAMERA_EVENT_KIND_UNSPECIFIED
+}
+
+func (x *CameraEvent) GetActive() bool {
+	if x != nil {
+		return x.Active
+	}
+	return false
+}
+
+func (x *CameraEvent) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *CameraEvent) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *CameraEvent) GetSource() map[string]string {
+	if x != nil {
+		return x.Source
+	}
+	return nil
+}
+
+func (x *CameraEvent) GetReceived() int64 {
+	if x != nil {
+		return x.Received
+	}
+	return 0
+}
+
+// What the agent tells the hub on the control stream
+type UpstreamControlMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Body:
+	//	*UpstreamControlMessage_Reply
+	//	*UpstreamControlMessage_Status
+	//	*UpstreamControlMessage_Event
+	Body isUpstreamControlMessage_Body `protobuf_oneof:"body"`
+}
+
+func (x *UpstreamControlMessage) Reset() {
+	*x = UpstreamControlMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hub_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UpstreamControlMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpstreamControlMessage) ProtoMessage() {}
+
+func (x *UpstreamControlMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpstreamControlMessage.ProtoReflect.Descriptor instead.
+func (*UpstreamControlMessage) Descriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{10}
+}
+
+func (m *UpstreamControlMessage) GetBody() isUpstreamControlMessage_Body {
+	if m != nil {
+		return m.Body
+	}
+	return nil
+}
+
+func (x *UpstreamControlMessage) GetReply() *UpstreamControlReply {
+	if x, ok := x.GetBody().(*UpstreamControlMessage_Reply); ok {
+		return x.Reply
+	}
+	return nil
+}
+
+func (x *UpstreamControlMessage) GetStatus() *CameraStatus {
+	if x, ok := x.GetBody().(*UpstreamControlMessage_Status); ok {
+		return x.Status
+	}
+	return nil
+}
+
+func (x *UpstreamControlMessage) GetEvent() *CameraEvent {
+	if x, ok := x.GetBody().(*UpstreamControlMessage_Event); ok {
+		return x.Event
+	}
+	return nil
+}
+
+type isUpstreamControlMessage_Body interface {
+	isUpstreamControlMessage_Body()
+}
+
+type UpstreamControlMessage_Reply struct {
+	Reply *UpstreamControlReply `protobuf:"bytes,1,opt,name=reply,proto3,oneof"`
+}
+
+type UpstreamControlMessage_Status struct {
+	Status *CameraStatus `protobuf:"bytes,2,opt,name=status,proto3,oneof"`
+}
+
+type UpstreamControlMessage_Event struct {
+	Event *CameraEvent `protobuf:"bytes,3,opt,name=event,proto3,oneof"`
+}
+
+func (*UpstreamControlMessage_Reply) isUpstreamControlMessage_Body() {}
+
+func (*UpstreamControlMessage_Status) isUpstreamControlMessage_Body() {}
+
+func (*UpstreamControlMessage_Event) isUpstreamControlMessage_Body() {}
+
+// The frames of all the streams of an agent are multiplexed on the same upload,
+// each frame tells the stream it belongs to.
+type DownstreamMediaFrame struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type     DownstreamMediaFrameType `protobuf:"varint,2,opt,name=type,proto3,enum=cams.api.hub.DownstreamMediaFrameType" json:"type,omitempty"`
+	Payload  []byte                   `protobuf:"bytes,3,opt,name=payload,proto3" json:"payload,omitempty"`
+	StreamID string                   `protobuf:"bytes,4,opt,name=streamID,proto3" json:"streamID,omitempty"`
+	// Position of the media in the SDP banner of the stream, for RTP and RTCP frames
+	MediaIndex uint32 `protobuf:"varint,5,opt,name=mediaIndex,proto3" json:"mediaIndex,omitempty"`
+	// When the agent received the packet from the camera, in microseconds since the epoch
+	ReceivedAt int64 `protobuf:"varint,6,opt,name=receivedAt,proto3" json:"receivedAt,omitempty"`
+	// Incremented for each frame of the upload, including the frames dropped by the agent
+	Sequence uint64 `protobuf:"varint,7,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	// Set on RTP packets starting a keyframe, when the agent knows the codec
+	Keyframe bool `protobuf:"varint,8,opt,name=keyframe,proto3" json:"keyframe,omitempty"`
+	// Set on the frames of a recorded session, i.e. footage buffered by the agent
+	// and uploaded late. A recorded session runs aside the live session of the
+	// same stream, both are delimited by their own OPEN and CLOSE frames.
+	Recorded bool `protobuf:"varint,9,opt,name=recorded,proto3" json:"recorded,omitempty"`
+}
+
+func (x *DownstreamMediaFrame) Reset() {
+	*x = DownstreamMediaFrame{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hub_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DownstreamMediaFrame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownstreamMediaFrame) ProtoMessage() {}
+
+func (x *DownstreamMediaFrame) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownstreamMediaFrame.ProtoReflect.Descriptor instead.
+func (*DownstreamMediaFrame) Descriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *DownstreamMediaFrame) GetType() DownstreamMediaFrameType {
+	if x != nil {
+		return x.Type
+	}
+	return DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_UNSPECIFIED
+}
+
+func (x *DownstreamMediaFrame) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *DownstreamMediaFrame) GetStreamID() string {
+	if x != nil {
+		return x.StreamID
+	}
+	return ""
+}
+
+func (x *DownstreamMediaFrame) GetMediaIndex() uint32 {
+	if x != nil {
+		return x.MediaIndex
+	}
+	return 0
+}
+
+func (x *DownstreamMediaFrame) GetReceivedAt() int64 {
+	if x != nil {
+		return x.ReceivedAt
+	}
+	return 0
+}
+
+func (x *DownstreamMediaFrame) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *DownstreamMediaFrame) GetKeyframe() bool {
+	if x != nil {
+		return x.Keyframe
+	}
+	return false
+}
+
+func (x *DownstreamMediaFrame) GetRecorded() bool {
+	if x != nil {
+		return x.Recorded
+	}
+	return false
+}
+
+type RegisterRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id *StreamId `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// What the agent knows about the camera, possibly partial
+	Metadata *CameraMetadata `protobuf:"bytes,2,opt,name=metadata,proto3" json:"metadata,omitempty"`
+}
+
+func (x *RegisterRequest) Reset() {
+	*x = RegisterRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hub_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RegisterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterRequest) ProtoMessage() {}
+
+func (x *RegisterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterRequest.ProtoReflect.Descriptor instead.
+func (*RegisterRequest) Descriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *RegisterRequest) GetId() *StreamId {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *RegisterRequest) GetMetadata() *CameraMetadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type RegistrationMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Echoed in the acknowledgement
+	Sequence uint64           `protobuf:"varint,1,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	Kind     RegistrationKind `protobuf:"varint,2,opt,name=kind,proto3,enum=cams.api.hub.RegistrationKind" json:"kind,omitempty"`
+	// For a SNAPSHOT, all the streams. For a DELTA, the new streams.
+	Added []*RegisterRequest `protobuf:"bytes,3,rep,name=added,proto3" json:"added,omitempty"`
+	// For a DELTA, the streams whose metadata changed
+	Updated []*RegisterRequest `protobuf:"bytes,4,rep,name=updated,proto3" json:"updated,omitempty"`
+	// For a DELTA, the IDs of the streams gone
+	Removed []string `protobuf:"bytes,5,rep,name=removed,proto3" json:"removed,omitempty"`
+}
+
+func (x *RegistrationMessage) Reset() {
+	*x = RegistrationMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hub_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RegistrationMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegistrationMessage) ProtoMessage() {}
+
+func (x *RegistrationMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegistrationMessage.ProtoReflect.Descriptor instead.
+func (*RegistrationMessage) Descriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *RegistrationMessage) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *RegistrationMessage) GetKind() RegistrationKind {
+	if x != nil {
+		return x.Kind
+	}
+	return RegistrationKind_REGISTRATION_KIND_UNSPECIFIED
+}
+
+func (x *RegistrationMessage) GetAdded() []*RegisterRequest {
+	if x != nil {
+		return x.Added
+	}
+	return nil
+}
+
+func (x *RegistrationMessage) GetUpdated() []*RegisterRequest {
+	if x != nil {
+		return x.Updated
+	}
+	return nil
+}
+
+func (x *RegistrationMessage) GetRemoved() []string {
+	if x != nil {
+		return x.Removed
+	}
+	return nil
+}
+
+type RegistrationRejection struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	StreamID string `protobuf:"bytes,1,opt,name=streamID,proto3" json:"streamID,omitempty"`
+	Reason   string `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+}
+
+func (x *RegistrationRejection) Reset() {
+	*x = RegistrationRejection{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hub_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RegistrationRejection) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegistrationRejection) ProtoMessage() {}
+
+func (x *RegistrationRejection) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegistrationRejection.ProtoReflect.Descriptor instead.
+func (*RegistrationRejection) Descriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *RegistrationRejection) GetStreamID() string {
+	if x != nil {
+		return x.StreamID
+	}
+	return ""
+}
+
+func (x *RegistrationRejection) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type RegistrationAck struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sequence uint64 `protobuf:"varint,1,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	// The streams of the message that could not be registered, the others were
+	Rejected []*RegistrationRejection `protobuf:"bytes,2,rep,name=rejected,proto3" json:"rejected,omitempty"`
+}
+
+func (x *RegistrationAck) Reset() {
+	*x = RegistrationAck{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hub_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RegistrationAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegistrationAck) ProtoMessage() {}
+
+func (x *RegistrationAck) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegistrationAck.ProtoReflect.Descriptor instead.
+func (*RegistrationAck) Descriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *RegistrationAck) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *RegistrationAck) GetRejected() []*RegistrationRejection {
+	if x != nil {
+		return x.Rejected
+	}
+	return nil
+}
+
+type CameraMetadata struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Manufacturer string `protobuf:"bytes,1,opt,name=manufacturer,proto3" json:"manufacturer,omitempty"`
+	Model        string `protobuf:"bytes,2,opt,name=model,proto3" json:"model,omitempty"`
+	Firmware     string `protobuf:"bytes,3,opt,name=firmware,proto3" json:"firmware,omitempty"`
+	// The ONVIF media profiles, the first one is streamed
+	Profiles []*CameraProfile `protobuf:"bytes,4,rep,name=profiles,proto3" json:"profiles,omitempty"`
+}
+
+func (x *CameraMetadata) Reset() {
+	*x = CameraMetadata{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hub_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CameraMetadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CameraMetadata) ProtoMessage() {}
+
+func (x *CameraMetadata) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CameraMetadata.ProtoReflect.Descriptor instead.
+func (*CameraMetadata) Descriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *CameraMetadata) GetManufacturer() string {
+	if x != nil {
+		return x.Manufacturer
+	}
+	return ""
+}
+
+func (x *CameraMetadata) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *CameraMetadata) GetFirmware() string {
+	if x != nil {
+		return x.Firmware
+	}
+	return ""
+}
+
+func (x *CameraMetadata) GetProfiles() []*CameraProfile {
+	if x != nil {
+		return x.Profiles
+	}
+	return nil
+}
+
+type CameraProfile struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Token string `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	Name  string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// The video codec, e.g. "H264" or "M-JPEG"
+	Codec  string  `protobuf:"bytes,3,opt,name=codec,proto3" json:"codec,omitempty"`
+	Width  uint32  `protobuf:"varint,4,opt,name=width,proto3" json:"width,omitempty"`
+	Height uint32  `protobuf:"varint,5,opt,name=height,proto3" json:"height,omitempty"`
+	Fps    float32 `protobuf:"fixed32,6,opt,name=fps,proto3" json:"fps,omitempty"`
+}
+
+func (x *CameraProfile) Reset() {
+	*x = CameraProfile{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hub_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CameraProfile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CameraProfile) ProtoMessage() {}
+
+func (x *CameraProfile) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CameraProfile.ProtoReflect.Descriptor instead.
+func (*CameraProfile) Descriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *CameraProfile) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *CameraProfile) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CameraProfile) GetCodec() string {
+	if x != nil {
+		return x.Codec
+	}
+	return ""
+}
+
+func (x *CameraProfile) GetWidth() uint32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *CameraProfile) GetHeight() uint32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *CameraProfile) GetFps() float32 {
+	if x != nil {
+		return x.Fps
+	}
+	return 0
+}
+
+type PlayRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id *StreamId `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *PlayRequest) Reset() {
+	*x = PlayRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hub_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PlayRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlayRequest) ProtoMessage() {}
+
+func (x *PlayRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlayRequest.ProtoReflect.Descriptor instead.
+func (*PlayRequest) Descriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *PlayRequest) GetId() *StreamId {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+type PauseRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id *StreamId `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *PauseRequest) Reset() {
+	*x = PauseRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hub_proto_msgTypes[19]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PauseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PauseRequest) ProtoMessage() {}
+
+func (x *PauseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_proto_msgTypes[19]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PauseRequest.ProtoReflect.Descriptor instead.
+func (*PauseRequest) Descriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *PauseRequest) GetId() *StreamId {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+type StatusRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id *StreamId `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *StatusRequest) Reset() {
+	*x = StatusRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hub_proto_msgTypes[20]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusRequest) ProtoMessage() {}
+
+func (x *StatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_proto_msgTypes[20]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusRequest.ProtoReflect.Descriptor instead.
+func (*StatusRequest) Descriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *StatusRequest) GetId() *StreamId {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+type TriggerRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id *StreamId `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// How long (in seconds) the live media are uploaded, the agent default if zero
+	Duration uint32 `protobuf:"varint,2,opt,name=duration,proto3" json:"duration,omitempty"`
+}
+
+func (x *TriggerRequest) Reset() {
+	*x = TriggerRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hub_proto_msgTypes[21]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TriggerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TriggerRequest) ProtoMessage() {}
+
+func (x *TriggerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_proto_msgTypes[21]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TriggerRequest.ProtoReflect.Descriptor instead.
+func (*TriggerRequest) Descriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *TriggerRequest) GetId() *StreamId {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *TriggerRequest) GetDuration() uint32 {
+	if x != nil {
+		return x.Duration
+	}
+	return 0
+}
+
+type EventsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id *StreamId `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Only the events received after that Unix timestamp (in milliseconds)
+	Since int64 `protobuf:"varint,2,opt,name=since,proto3" json:"since,omitempty"`
+	// The maximum number of events returned, a default limit applies if zero
+	Limit uint32 `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+}
+
+func (x *EventsRequest) Reset() {
+	*x = EventsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hub_proto_msgTypes[22]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventsRequest) ProtoMessage() {}
+
+func (x *EventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_proto_msgTypes[22]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventsRequest.ProtoReflect.Descriptor instead.
+func (*EventsRequest) Descriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *EventsRequest) GetId() *StreamId {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *EventsRequest) GetSince() int64 {
+	if x != nil {
+		return x.Since
+	}
+	return 0
+}
+
+func (x *EventsRequest) GetLimit() uint32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type EventsReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Events []*CameraEvent `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+}
+
+func (x *EventsReply) Reset() {
+	*x = EventsReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hub_proto_msgTypes[23]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EventsReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventsReply) ProtoMessage() {}
+
+func (x *EventsReply) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_proto_msgTypes[23]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventsReply.ProtoReflect.Descriptor instead.
+func (*EventsReply) Descriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *EventsReply) GetEvents() []*CameraEvent {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+type PTZMoveRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id *StreamId `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// The velocities in [-1,1]
+	Pan  float32 `protobuf:"fixed32,2,opt,name=pan,proto3" json:"pan,omitempty"`
+	Tilt float32 `protobuf:"fixed32,3,opt,name=tilt,proto3" json:"tilt,omitempty"`
+	Zoom float32 `protobuf:"fixed32,4,opt,name=zoom,proto3" json:"zoom,omitempty"`
+	// How long (in milliseconds) the camera moves unless stopped before, one
+	// second if zero.
+	Timeout uint32 `protobuf:"varint,5,opt,name=timeout,proto3" json:"timeout,omitempty"`
+}
+
+func (x *PTZMoveRequest) Reset() {
+	*x = PTZMoveRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hub_proto_msgTypes[24]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PTZMoveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PTZMoveRequest) ProtoMessage() {}
+
+func (x *PTZMoveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_proto_msgTypes[24]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PTZMoveRequest.ProtoReflect.Descriptor instead.
+func (*PTZMoveRequest) Descriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *PTZMoveRequest) GetId() *StreamId {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *PTZMoveRequest) GetPan() float32 {
+	if x != nil {
+		return x.Pan
+	}
+	return 0
+}
+
+func (x *PTZMoveRequest) GetTilt() float32 {
+	if x != nil {
+		return x.Tilt
+	}
+	return 0
+}
+
+func (x *PTZMoveRequest) GetZoom() float32 {
+	if x != nil {
+		return x.Zoom
+	}
+	return 0
+}
+
+func (x *PTZMoveRequest) GetTimeout() uint32 {
+	if x != nil {
+		return x.Timeout
+	}
+	return 0
+}
+
+type PTZStopRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id *StreamId `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *PTZStopRequest) Reset() {
+	*x = PTZStopRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hub_proto_msgTypes[25]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PTZStopRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PTZStopRequest) ProtoMessage() {}
+
+func (x *PTZStopRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_proto_msgTypes[25]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PTZStopRequest.ProtoReflect.Descriptor instead.
+func (*PTZStopRequest) Descriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *PTZStopRequest) GetId() *StreamId {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+type PTZGotoPresetRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id     *StreamId `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Preset string    `protobuf:"bytes,2,opt,name=preset,proto3" json:"preset,omitempty"`
+}
+
+func (x *PTZGotoPresetRequest) Reset() {
+	*x = PTZGotoPresetRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hub_proto_msgTypes[26]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PTZGotoPresetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PTZGotoPresetRequest) ProtoMessage() {}
+
+func (x *PTZGotoPresetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_proto_msgTypes[26]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PTZGotoPresetRequest.ProtoReflect.Descriptor instead.
+func (*PTZGotoPresetRequest) Descriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *PTZGotoPresetRequest) GetId() *StreamId {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *PTZGotoPresetRequest) GetPreset() string {
+	if x != nil {
+		return x.Preset
+	}
+	return ""
+}
+
+type PTZPresetsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id *StreamId `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *PTZPresetsRequest) Reset() {
+	*x = PTZPresetsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hub_proto_msgTypes[27]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PTZPresetsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PTZPresetsRequest) ProtoMessage() {}
+
+func (x *PTZPresetsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_proto_msgTypes[27]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PTZPresetsRequest.ProtoReflect.Descriptor instead.
+func (*PTZPresetsRequest) Descriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *PTZPresetsRequest) GetId() *StreamId {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+type PTZPresetsReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Presets []*PTZPreset `protobuf:"bytes,1,rep,name=presets,proto3" json:"presets,omitempty"`
+}
+
+func (x *PTZPresetsReply) Reset() {
+	*x = PTZPresetsReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hub_proto_msgTypes[28]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PTZPresetsReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PTZPresetsReply) ProtoMessage() {}
+
+func (x *PTZPresetsReply) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_proto_msgTypes[28]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PTZPresetsReply.ProtoReflect.Descriptor instead.
+func (*PTZPresetsReply) Descriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *PTZPresetsReply) GetPresets() []*PTZPreset {
+	if x != nil {
+		return x.Presets
+	}
+	return nil
+}
+
+type SnapshotRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id *StreamId `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *SnapshotRequest) Reset() {
+	*x = SnapshotRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hub_proto_msgTypes[29]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SnapshotRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotRequest) ProtoMessage() {}
+
+func (x *SnapshotRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_proto_msgTypes[29]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotRequest.ProtoReflect.Descriptor instead.
+func (*SnapshotRequest) Descriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *SnapshotRequest) GetId() *StreamId {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+type ReloadAgentRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	User  string `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Agent string `protobuf:"bytes,2,opt,name=agent,proto3" json:"agent,omitempty"`
+}
+
+func (x *ReloadAgentRequest) Reset() {
+	*x = ReloadAgentRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hub_proto_msgTypes[30]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReloadAgentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReloadAgentRequest) ProtoMessage() {}
+
+func (x *ReloadAgentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_proto_msgTypes[30]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReloadAgentRequest.ProtoReflect.Descriptor instead.
+func (*ReloadAgentRequest) Descriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ReloadAgentRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *ReloadAgentRequest) GetAgent() string {
+	if x != nil {
+		return x.Agent
+	}
+	return ""
+}
+
+type StreamSnapshot struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Jpeg []byte `protobuf:"bytes,1,opt,name=jpeg,proto3" json:"jpeg,omitempty"`
+	// Unix timestamp (in milliseconds) of the image
+	Taken int64 `protobuf:"varint,2,opt,name=taken,proto3" json:"taken,omitempty"`
+}
+
+func (x *StreamSnapshot) Reset() {
+	*x = StreamSnapshot{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hub_proto_msgTypes[31]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StreamSnapshot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamSnapshot) ProtoMessage() {}
+
+func (x *StreamSnapshot) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_proto_msgTypes[31]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamSnapshot.ProtoReflect.Descriptor instead.
+func (*StreamSnapshot) Descriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *StreamSnapshot) GetJpeg() []byte {
+	if x != nil {
+		return x.Jpeg
+	}
+	return nil
+}
+
+func (x *StreamSnapshot) GetTaken() int64 {
+	if x != nil {
+		return x.Taken
+	}
+	return 0
+}
+
+type ListRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	User string `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	// Only the streams whose ID sorts after that one, for pagination
+	Start string `protobuf:"bytes,2,opt,name=start,proto3" json:"start,omitempty"`
+}
+
+func (x *ListRequest) Reset() {
+	*x = ListRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hub_proto_msgTypes[32]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRequest) ProtoMessage() {}
+
+func (x *ListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_proto_msgTypes[32]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRequest.ProtoReflect.Descriptor instead.
+func (*ListRequest) Descriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *ListRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *ListRequest) GetStart() string {
+	if x != nil {
+		return x.Start
+	}
+	return ""
+}
+
+type StreamSummary struct {
+	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
 	Id *StreamId `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Is the agent of the stream connected to the hub
+	Online bool `protobuf:"varint,2,opt,name=online,proto3" json:"online,omitempty"`
+	// A thumbnail of the latest snapshot of the stream, if any. Its image is
+	// empty when the snapshot could not be decoded.
+	Thumbnail *StreamSnapshot `protobuf:"bytes,3,opt,name=thumbnail,proto3" json:"thumbnail,omitempty"`
+	Metadata  *CameraMetadata `protobuf:"bytes,4,opt,name=metadata,proto3" json:"metadata,omitempty"`
 }
 
-func (x *PauseRequest) Reset() {
-	*x = PauseRequest{}
+func (x *StreamSummary) Reset() {
+	*x = StreamSummary{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_hub_proto_msgTypes[7]
+		mi := &file_hub_proto_msgTypes[33]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *PauseRequest) String() string {
+func (x *StreamSummary) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PauseRequest) ProtoMessage() {}
+func (*StreamSummary) ProtoMessage() {}
 
-func (x *PauseRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_hub_proto_msgTypes[7]
+func (x *StreamSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_proto_msgTypes[33]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -509,18 +2612,169 @@ func (x *PauseRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PauseRequest.ProtoReflect.Descriptor instead.
-func (*PauseRequest) Descriptor() ([]byte, []int) {
-	return file_hub_proto_rawDescGZIP(), []int{7}
+// Deprecated: Use StreamSummary.ProtoReflect.Descriptor instead.
+func (*StreamSummary) Descriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{33}
 }
 
-func (x *PauseRequest) GetId() *StreamId {
+func (x *StreamSummary) GetId() *StreamId {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *StreamSummary) GetOnline() bool {
+	if x != nil {
+		return x.Online
+	}
+	return false
+}
+
+func (x *StreamSummary) GetThumbnail() *StreamSnapshot {
+	if x != nil {
+		return x.Thumbnail
+	}
+	return nil
+}
+
+func (x *StreamSummary) GetMetadata() *CameraMetadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type ListReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Streams []*StreamSummary `protobuf:"bytes,1,rep,name=streams,proto3" json:"streams,omitempty"`
+}
+
+func (x *ListReply) Reset() {
+	*x = ListReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hub_proto_msgTypes[34]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListReply) ProtoMessage() {}
+
+func (x *ListReply) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_proto_msgTypes[34]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListReply.ProtoReflect.Descriptor instead.
+func (*ListReply) Descriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *ListReply) GetStreams() []*StreamSummary {
+	if x != nil {
+		return x.Streams
+	}
+	return nil
+}
+
+type StreamStatus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id *StreamId `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Is the agent of the stream connected to the hub
+	Online bool `protobuf:"varint,2,opt,name=online,proto3" json:"online,omitempty"`
+	// Unset while the agent has not reported the camera yet
+	Camera *CameraStatus `protobuf:"bytes,3,opt,name=camera,proto3" json:"camera,omitempty"`
+	// Unix timestamp (in milliseconds) of the last status reported by the agent
+	Updated int64 `protobuf:"varint,4,opt,name=updated,proto3" json:"updated,omitempty"`
+	// As registered by the agent
+	Metadata *CameraMetadata `protobuf:"bytes,5,opt,name=metadata,proto3" json:"metadata,omitempty"`
+}
+
+func (x *StreamStatus) Reset() {
+	*x = StreamStatus{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hub_proto_msgTypes[35]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StreamStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamStatus) ProtoMessage() {}
+
+func (x *StreamStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_proto_msgTypes[35]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamStatus.ProtoReflect.Descriptor instead.
+func (*StreamStatus) Descriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *StreamStatus) GetId() *StreamId {
 	if x != nil {
 		return x.Id
 	}
 	return nil
 }
 
+func (x *StreamStatus) GetOnline() bool {
+	if x != nil {
+		return x.Online
+	}
+	return false
+}
+
+func (x *StreamStatus) GetCamera() *CameraStatus {
+	if x != nil {
+		return x.Camera
+	}
+	return nil
+}
+
+func (x *StreamStatus) GetUpdated() int64 {
+	if x != nil {
+		return x.Updated
+	}
+	return 0
+}
+
+func (x *StreamStatus) GetMetadata() *CameraMetadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
 var File_hub_proto protoreflect.FileDescriptor
 
 var file_hub_proto_rawDesc = []byte{
@@ -533,77 +2787,433 @@ var file_hub_proto_rawDesc = []byte{
 	0x73, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12,
 	0x16, 0x0a, 0x06, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
 	0x06, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x22, 0x06, 0x0a, 0x04, 0x4e, 0x6f, 0x6e, 0x65, 0x22,
-	0x75, 0x0a, 0x18, 0x44, 0x6f, 0x77, 0x6e, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x43, 0x6f, 0x6e,
-	0x74, 0x72, 0x6f, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x73,
-	0x74, 0x72, 0x65, 0x61, 0x6d, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x73,
-	0x74, 0x72, 0x65, 0x61, 0x6d, 0x49, 0x44, 0x12, 0x3d, 0x0a, 0x07, 0x63, 0x6f, 0x6d, 0x6d, 0x61,
-	0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x23, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e,
-	0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x44, 0x6f, 0x77, 0x6e, 0x73, 0x74, 0x72, 0x65,
-	0x61, 0x6d, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x54, 0x79, 0x70, 0x65, 0x52, 0x07, 0x63,
-	0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x22, 0x6c, 0x0a, 0x14, 0x44, 0x6f, 0x77, 0x6e, 0x73, 0x74,
-	0x72, 0x65, 0x61, 0x6d, 0x4d, 0x65, 0x64, 0x69, 0x61, 0x46, 0x72, 0x61, 0x6d, 0x65, 0x12, 0x3a,
-	0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x26, 0x2e, 0x63,
+	0xa9, 0x01, 0x0a, 0x0a, 0x50, 0x54, 0x5a, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x12, 0x2f,
+	0x0a, 0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x17,
+	0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x50, 0x54,
+	0x5a, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12,
+	0x10, 0x0a, 0x03, 0x70, 0x61, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x02, 0x52, 0x03, 0x70, 0x61,
+	0x6e, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x69, 0x6c, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x02, 0x52,
+	0x04, 0x74, 0x69, 0x6c, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x7a, 0x6f, 0x6f, 0x6d, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x02, 0x52, 0x04, 0x7a, 0x6f, 0x6f, 0x6d, 0x12, 0x18, 0x0a, 0x07, 0x74, 0x69, 0x6d,
+	0x65, 0x6f, 0x75, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x74, 0x69, 0x6d, 0x65,
+	0x6f, 0x75, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x72, 0x65, 0x73, 0x65, 0x74, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x70, 0x72, 0x65, 0x73, 0x65, 0x74, 0x22, 0x35, 0x0a, 0x09, 0x50,
+	0x54, 0x5a, 0x50, 0x72, 0x65, 0x73, 0x65, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x6f, 0x6b, 0x65,
+	0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x12,
+	0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61,
+	0x6d, 0x65, 0x22, 0xf5, 0x01, 0x0a, 0x18, 0x44, 0x6f, 0x77, 0x6e, 0x73, 0x74, 0x72, 0x65, 0x61,
+	0x6d, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x1a, 0x0a, 0x08, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x08, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x49, 0x44, 0x12, 0x3d, 0x0a, 0x07, 0x63,
+	0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x23, 0x2e, 0x63,
 	0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x44, 0x6f, 0x77, 0x6e,
-	0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x4d, 0x65, 0x64, 0x69, 0x61, 0x46, 0x72, 0x61, 0x6d, 0x65,
-	0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x61,
-	0x79, 0x6c, 0x6f, 0x61, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x70, 0x61, 0x79,
-	0x6c, 0x6f, 0x61, 0x64, 0x22, 0x39, 0x0a, 0x0f, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x26, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68,
-	0x75, 0x62, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x49, 0x64, 0x52, 0x02, 0x69, 0x64, 0x22,
-	0x35, 0x0a, 0x0b, 0x50, 0x6c, 0x61, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x26,
-	0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x63, 0x61, 0x6d,
-	0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d,
-	0x49, 0x64, 0x52, 0x02, 0x69, 0x64, 0x22, 0x36, 0x0a, 0x0c, 0x50, 0x61, 0x75, 0x73, 0x65, 0x52,
+	0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x54, 0x79, 0x70,
+	0x65, 0x52, 0x07, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x72, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x44, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x44, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x6c, 0x61, 0x79,
+	0x69, 0x6e, 0x67, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x70, 0x6c, 0x61, 0x79, 0x69,
+	0x6e, 0x67, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x2a,
+	0x0a, 0x03, 0x70, 0x74, 0x7a, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x63, 0x61,
+	0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x50, 0x54, 0x5a, 0x43, 0x6f,
+	0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x52, 0x03, 0x70, 0x74, 0x7a, 0x22, 0xee, 0x01, 0x0a, 0x14, 0x55,
+	0x70, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x52, 0x65,
+	0x70, 0x6c, 0x79, 0x12, 0x1c, 0x0a, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x44,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49,
+	0x44, 0x12, 0x39, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x21, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62,
+	0x2e, 0x55, 0x70, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x53, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x18, 0x0a, 0x07,
+	0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x31, 0x0a, 0x07, 0x70, 0x72, 0x65, 0x73, 0x65, 0x74,
+	0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61,
+	0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x50, 0x54, 0x5a, 0x50, 0x72, 0x65, 0x73, 0x65, 0x74,
+	0x52, 0x07, 0x70, 0x72, 0x65, 0x73, 0x65, 0x74, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x6e, 0x61,
+	0x70, 0x73, 0x68, 0x6f, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x73, 0x6e, 0x61,
+	0x70, 0x73, 0x68, 0x6f, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x61, 0x6b, 0x65, 0x6e, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x74, 0x61, 0x6b, 0x65, 0x6e, 0x22, 0xa7, 0x01, 0x0a, 0x0b,
+	0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x72,
+	0x74, 0x70, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x0a, 0x72, 0x74, 0x70, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x72,
+	0x74, 0x70, 0x42, 0x79, 0x74, 0x65, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x72,
+	0x74, 0x70, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x20, 0x0a, 0x0b, 0x72, 0x74, 0x63, 0x70, 0x50,
+	0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0b, 0x72, 0x74,
+	0x63, 0x70, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x73,
+	0x74, 0x61, 0x72, 0x74, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x72, 0x65, 0x73,
+	0x74, 0x61, 0x72, 0x74, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x6c, 0x61, 0x73, 0x74, 0x50, 0x61, 0x63,
+	0x6b, 0x65, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x6c, 0x61, 0x73, 0x74, 0x50,
+	0x61, 0x63, 0x6b, 0x65, 0x74, 0x22, 0xaa, 0x01, 0x0a, 0x0c, 0x43, 0x61, 0x6d, 0x65, 0x72, 0x61,
+	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d,
+	0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d,
+	0x49, 0x44, 0x12, 0x2f, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x19, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62,
+	0x2e, 0x43, 0x61, 0x6d, 0x65, 0x72, 0x61, 0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x05, 0x73, 0x74,
+	0x61, 0x74, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x6c, 0x61, 0x73, 0x74, 0x45, 0x72, 0x72, 0x6f, 0x72,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x6c, 0x61, 0x73, 0x74, 0x45, 0x72, 0x72, 0x6f,
+	0x72, 0x12, 0x2f, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x19, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e,
+	0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x05, 0x73, 0x74, 0x61,
+	0x74, 0x73, 0x22, 0xb4, 0x02, 0x0a, 0x0b, 0x43, 0x61, 0x6d, 0x65, 0x72, 0x61, 0x45, 0x76, 0x65,
+	0x6e, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x49, 0x44, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x49, 0x44, 0x12, 0x31,
+	0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1d, 0x2e, 0x63,
+	0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x43, 0x61, 0x6d, 0x65,
+	0x72, 0x61, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x4b, 0x69, 0x6e, 0x64, 0x52, 0x04, 0x6b, 0x69, 0x6e,
+	0x64, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x63, 0x74, 0x69, 0x76, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x06, 0x61, 0x63, 0x74, 0x69, 0x76, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x69, 0x6d,
+	0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x12, 0x14, 0x0a,
+	0x05, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x6f,
+	0x70, 0x69, 0x63, 0x12, 0x3d, 0x0a, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x06, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x25, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68,
+	0x75, 0x62, 0x2e, 0x43, 0x61, 0x6d, 0x65, 0x72, 0x61, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x2e, 0x53,
+	0x6f, 0x75, 0x72, 0x63, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x06, 0x73, 0x6f, 0x75, 0x72,
+	0x63, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x18, 0x07,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x1a, 0x39,
+	0x0a, 0x0b, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a,
+	0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12,
+	0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0xc5, 0x01, 0x0a, 0x16, 0x55, 0x70,
+	0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x4d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x12, 0x3a, 0x0a, 0x05, 0x72, 0x65, 0x70, 0x6c, 0x79, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x22, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68,
+	0x75, 0x62, 0x2e, 0x55, 0x70, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x43, 0x6f, 0x6e, 0x74, 0x72,
+	0x6f, 0x6c, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x48, 0x00, 0x52, 0x05, 0x72, 0x65, 0x70, 0x6c, 0x79,
+	0x12, 0x34, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1a, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e,
+	0x43, 0x61, 0x6d, 0x65, 0x72, 0x61, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x48, 0x00, 0x52, 0x06,
+	0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x31, 0x0a, 0x05, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69,
+	0x2e, 0x68, 0x75, 0x62, 0x2e, 0x43, 0x61, 0x6d, 0x65, 0x72, 0x61, 0x45, 0x76, 0x65, 0x6e, 0x74,
+	0x48, 0x00, 0x52, 0x05, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x42, 0x06, 0x0a, 0x04, 0x62, 0x6f, 0x64,
+	0x79, 0x22, 0x9c, 0x02, 0x0a, 0x14, 0x44, 0x6f, 0x77, 0x6e, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d,
+	0x4d, 0x65, 0x64, 0x69, 0x61, 0x46, 0x72, 0x61, 0x6d, 0x65, 0x12, 0x3a, 0x0a, 0x04, 0x74, 0x79,
+	0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x26, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e,
+	0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x44, 0x6f, 0x77, 0x6e, 0x73, 0x74, 0x72, 0x65,
+	0x61, 0x6d, 0x4d, 0x65, 0x64, 0x69, 0x61, 0x46, 0x72, 0x61, 0x6d, 0x65, 0x54, 0x79, 0x70, 0x65,
+	0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61,
+	0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64,
+	0x12, 0x1a, 0x0a, 0x08, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x49, 0x44, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x08, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x49, 0x44, 0x12, 0x1e, 0x0a, 0x0a,
+	0x6d, 0x65, 0x64, 0x69, 0x61, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x0a, 0x6d, 0x65, 0x64, 0x69, 0x61, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x1e, 0x0a, 0x0a,
+	0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x41, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x0a, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x41, 0x74, 0x12, 0x1a, 0x0a, 0x08,
+	0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08,
+	0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x6b, 0x65, 0x79, 0x66,
+	0x72, 0x61, 0x6d, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x6b, 0x65, 0x79, 0x66,
+	0x72, 0x61, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x65, 0x64,
+	0x18, 0x09, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x65, 0x64,
+	0x22, 0x73, 0x0a, 0x0f, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x26, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x16, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x53,
+	0x74, 0x72, 0x65, 0x61, 0x6d, 0x49, 0x64, 0x52, 0x02, 0x69, 0x64, 0x12, 0x38, 0x0a, 0x08, 0x6d,
+	0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e,
+	0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x43, 0x61, 0x6d,
+	0x65, 0x72, 0x61, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x52, 0x08, 0x6d, 0x65, 0x74,
+	0x61, 0x64, 0x61, 0x74, 0x61, 0x22, 0xed, 0x01, 0x0a, 0x13, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74,
+	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1a, 0x0a,
+	0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x32, 0x0a, 0x04, 0x6b, 0x69, 0x6e,
+	0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1e, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61,
+	0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x4b, 0x69, 0x6e, 0x64, 0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x12, 0x33, 0x0a,
+	0x05, 0x61, 0x64, 0x64, 0x65, 0x64, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x63,
+	0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x52, 0x65, 0x67, 0x69,
+	0x73, 0x74, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x05, 0x61, 0x64, 0x64,
+	0x65, 0x64, 0x12, 0x37, 0x0a, 0x07, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x18, 0x04, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68,
+	0x75, 0x62, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x52, 0x07, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x72,
+	0x65, 0x6d, 0x6f, 0x76, 0x65, 0x64, 0x18, 0x05, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x72, 0x65,
+	0x6d, 0x6f, 0x76, 0x65, 0x64, 0x22, 0x4b, 0x0a, 0x15, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x6a, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1a,
+	0x0a, 0x08, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x08, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x49, 0x44, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65,
+	0x61, 0x73, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x65, 0x61, 0x73,
+	0x6f, 0x6e, 0x22, 0x6e, 0x0a, 0x0f, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x41, 0x63, 0x6b, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63,
+	0x65, 0x12, 0x3f, 0x0a, 0x08, 0x72, 0x65, 0x6a, 0x65, 0x63, 0x74, 0x65, 0x64, 0x18, 0x02, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68,
+	0x75, 0x62, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52,
+	0x65, 0x6a, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x08, 0x72, 0x65, 0x6a, 0x65, 0x63, 0x74,
+	0x65, 0x64, 0x22, 0x9f, 0x01, 0x0a, 0x0e, 0x43, 0x61, 0x6d, 0x65, 0x72, 0x61, 0x4d, 0x65, 0x74,
+	0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x22, 0x0a, 0x0c, 0x6d, 0x61, 0x6e, 0x75, 0x66, 0x61, 0x63,
+	0x74, 0x75, 0x72, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x6d, 0x61, 0x6e,
+	0x75, 0x66, 0x61, 0x63, 0x74, 0x75, 0x72, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x6d, 0x6f, 0x64,
+	0x65, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6d, 0x6f, 0x64, 0x65, 0x6c, 0x12,
+	0x1a, 0x0a, 0x08, 0x66, 0x69, 0x72, 0x6d, 0x77, 0x61, 0x72, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x08, 0x66, 0x69, 0x72, 0x6d, 0x77, 0x61, 0x72, 0x65, 0x12, 0x37, 0x0a, 0x08, 0x70,
+	0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e,
+	0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x43, 0x61, 0x6d,
+	0x65, 0x72, 0x61, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x66,
+	0x69, 0x6c, 0x65, 0x73, 0x22, 0x8f, 0x01, 0x0a, 0x0d, 0x43, 0x61, 0x6d, 0x65, 0x72, 0x61, 0x50,
+	0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x12, 0x0a, 0x04,
+	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65,
+	0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x64, 0x65, 0x63, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x05, 0x63, 0x6f, 0x64, 0x65, 0x63, 0x12, 0x14, 0x0a, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x12, 0x16, 0x0a, 0x06,
+	0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x68, 0x65,
+	0x69, 0x67, 0x68, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x66, 0x70, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x02, 0x52, 0x03, 0x66, 0x70, 0x73, 0x22, 0x35, 0x0a, 0x0b, 0x50, 0x6c, 0x61, 0x79, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x26, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x16, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62,
+	0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x49, 0x64, 0x52, 0x02, 0x69, 0x64, 0x22, 0x36, 0x0a,
+	0x0c, 0x50, 0x61, 0x75, 0x73, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x26, 0x0a,
+	0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x63, 0x61, 0x6d, 0x73,
+	0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x49,
+	0x64, 0x52, 0x02, 0x69, 0x64, 0x22, 0x37, 0x0a, 0x0d, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52,
 	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x26, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
 	0x28, 0x0b, 0x32, 0x16, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75,
-	0x62, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x49, 0x64, 0x52, 0x02, 0x69, 0x64, 0x2a, 0x84,
-	0x01, 0x0a, 0x15, 0x44, 0x6f, 0x77, 0x6e, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x43, 0x6f, 0x6d,
-	0x6d, 0x61, 0x6e, 0x64, 0x54, 0x79, 0x70, 0x65, 0x12, 0x27, 0x0a, 0x23, 0x44, 0x4f, 0x57, 0x4e,
+	0x62, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x49, 0x64, 0x52, 0x02, 0x69, 0x64, 0x22, 0x54,
+	0x0a, 0x0e, 0x54, 0x72, 0x69, 0x67, 0x67, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x26, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x63,
+	0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x53, 0x74, 0x72, 0x65,
+	0x61, 0x6d, 0x49, 0x64, 0x52, 0x02, 0x69, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x75, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x64, 0x75, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x22, 0x63, 0x0a, 0x0d, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x26, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x16, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62,
+	0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x49, 0x64, 0x52, 0x02, 0x69, 0x64, 0x12, 0x14, 0x0a,
+	0x05, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x73, 0x69,
+	0x6e, 0x63, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x22, 0x40, 0x0a, 0x0b, 0x45, 0x76, 0x65,
+	0x6e, 0x74, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x31, 0x0a, 0x06, 0x65, 0x76, 0x65, 0x6e,
+	0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e,
+	0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x43, 0x61, 0x6d, 0x65, 0x72, 0x61, 0x45, 0x76,
+	0x65, 0x6e, 0x74, 0x52, 0x06, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x22, 0x8c, 0x01, 0x0a, 0x0e,
+	0x50, 0x54, 0x5a, 0x4d, 0x6f, 0x76, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x26,
+	0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x63, 0x61, 0x6d,
+	0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d,
+	0x49, 0x64, 0x52, 0x02, 0x69, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x70, 0x61, 0x6e, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x02, 0x52, 0x03, 0x70, 0x61, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x69, 0x6c, 0x74,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x02, 0x52, 0x04, 0x74, 0x69, 0x6c, 0x74, 0x12, 0x12, 0x0a, 0x04,
+	0x7a, 0x6f, 0x6f, 0x6d, 0x18, 0x04, 0x20, 0x01, 0x28, 0x02, 0x52, 0x04, 0x7a, 0x6f, 0x6f, 0x6d,
+	0x12, 0x18, 0x0a, 0x07, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x07, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x22, 0x38, 0x0a, 0x0e, 0x50, 0x54,
+	0x5a, 0x53, 0x74, 0x6f, 0x70, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x26, 0x0a, 0x02,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e,
+	0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x49, 0x64,
+	0x52, 0x02, 0x69, 0x64, 0x22, 0x56, 0x0a, 0x14, 0x50, 0x54, 0x5a, 0x47, 0x6f, 0x74, 0x6f, 0x50,
+	0x72, 0x65, 0x73, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x26, 0x0a, 0x02,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e,
+	0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x49, 0x64,
+	0x52, 0x02, 0x69, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x72, 0x65, 0x73, 0x65, 0x74, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x70, 0x72, 0x65, 0x73, 0x65, 0x74, 0x22, 0x3b, 0x0a, 0x11,
+	0x50, 0x54, 0x5a, 0x50, 0x72, 0x65, 0x73, 0x65, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x26, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e,
+	0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x53, 0x74, 0x72,
+	0x65, 0x61, 0x6d, 0x49, 0x64, 0x52, 0x02, 0x69, 0x64, 0x22, 0x44, 0x0a, 0x0f, 0x50, 0x54, 0x5a,
+	0x50, 0x72, 0x65, 0x73, 0x65, 0x74, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x31, 0x0a, 0x07,
+	0x70, 0x72, 0x65, 0x73, 0x65, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e,
+	0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x50, 0x54, 0x5a,
+	0x50, 0x72, 0x65, 0x73, 0x65, 0x74, 0x52, 0x07, 0x70, 0x72, 0x65, 0x73, 0x65, 0x74, 0x73, 0x22,
+	0x39, 0x0a, 0x0f, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x26, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16,
+	0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x53, 0x74,
+	0x72, 0x65, 0x61, 0x6d, 0x49, 0x64, 0x52, 0x02, 0x69, 0x64, 0x22, 0x3e, 0x0a, 0x12, 0x52, 0x65,
+	0x6c, 0x6f, 0x61, 0x64, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x12, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x75, 0x73, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x05, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x22, 0x3a, 0x0a, 0x0e, 0x53, 0x74,
+	0x72, 0x65, 0x61, 0x6d, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x12, 0x12, 0x0a, 0x04,
+	0x6a, 0x70, 0x65, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x6a, 0x70, 0x65, 0x67,
+	0x12, 0x14, 0x0a, 0x05, 0x74, 0x61, 0x6b, 0x65, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x05, 0x74, 0x61, 0x6b, 0x65, 0x6e, 0x22, 0x37, 0x0a, 0x0b, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x61,
+	0x72, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x22,
+	0xc5, 0x01, 0x0a, 0x0d, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72,
+	0x79, 0x12, 0x26, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e,
+	0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x53, 0x74, 0x72,
+	0x65, 0x61, 0x6d, 0x49, 0x64, 0x52, 0x02, 0x69, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x6f, 0x6e, 0x6c,
+	0x69, 0x6e, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x6f, 0x6e, 0x6c, 0x69, 0x6e,
+	0x65, 0x12, 0x3a, 0x0a, 0x09, 0x74, 0x68, 0x75, 0x6d, 0x62, 0x6e, 0x61, 0x69, 0x6c, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e,
+	0x68, 0x75, 0x62, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68,
+	0x6f, 0x74, 0x52, 0x09, 0x74, 0x68, 0x75, 0x6d, 0x62, 0x6e, 0x61, 0x69, 0x6c, 0x12, 0x38, 0x0a,
+	0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x1c, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x43,
+	0x61, 0x6d, 0x65, 0x72, 0x61, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x52, 0x08, 0x6d,
+	0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x22, 0x42, 0x0a, 0x09, 0x4c, 0x69, 0x73, 0x74, 0x52,
+	0x65, 0x70, 0x6c, 0x79, 0x12, 0x35, 0x0a, 0x07, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x73, 0x18,
+	0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69,
+	0x2e, 0x68, 0x75, 0x62, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x53, 0x75, 0x6d, 0x6d, 0x61,
+	0x72, 0x79, 0x52, 0x07, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x73, 0x22, 0xd6, 0x01, 0x0a, 0x0c,
+	0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x26, 0x0a, 0x02,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e,
+	0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x49, 0x64,
+	0x52, 0x02, 0x69, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x6f, 0x6e, 0x6c, 0x69, 0x6e, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x6f, 0x6e, 0x6c, 0x69, 0x6e, 0x65, 0x12, 0x32, 0x0a, 0x06,
+	0x63, 0x61, 0x6d, 0x65, 0x72, 0x61, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x63,
+	0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x43, 0x61, 0x6d, 0x65,
+	0x72, 0x61, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x63, 0x61, 0x6d, 0x65, 0x72, 0x61,
+	0x12, 0x18, 0x0a, 0x07, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x07, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x12, 0x38, 0x0a, 0x08, 0x6d, 0x65,
+	0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x63,
+	0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x43, 0x61, 0x6d, 0x65,
+	0x72, 0x61, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x52, 0x08, 0x6d, 0x65, 0x74, 0x61,
+	0x64, 0x61, 0x74, 0x61, 0x2a, 0xbb, 0x02, 0x0a, 0x15, 0x44, 0x6f, 0x77, 0x6e, 0x73, 0x74, 0x72,
+	0x65, 0x61, 0x6d, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x54, 0x79, 0x70, 0x65, 0x12, 0x27,
+	0x0a, 0x23, 0x44, 0x4f, 0x57, 0x4e, 0x53, 0x54, 0x52, 0x45, 0x41, 0x4d, 0x5f, 0x43, 0x4f, 0x4d,
+	0x4d, 0x41, 0x4e, 0x44, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43,
+	0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x20, 0x0a, 0x1c, 0x44, 0x4f, 0x57, 0x4e, 0x53,
+	0x54, 0x52, 0x45, 0x41, 0x4d, 0x5f, 0x43, 0x4f, 0x4d, 0x4d, 0x41, 0x4e, 0x44, 0x5f, 0x54, 0x59,
+	0x50, 0x45, 0x5f, 0x50, 0x4c, 0x41, 0x59, 0x10, 0x01, 0x12, 0x20, 0x0a, 0x1c, 0x44, 0x4f, 0x57,
+	0x4e, 0x53, 0x54, 0x52, 0x45, 0x41, 0x4d, 0x5f, 0x43, 0x4f, 0x4d, 0x4d, 0x41, 0x4e, 0x44, 0x5f,
+	0x54, 0x59, 0x50, 0x45, 0x5f, 0x53, 0x54, 0x4f, 0x50, 0x10, 0x02, 0x12, 0x25, 0x0a, 0x21, 0x44,
+	0x4f, 0x57, 0x4e, 0x53, 0x54, 0x52, 0x45, 0x41, 0x4d, 0x5f, 0x43, 0x4f, 0x4d, 0x4d, 0x41, 0x4e,
+	0x44, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x52, 0x45, 0x43, 0x4f, 0x4e, 0x43, 0x49, 0x4c, 0x45,
+	0x10, 0x03, 0x12, 0x23, 0x0a, 0x1f, 0x44, 0x4f, 0x57, 0x4e, 0x53, 0x54, 0x52, 0x45, 0x41, 0x4d,
+	0x5f, 0x43, 0x4f, 0x4d, 0x4d, 0x41, 0x4e, 0x44, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x54, 0x52,
+	0x49, 0x47, 0x47, 0x45, 0x52, 0x10, 0x04, 0x12, 0x1f, 0x0a, 0x1b, 0x44, 0x4f, 0x57, 0x4e, 0x53,
+	0x54, 0x52, 0x45, 0x41, 0x4d, 0x5f, 0x43, 0x4f, 0x4d, 0x4d, 0x41, 0x4e, 0x44, 0x5f, 0x54, 0x59,
+	0x50, 0x45, 0x5f, 0x50, 0x54, 0x5a, 0x10, 0x05, 0x12, 0x24, 0x0a, 0x20, 0x44, 0x4f, 0x57, 0x4e,
 	0x53, 0x54, 0x52, 0x45, 0x41, 0x4d, 0x5f, 0x43, 0x4f, 0x4d, 0x4d, 0x41, 0x4e, 0x44, 0x5f, 0x54,
-	0x59, 0x50, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10,
-	0x00, 0x12, 0x20, 0x0a, 0x1c, 0x44, 0x4f, 0x57, 0x4e, 0x53, 0x54, 0x52, 0x45, 0x41, 0x4d, 0x5f,
-	0x43, 0x4f, 0x4d, 0x4d, 0x41, 0x4e, 0x44, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x50, 0x4c, 0x41,
-	0x59, 0x10, 0x01, 0x12, 0x20, 0x0a, 0x1c, 0x44, 0x4f, 0x57, 0x4e, 0x53, 0x54, 0x52, 0x45, 0x41,
-	0x4d, 0x5f, 0x43, 0x4f, 0x4d, 0x4d, 0x41, 0x4e, 0x44, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x53,
-	0x54, 0x4f, 0x50, 0x10, 0x02, 0x2a, 0xb7, 0x01, 0x0a, 0x18, 0x44, 0x6f, 0x77, 0x6e, 0x73, 0x74,
-	0x72, 0x65, 0x61, 0x6d, 0x4d, 0x65, 0x64, 0x69, 0x61, 0x46, 0x72, 0x61, 0x6d, 0x65, 0x54, 0x79,
-	0x70, 0x65, 0x12, 0x2b, 0x0a, 0x27, 0x44, 0x4f, 0x57, 0x4e, 0x53, 0x54, 0x52, 0x45, 0x41, 0x4d,
+	0x59, 0x50, 0x45, 0x5f, 0x53, 0x4e, 0x41, 0x50, 0x53, 0x48, 0x4f, 0x54, 0x10, 0x06, 0x12, 0x22,
+	0x0a, 0x1e, 0x44, 0x4f, 0x57, 0x4e, 0x53, 0x54, 0x52, 0x45, 0x41, 0x4d, 0x5f, 0x43, 0x4f, 0x4d,
+	0x4d, 0x41, 0x4e, 0x44, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x52, 0x45, 0x4c, 0x4f, 0x41, 0x44,
+	0x10, 0x07, 0x2a, 0x8a, 0x01, 0x0a, 0x09, 0x50, 0x54, 0x5a, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x12, 0x1a, 0x0a, 0x16, 0x50, 0x54, 0x5a, 0x5f, 0x41, 0x43, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x55,
+	0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x13, 0x0a, 0x0f,
+	0x50, 0x54, 0x5a, 0x5f, 0x41, 0x43, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x4d, 0x4f, 0x56, 0x45, 0x10,
+	0x01, 0x12, 0x13, 0x0a, 0x0f, 0x50, 0x54, 0x5a, 0x5f, 0x41, 0x43, 0x54, 0x49, 0x4f, 0x4e, 0x5f,
+	0x53, 0x54, 0x4f, 0x50, 0x10, 0x02, 0x12, 0x1a, 0x0a, 0x16, 0x50, 0x54, 0x5a, 0x5f, 0x41, 0x43,
+	0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x47, 0x4f, 0x54, 0x4f, 0x5f, 0x50, 0x52, 0x45, 0x53, 0x45, 0x54,
+	0x10, 0x03, 0x12, 0x1b, 0x0a, 0x17, 0x50, 0x54, 0x5a, 0x5f, 0x41, 0x43, 0x54, 0x49, 0x4f, 0x4e,
+	0x5f, 0x4c, 0x49, 0x53, 0x54, 0x5f, 0x50, 0x52, 0x45, 0x53, 0x45, 0x54, 0x53, 0x10, 0x04, 0x2a,
+	0xac, 0x01, 0x0a, 0x13, 0x55, 0x70, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x52, 0x65, 0x70, 0x6c,
+	0x79, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x25, 0x0a, 0x21, 0x55, 0x50, 0x53, 0x54, 0x52,
+	0x45, 0x41, 0x4d, 0x5f, 0x52, 0x45, 0x50, 0x4c, 0x59, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53,
+	0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x1c,
+	0x0a, 0x18, 0x55, 0x50, 0x53, 0x54, 0x52, 0x45, 0x41, 0x4d, 0x5f, 0x52, 0x45, 0x50, 0x4c, 0x59,
+	0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x4f, 0x4b, 0x10, 0x01, 0x12, 0x28, 0x0a, 0x24,
+	0x55, 0x50, 0x53, 0x54, 0x52, 0x45, 0x41, 0x4d, 0x5f, 0x52, 0x45, 0x50, 0x4c, 0x59, 0x5f, 0x53,
+	0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x4e, 0x4f, 0x5f, 0x53, 0x55, 0x43, 0x48, 0x5f, 0x43, 0x41,
+	0x4d, 0x45, 0x52, 0x41, 0x10, 0x02, 0x12, 0x26, 0x0a, 0x22, 0x55, 0x50, 0x53, 0x54, 0x52, 0x45,
+	0x41, 0x4d, 0x5f, 0x52, 0x45, 0x50, 0x4c, 0x59, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f,
+	0x43, 0x41, 0x4d, 0x45, 0x52, 0x41, 0x5f, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x10, 0x03, 0x2a, 0xa7,
+	0x01, 0x0a, 0x0b, 0x43, 0x61, 0x6d, 0x65, 0x72, 0x61, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x1c,
+	0x0a, 0x18, 0x43, 0x41, 0x4d, 0x45, 0x52, 0x41, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x45, 0x5f, 0x55,
+	0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x14, 0x0a, 0x10,
+	0x43, 0x41, 0x4d, 0x45, 0x52, 0x41, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x45, 0x5f, 0x4f, 0x46, 0x46,
+	0x10, 0x01, 0x12, 0x15, 0x0a, 0x11, 0x43, 0x41, 0x4d, 0x45, 0x52, 0x41, 0x5f, 0x53, 0x54, 0x41,
+	0x54, 0x45, 0x5f, 0x49, 0x44, 0x4c, 0x45, 0x10, 0x02, 0x12, 0x18, 0x0a, 0x14, 0x43, 0x41, 0x4d,
+	0x45, 0x52, 0x41, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x45, 0x5f, 0x50, 0x4c, 0x41, 0x59, 0x49, 0x4e,
+	0x47, 0x10, 0x03, 0x12, 0x18, 0x0a, 0x14, 0x43, 0x41, 0x4d, 0x45, 0x52, 0x41, 0x5f, 0x53, 0x54,
+	0x41, 0x54, 0x45, 0x5f, 0x50, 0x41, 0x55, 0x53, 0x49, 0x4e, 0x47, 0x10, 0x04, 0x12, 0x19, 0x0a,
+	0x15, 0x43, 0x41, 0x4d, 0x45, 0x52, 0x41, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x45, 0x5f, 0x52, 0x45,
+	0x53, 0x55, 0x4d, 0x49, 0x4e, 0x47, 0x10, 0x05, 0x2a, 0x8d, 0x01, 0x0a, 0x0f, 0x43, 0x61, 0x6d,
+	0x65, 0x72, 0x61, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x4b, 0x69, 0x6e, 0x64, 0x12, 0x21, 0x0a, 0x1d,
+	0x43, 0x41, 0x4d, 0x45, 0x52, 0x41, 0x5f, 0x45, 0x56, 0x45, 0x4e, 0x54, 0x5f, 0x4b, 0x49, 0x4e,
+	0x44, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12,
+	0x1c, 0x0a, 0x18, 0x43, 0x41, 0x4d, 0x45, 0x52, 0x41, 0x5f, 0x45, 0x56, 0x45, 0x4e, 0x54, 0x5f,
+	0x4b, 0x49, 0x4e, 0x44, 0x5f, 0x4d, 0x4f, 0x54, 0x49, 0x4f, 0x4e, 0x10, 0x01, 0x12, 0x1c, 0x0a,
+	0x18, 0x43, 0x41, 0x4d, 0x45, 0x52, 0x41, 0x5f, 0x45, 0x56, 0x45, 0x4e, 0x54, 0x5f, 0x4b, 0x49,
+	0x4e, 0x44, 0x5f, 0x54, 0x41, 0x4d, 0x50, 0x45, 0x52, 0x10, 0x02, 0x12, 0x1b, 0x0a, 0x17, 0x43,
+	0x41, 0x4d, 0x45, 0x52, 0x41, 0x5f, 0x45, 0x56, 0x45, 0x4e, 0x54, 0x5f, 0x4b, 0x49, 0x4e, 0x44,
+	0x5f, 0x49, 0x4e, 0x50, 0x55, 0x54, 0x10, 0x03, 0x2a, 0x84, 0x02, 0x0a, 0x18, 0x44, 0x6f, 0x77,
+	0x6e, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x4d, 0x65, 0x64, 0x69, 0x61, 0x46, 0x72, 0x61, 0x6d,
+	0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x2b, 0x0a, 0x27, 0x44, 0x4f, 0x57, 0x4e, 0x53, 0x54, 0x52,
+	0x45, 0x41, 0x4d, 0x5f, 0x4d, 0x45, 0x44, 0x49, 0x41, 0x5f, 0x46, 0x52, 0x41, 0x4d, 0x45, 0x5f,
+	0x54, 0x59, 0x50, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44,
+	0x10, 0x00, 0x12, 0x23, 0x0a, 0x1f, 0x44, 0x4f, 0x57, 0x4e, 0x53, 0x54, 0x52, 0x45, 0x41, 0x4d,
 	0x5f, 0x4d, 0x45, 0x44, 0x49, 0x41, 0x5f, 0x46, 0x52, 0x41, 0x4d, 0x45, 0x5f, 0x54, 0x59, 0x50,
-	0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12,
-	0x23, 0x0a, 0x1f, 0x44, 0x4f, 0x57, 0x4e, 0x53, 0x54, 0x52, 0x45, 0x41, 0x4d, 0x5f, 0x4d, 0x45,
-	0x44, 0x49, 0x41, 0x5f, 0x46, 0x52, 0x41, 0x4d, 0x45, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x52,
-	0x54, 0x50, 0x10, 0x01, 0x12, 0x24, 0x0a, 0x20, 0x44, 0x4f, 0x57, 0x4e, 0x53, 0x54, 0x52, 0x45,
-	0x41, 0x4d, 0x5f, 0x4d, 0x45, 0x44, 0x49, 0x41, 0x5f, 0x46, 0x52, 0x41, 0x4d, 0x45, 0x5f, 0x54,
-	0x59, 0x50, 0x45, 0x5f, 0x52, 0x54, 0x43, 0x50, 0x10, 0x02, 0x12, 0x23, 0x0a, 0x1f, 0x44, 0x4f,
-	0x57, 0x4e, 0x53, 0x54, 0x52, 0x45, 0x41, 0x4d, 0x5f, 0x4d, 0x45, 0x44, 0x49, 0x41, 0x5f, 0x46,
-	0x52, 0x41, 0x4d, 0x45, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x53, 0x44, 0x50, 0x10, 0x03, 0x32,
-	0x59, 0x0a, 0x0a, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x6c, 0x65, 0x72, 0x12, 0x4b, 0x0a,
-	0x07, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x12, 0x12, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e,
-	0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x4e, 0x6f, 0x6e, 0x65, 0x1a, 0x26, 0x2e, 0x63,
-	0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x44, 0x6f, 0x77, 0x6e,
-	0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x52, 0x65, 0x71,
-	0x75, 0x65, 0x73, 0x74, 0x22, 0x00, 0x28, 0x01, 0x30, 0x01, 0x32, 0x55, 0x0a, 0x08, 0x55, 0x70,
-	0x6c, 0x6f, 0x61, 0x64, 0x65, 0x72, 0x12, 0x49, 0x0a, 0x0b, 0x4d, 0x65, 0x64, 0x69, 0x61, 0x55,
-	0x70, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x22, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69,
-	0x2e, 0x68, 0x75, 0x62, 0x2e, 0x44, 0x6f, 0x77, 0x6e, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x4d,
-	0x65, 0x64, 0x69, 0x61, 0x46, 0x72, 0x61, 0x6d, 0x65, 0x1a, 0x12, 0x2e, 0x63, 0x61, 0x6d, 0x73,
-	0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x4e, 0x6f, 0x6e, 0x65, 0x22, 0x00, 0x28,
-	0x01, 0x32, 0x4c, 0x0a, 0x09, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x72, 0x12, 0x3f,
-	0x0a, 0x08, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x12, 0x1d, 0x2e, 0x63, 0x61, 0x6d,
-	0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74,
-	0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e, 0x63, 0x61, 0x6d, 0x73,
-	0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x4e, 0x6f, 0x6e, 0x65, 0x22, 0x00, 0x32,
-	0x7c, 0x0a, 0x06, 0x56, 0x69, 0x65, 0x77, 0x65, 0x72, 0x12, 0x37, 0x0a, 0x04, 0x50, 0x6c, 0x61,
-	0x79, 0x12, 0x19, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62,
-	0x2e, 0x50, 0x6c, 0x61, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e, 0x63,
-	0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x4e, 0x6f, 0x6e, 0x65,
-	0x22, 0x00, 0x12, 0x39, 0x0a, 0x05, 0x50, 0x61, 0x75, 0x73, 0x65, 0x12, 0x1a, 0x2e, 0x63, 0x61,
-	0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x50, 0x61, 0x75, 0x73, 0x65,
+	0x45, 0x5f, 0x52, 0x54, 0x50, 0x10, 0x01, 0x12, 0x24, 0x0a, 0x20, 0x44, 0x4f, 0x57, 0x4e, 0x53,
+	0x54, 0x52, 0x45, 0x41, 0x4d, 0x5f, 0x4d, 0x45, 0x44, 0x49, 0x41, 0x5f, 0x46, 0x52, 0x41, 0x4d,
+	0x45, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x52, 0x54, 0x43, 0x50, 0x10, 0x02, 0x12, 0x23, 0x0a,
+	0x1f, 0x44, 0x4f, 0x57, 0x4e, 0x53, 0x54, 0x52, 0x45, 0x41, 0x4d, 0x5f, 0x4d, 0x45, 0x44, 0x49,
+	0x41, 0x5f, 0x46, 0x52, 0x41, 0x4d, 0x45, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x53, 0x44, 0x50,
+	0x10, 0x03, 0x12, 0x24, 0x0a, 0x20, 0x44, 0x4f, 0x57, 0x4e, 0x53, 0x54, 0x52, 0x45, 0x41, 0x4d,
+	0x5f, 0x4d, 0x45, 0x44, 0x49, 0x41, 0x5f, 0x46, 0x52, 0x41, 0x4d, 0x45, 0x5f, 0x54, 0x59, 0x50,
+	0x45, 0x5f, 0x4f, 0x50, 0x45, 0x4e, 0x10, 0x04, 0x12, 0x25, 0x0a, 0x21, 0x44, 0x4f, 0x57, 0x4e,
+	0x53, 0x54, 0x52, 0x45, 0x41, 0x4d, 0x5f, 0x4d, 0x45, 0x44, 0x49, 0x41, 0x5f, 0x46, 0x52, 0x41,
+	0x4d, 0x45, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x43, 0x4c, 0x4f, 0x53, 0x45, 0x10, 0x05, 0x2a,
+	0x93, 0x01, 0x0a, 0x10, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x4b, 0x69, 0x6e, 0x64, 0x12, 0x21, 0x0a, 0x1d, 0x52, 0x45, 0x47, 0x49, 0x53, 0x54, 0x52, 0x41,
+	0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x4b, 0x49, 0x4e, 0x44, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43,
+	0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x1e, 0x0a, 0x1a, 0x52, 0x45, 0x47, 0x49, 0x53,
+	0x54, 0x52, 0x41, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x4b, 0x49, 0x4e, 0x44, 0x5f, 0x53, 0x4e, 0x41,
+	0x50, 0x53, 0x48, 0x4f, 0x54, 0x10, 0x01, 0x12, 0x1b, 0x0a, 0x17, 0x52, 0x45, 0x47, 0x49, 0x53,
+	0x54, 0x52, 0x41, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x4b, 0x49, 0x4e, 0x44, 0x5f, 0x44, 0x45, 0x4c,
+	0x54, 0x41, 0x10, 0x02, 0x12, 0x1f, 0x0a, 0x1b, 0x52, 0x45, 0x47, 0x49, 0x53, 0x54, 0x52, 0x41,
+	0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x4b, 0x49, 0x4e, 0x44, 0x5f, 0x48, 0x45, 0x41, 0x52, 0x54, 0x42,
+	0x45, 0x41, 0x54, 0x10, 0x03, 0x32, 0x6b, 0x0a, 0x0a, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c,
+	0x6c, 0x65, 0x72, 0x12, 0x5d, 0x0a, 0x07, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x12, 0x24,
+	0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x55, 0x70,
+	0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x4d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x1a, 0x26, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e,
+	0x68, 0x75, 0x62, 0x2e, 0x44, 0x6f, 0x77, 0x6e, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x43, 0x6f,
+	0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x00, 0x28, 0x01,
+	0x30, 0x01, 0x32, 0x55, 0x0a, 0x08, 0x55, 0x70, 0x6c, 0x6f, 0x61, 0x64, 0x65, 0x72, 0x12, 0x49,
+	0x0a, 0x0b, 0x4d, 0x65, 0x64, 0x69, 0x61, 0x55, 0x70, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x22, 0x2e,
+	0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x44, 0x6f, 0x77,
+	0x6e, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x4d, 0x65, 0x64, 0x69, 0x61, 0x46, 0x72, 0x61, 0x6d,
+	0x65, 0x1a, 0x12, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62,
+	0x2e, 0x4e, 0x6f, 0x6e, 0x65, 0x22, 0x00, 0x28, 0x01, 0x32, 0x9c, 0x01, 0x0a, 0x09, 0x52, 0x65,
+	0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x72, 0x12, 0x3f, 0x0a, 0x08, 0x52, 0x65, 0x67, 0x69, 0x73,
+	0x74, 0x65, 0x72, 0x12, 0x1d, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68,
+	0x75, 0x62, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x12, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75,
+	0x62, 0x2e, 0x4e, 0x6f, 0x6e, 0x65, 0x22, 0x00, 0x12, 0x4e, 0x0a, 0x04, 0x53, 0x79, 0x6e, 0x63,
+	0x12, 0x21, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e,
+	0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x1a, 0x1d, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68,
+	0x75, 0x62, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x41,
+	0x63, 0x6b, 0x22, 0x00, 0x28, 0x01, 0x30, 0x01, 0x32, 0xad, 0x06, 0x0a, 0x06, 0x56, 0x69, 0x65,
+	0x77, 0x65, 0x72, 0x12, 0x37, 0x0a, 0x04, 0x50, 0x6c, 0x61, 0x79, 0x12, 0x19, 0x2e, 0x63, 0x61,
+	0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x50, 0x6c, 0x61, 0x79, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70,
+	0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x4e, 0x6f, 0x6e, 0x65, 0x22, 0x00, 0x12, 0x39, 0x0a, 0x05,
+	0x50, 0x61, 0x75, 0x73, 0x65, 0x12, 0x1a, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69,
+	0x2e, 0x68, 0x75, 0x62, 0x2e, 0x50, 0x61, 0x75, 0x73, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x12, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62,
+	0x2e, 0x4e, 0x6f, 0x6e, 0x65, 0x22, 0x00, 0x12, 0x43, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x12, 0x1b, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62,
+	0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a,
+	0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x53, 0x74,
+	0x72, 0x65, 0x61, 0x6d, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x00, 0x12, 0x3d, 0x0a, 0x07,
+	0x54, 0x72, 0x69, 0x67, 0x67, 0x65, 0x72, 0x12, 0x1c, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61,
+	0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x54, 0x72, 0x69, 0x67, 0x67, 0x65, 0x72, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69,
+	0x2e, 0x68, 0x75, 0x62, 0x2e, 0x4e, 0x6f, 0x6e, 0x65, 0x22, 0x00, 0x12, 0x42, 0x0a, 0x06, 0x45,
+	0x76, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x1b, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69,
+	0x2e, 0x68, 0x75, 0x62, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x19, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75,
+	0x62, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12,
+	0x3d, 0x0a, 0x07, 0x50, 0x54, 0x5a, 0x4d, 0x6f, 0x76, 0x65, 0x12, 0x1c, 0x2e, 0x63, 0x61, 0x6d,
+	0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x50, 0x54, 0x5a, 0x4d, 0x6f, 0x76,
+	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e,
+	0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x4e, 0x6f, 0x6e, 0x65, 0x22, 0x00, 0x12, 0x3d,
+	0x0a, 0x07, 0x50, 0x54, 0x5a, 0x53, 0x74, 0x6f, 0x70, 0x12, 0x1c, 0x2e, 0x63, 0x61, 0x6d, 0x73,
+	0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x50, 0x54, 0x5a, 0x53, 0x74, 0x6f, 0x70,
 	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61,
-	0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x4e, 0x6f, 0x6e, 0x65, 0x22, 0x00, 0x42, 0x0e, 0x5a,
-	0x0c, 0x2e, 0x2e, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x70, 0x62, 0x3b, 0x70, 0x62, 0x62, 0x06, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x4e, 0x6f, 0x6e, 0x65, 0x22, 0x00, 0x12, 0x49, 0x0a,
+	0x0d, 0x50, 0x54, 0x5a, 0x47, 0x6f, 0x74, 0x6f, 0x50, 0x72, 0x65, 0x73, 0x65, 0x74, 0x12, 0x22,
+	0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x50, 0x54,
+	0x5a, 0x47, 0x6f, 0x74, 0x6f, 0x50, 0x72, 0x65, 0x73, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x12, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75,
+	0x62, 0x2e, 0x4e, 0x6f, 0x6e, 0x65, 0x22, 0x00, 0x12, 0x4e, 0x0a, 0x0a, 0x50, 0x54, 0x5a, 0x50,
+	0x72, 0x65, 0x73, 0x65, 0x74, 0x73, 0x12, 0x1f, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70,
+	0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x50, 0x54, 0x5a, 0x50, 0x72, 0x65, 0x73, 0x65, 0x74, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61,
+	0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x50, 0x54, 0x5a, 0x50, 0x72, 0x65, 0x73, 0x65, 0x74,
+	0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x49, 0x0a, 0x08, 0x53, 0x6e, 0x61, 0x70,
+	0x73, 0x68, 0x6f, 0x74, 0x12, 0x1d, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e,
+	0x68, 0x75, 0x62, 0x2e, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68,
+	0x75, 0x62, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f,
+	0x74, 0x22, 0x00, 0x12, 0x3c, 0x0a, 0x04, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x19, 0x2e, 0x63, 0x61,
+	0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70,
+	0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22,
+	0x00, 0x12, 0x45, 0x0a, 0x0b, 0x52, 0x65, 0x6c, 0x6f, 0x61, 0x64, 0x41, 0x67, 0x65, 0x6e, 0x74,
+	0x12, 0x20, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75, 0x62, 0x2e,
+	0x52, 0x65, 0x6c, 0x6f, 0x61, 0x64, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x12, 0x2e, 0x63, 0x61, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x68, 0x75,
+	0x62, 0x2e, 0x4e, 0x6f, 0x6e, 0x65, 0x22, 0x00, 0x42, 0x0e, 0x5a, 0x0c, 0x2e, 0x2e, 0x2f, 0x61,
+	0x70, 0x69, 0x2f, 0x70, 0x62, 0x3b, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -618,41 +3228,131 @@ func file_hub_proto_rawDescGZIP() []byte {
 	return file_hub_proto_rawDescData
 }
 
-var file_hub_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_hub_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_hub_proto_enumTypes = make([]protoimpl.EnumInfo, 7)
+var file_hub_proto_msgTypes = make([]protoimpl.MessageInfo, 37)
 var file_hub_proto_goTypes = []interface{}{
 	(DownstreamCommandType)(0),       // 0: cams.api.hub.DownstreamCommandType
-	(DownstreamMediaFrameType)(0),    // 1: cams.api.hub.DownstreamMediaFrameType
-	(*Status)(nil),                   // 2: cams.api.hub.Status
-	(*StreamId)(nil),                 // 3: cams.api.hub.StreamId
-	(*None)(nil),                     // 4: cams.api.hub.None
-	(*DownstreamControlRequest)(nil), // 5: cams.api.hub.DownstreamControlRequest
-	(*DownstreamMediaFrame)(nil),     // 6: cams.api.hub.DownstreamMediaFrame
-	(*RegisterRequest)(nil),          // 7: cams.api.hub.RegisterRequest
-	(*PlayRequest)(nil),              // 8: cams.api.hub.PlayRequest
-	(*PauseRequest)(nil),             // 9: cams.api.hub.PauseRequest
+	(PTZAction)(0),                   // 1: cams.api.hub.PTZAction
+	(UpstreamReplyStatus)(0),         // 2: cams.api.hub.UpstreamReplyStatus
+	(CameraState)(0),                 // 3: cams.api.hub.CameraState
+	(CameraEventKind)(0),             // 4: cams.api.hub.CameraEventKind
+	(DownstreamMediaFrameType)(0),    // 5: cams.api.hub.DownstreamMediaFrameType
+	(RegistrationKind)(0),            // 6: cams.api.hub.RegistrationKind
+	(*Status)(nil),                   // 7: cams.api.hub.Status
+	(*StreamId)(nil),                 // 8: cams.api.hub.StreamId
+	(*None)(nil),                     // 9: cams.api.hub.None
+	(*PTZCommand)(nil),               // 10: cams.api.hub.PTZCommand
+	(*PTZPreset)(nil),                // 11: cams.api.hub.PTZPreset
+	(*DownstreamControlRequest)(nil), // 12: cams.api.hub.DownstreamControlRequest
+	(*UpstreamControlReply)(nil),     // 13: cams.api.hub.UpstreamControlReply
+	(*StreamStats)(nil),              // 14: cams.api.hub.StreamStats
+	(*CameraStatus)(nil),             // 15: cams.api.hub.CameraStatus
+	(*CameraEvent)(nil),              // 16: cams.api.hub.CameraEvent
+	(*UpstreamControlMessage)(nil),   // 17: cams.api.hub.UpstreamControlMessage
+	(*DownstreamMediaFrame)(nil),     // 18: cams.api.hub.DownstreamMediaFrame
+	(*RegisterRequest)(nil),          // 19: cams.api.hub.RegisterRequest
+	(*RegistrationMessage)(nil),      // 20: cams.api.hub.RegistrationMessage
+	(*RegistrationRejection)(nil),    // 21: cams.api.hub.RegistrationRejection
+	(*RegistrationAck)(nil),          // 22: cams.api.hub.RegistrationAck
+	(*CameraMetadata)(nil),           // 23: cams.api.hub.CameraMetadata
+	(*CameraProfile)(nil),            // 24: cams.api.hub.CameraProfile
+	(*PlayRequest)(nil),              // 25: cams.api.hub.PlayRequest
+	(*PauseRequest)(nil),             // 26: cams.api.hub.PauseRequest
+	(*StatusRequest)(nil),            // 27: cams.api.hub.StatusRequest
+	(*TriggerRequest)(nil),           // 28: cams.api.hub.TriggerRequest
+	(*EventsRequest)(nil),            // 29: cams.api.hub.EventsRequest
+	(*EventsReply)(nil),              // 30: cams.api.hub.EventsReply
+	(*PTZMoveRequest)(nil),           // 31: cams.api.hub.PTZMoveRequest
+	(*PTZStopRequest)(nil),           // 32: cams.api.hub.PTZStopRequest
+	(*PTZGotoPresetRequest)(nil),     // 33: cams.api.hub.PTZGotoPresetRequest
+	(*PTZPresetsRequest)(nil),        // 34: cams.api.hub.PTZPresetsRequest
+	(*PTZPresetsReply)(nil),          // 35: cams.api.hub.PTZPresetsReply
+	(*SnapshotRequest)(nil),          // 36: cams.api.hub.SnapshotRequest
+	(*ReloadAgentRequest)(nil),       // 37: cams.api.hub.ReloadAgentRequest
+	(*StreamSnapshot)(nil),           // 38: cams.api.hub.StreamSnapshot
+	(*ListRequest)(nil),              // 39: cams.api.hub.ListRequest
+	(*StreamSummary)(nil),            // 40: cams.api.hub.StreamSummary
+	(*ListReply)(nil),                // 41: cams.api.hub.ListReply
+	(*StreamStatus)(nil),             // 42: cams.api.hub.StreamStatus
+	nil,                              // 43: cams.api.hub.CameraEvent.SourceEntry
 }
 var file_hub_proto_depIdxs = []int32{
-	0,  // 0: cams.api.hub.DownstreamControlRequest.command:type_name -> cams.api.hub.DownstreamCommandType
-	1,  // 1: cams.api.hub.DownstreamMediaFrame.type:type_name -> cams.api.hub.DownstreamMediaFrameType
-	3,  // 2: cams.api.hub.RegisterRequest.id:type_name -> cams.api.hub.StreamId
-	3,  // 3: cams.api.hub.PlayRequest.id:type_name -> cams.api.hub.StreamId
-	3,  // 4: cams.api.hub.PauseRequest.id:type_name -> cams.api.hub.StreamId
-	4,  // 5: cams.api.hub.Controller.Control:input_type -> cams.api.hub.None
-	6,  // 6: cams.api.hub.Uploader.MediaUpload:input_type -> cams.api.hub.DownstreamMediaFrame
-	7,  // 7: cams.api.hub.Registrar.Register:input_type -> cams.api.hub.RegisterRequest
-	8,  // 8: cams.api.hub.Viewer.Play:input_type -> cams.api.hub.PlayRequest
-	9,  // 9: cams.api.hub.Viewer.Pause:input_type -> cams.api.hub.PauseRequest
-	5,  // 10: cams.api.hub.Controller.Control:output_type -> cams.api.hub.DownstreamControlRequest
-	4,  // 11: cams.api.hub.Uploader.MediaUpload:output_type -> cams.api.hub.None
-	4,  // 12: cams.api.hub.Registrar.Register:output_type -> cams.api.hub.None
-	4,  // 13: cams.api.hub.Viewer.Play:output_type -> cams.api.hub.None
-	4,  // 14: cams.api.hub.Viewer.Pause:output_type -> cams.api.hub.None
-	10, // [10:15] is the sub-list for method output_type
-	5,  // [5:10] is the sub-list for method input_type
-	5,  // [5:5] is the sub-list for extension type_name
-	5,  // [5:5] is the sub-list for extension extendee
-	0,  // [0:5] is the sub-list for field type_name
+	1,  // 0: cams.api.hub.PTZCommand.action:type_name -> cams.api.hub.PTZAction
+	0,  // 1: cams.api.hub.DownstreamControlRequest.command:type_name -> cams.api.hub.DownstreamCommandType
+	10, // 2: cams.api.hub.DownstreamControlRequest.ptz:type_name -> cams.api.hub.PTZCommand
+	2,  // 3: cams.api.hub.UpstreamControlReply.status:type_name -> cams.api.hub.UpstreamReplyStatus
+	11, // 4: cams.api.hub.UpstreamControlReply.presets:type_name -> cams.api.hub.PTZPreset
+	3,  // 5: cams.api.hub.CameraStatus.state:type_name -> cams.api.hub.CameraState
+	14, // 6: cams.api.hub.CameraStatus.stats:type_name -> cams.api.hub.StreamStats
+	4,  // 7: cams.api.hub.CameraEvent.kind:type_name -> cams.api.hub.CameraEventKind
+	43, // 8: cams.api.hub.CameraEvent.source:type_name -> cams.api.hub.CameraEvent.SourceEntry
+	13, // 9: cams.api.hub.UpstreamControlMessage.reply:type_name -> cams.api.hub.UpstreamControlReply
+	15, // 10: cams.api.hub.UpstreamControlMessage.status:type_name -> cams.api.hub.CameraStatus
+	16, // 11: cams.api.hub.UpstreamControlMessage.event:type_name -> cams.api.hub.CameraEvent
+	5,  // 12: cams.api.hub.DownstreamMediaFrame.type:type_name -> cams.api.hub.DownstreamMediaFrameType
+	8,  // 13: cams.api.hub.RegisterRequest.id:type_name -> cams.api.hub.StreamId
+	23, // 14: cams.api.hub.RegisterRequest.metadata:type_name -> cams.api.hub.CameraMetadata
+	6,  // 15: cams.api.hub.RegistrationMessage.kind:type_name -> cams.api.hub.RegistrationKind
+	19, // 16: cams.api.hub.RegistrationMessage.added:type_name -> cams.api.hub.RegisterRequest
+	19, // 17: cams.api.hub.RegistrationMessage.updated:type_name -> cams.api.hub.RegisterRequest
+	21, // 18: cams.api.hub.RegistrationAck.rejected:type_name -> cams.api.hub.RegistrationRejection
+	24, // 19: cams.api.hub.CameraMetadata.profiles:type_name -> cams.api.hub.CameraProfile
+	8,  // 20: cams.api.hub.PlayRequest.id:type_name -> cams.api.hub.StreamId
+	8,  // 21: cams.api.hub.PauseRequest.id:type_name -> cams.api.hub.StreamId
+	8,  // 22: cams.api.hub.StatusRequest.id:type_name -> cams.api.hub.StreamId
+	8,  // 23: cams.api.hub.TriggerRequest.id:type_name -> cams.api.hub.StreamId
+	8,  // 24: cams.api.hub.EventsRequest.id:type_name -> cams.api.hub.StreamId
+	16, // 25: cams.api.hub.EventsReply.events:type_name -> cams.api.hub.CameraEvent
+	8,  // 26: cams.api.hub.PTZMoveRequest.id:type_name -> cams.api.hub.StreamId
+	8,  // 27: cams.api.hub.PTZStopRequest.id:type_name -> cams.api.hub.StreamId
+	8,  // 28: cams.api.hub.PTZGotoPresetRequest.id:type_name -> cams.api.hub.StreamId
+	8,  // 29: cams.api.hub.PTZPresetsRequest.id:type_name -> cams.api.hub.StreamId
+	11, // 30: cams.api.hub.PTZPresetsReply.presets:type_name -> cams.api.hub.PTZPreset
+	8,  // 31: cams.api.hub.SnapshotRequest.id:type_name -> cams.api.hub.StreamId
+	8,  // 32: cams.api.hub.StreamSummary.id:type_name -> cams.api.hub.StreamId
+	38, // 33: cams.api.hub.StreamSummary.thumbnail:type_name -> cams.api.hub.StreamSnapshot
+	23, // 34: cams.api.hub.StreamSummary.metadata:type_name -> cams.api.hub.CameraMetadata
+	40, // 35: cams.api.hub.ListReply.streams:type_name -> cams.api.hub.StreamSummary
+	8,  // 36: cams.api.hub.StreamStatus.id:type_name -> cams.api.hub.StreamId
+	15, // 37: cams.api.hub.StreamStatus.camera:type_name -> cams.api.hub.CameraStatus
+	23, // 38: cams.api.hub.StreamStatus.metadata:type_name -> cams.api.hub.CameraMetadata
+	17, // 39: cams.api.hub.Controller.Control:input_type -> cams.api.hub.UpstreamControlMessage
+	18, // 40: cams.api.hub.Uploader.MediaUpload:input_type -> cams.api.hub.DownstreamMediaFrame
+	19, // 41: cams.api.hub.Registrar.Register:input_type -> cams.api.hub.RegisterRequest
+	20, // 42: cams.api.hub.Registrar.Sync:input_type -> cams.api.hub.RegistrationMessage
+	25, // 43: cams.api.hub.Viewer.Play:input_type -> cams.api.hub.PlayRequest
+	26, // 44: cams.api.hub.Viewer.Pause:input_type -> cams.api.hub.PauseRequest
+	27, // 45: cams.api.hub.Viewer.Status:input_type -> cams.api.hub.StatusRequest
+	28, // 46: cams.api.hub.Viewer.Trigger:input_type -> cams.api.hub.TriggerRequest
+	29, // 47: cams.api.hub.Viewer.Events:input_type -> cams.api.hub.EventsRequest
+	31, // 48: cams.api.hub.Viewer.PTZMove:input_type -> cams.api.hub.PTZMoveRequest
+	32, // 49: cams.api.hub.Viewer.PTZStop:input_type -> cams.api.hub.PTZStopRequest
+	33, // 50: cams.api.hub.Viewer.PTZGotoPreset:input_type -> cams.api.hub.PTZGotoPresetRequest
+	34, // 51: cams.api.hub.Viewer.PTZPresets:input_type -> cams.api.hub.PTZPresetsRequest
+	36, // 52: cams.api.hub.Viewer.Snapshot:input_type -> cams.api.hub.SnapshotRequest
+	39, // 53: cams.api.hub.Viewer.List:input_type -> cams.api.hub.ListRequest
+	37, // 54: cams.api.hub.Viewer.ReloadAgent:input_type -> cams.api.hub.ReloadAgentRequest
+	12, // 55: cams.api.hub.Controller.Control:output_type -> cams.api.hub.DownstreamControlRequest
+	9,  // 56: cams.api.hub.Uploader.MediaUpload:output_type -> cams.api.hub.None
+	9,  // 57: cams.api.hub.Registrar.Register:output_type -> cams.api.hub.None
+	22, // 58: cams.api.hub.Registrar.Sync:output_type -> cams.api.hub.RegistrationAck
+	9,  // 59: cams.api.hub.Viewer.Play:output_type -> cams.api.hub.None
+	9,  // 60: cams.api.hub.Viewer.Pause:output_type -> cams.api.hub.None
+	42, // 61: cams.api.hub.Viewer.Status:output_type -> cams.api.hub.StreamStatus
+	9,  // 62: cams.api.hub.Viewer.Trigger:output_type -> cams.api.hub.None
+	30, // 63: cams.api.hub.Viewer.Events:output_type -> cams.api.hub.EventsReply
+	9,  // 64: cams.api.hub.Viewer.PTZMove:output_type -> cams.api.hub.None
+	9,  // 65: cams.api.hub.Viewer.PTZStop:output_type -> cams.api.hub.None
+	9,  // 66: cams.api.hub.Viewer.PTZGotoPreset:output_type -> cams.api.hub.None
+	35, // 67: cams.api.hub.Viewer.PTZPresets:output_type -> cams.api.hub.PTZPresetsReply
+	38, // 68: cams.api.hub.Viewer.Snapshot:output_type -> cams.api.hub.StreamSnapshot
+	41, // 69: cams.api.hub.Viewer.List:output_type -> cams.api.hub.ListReply
+	9,  // 70: cams.api.hub.Viewer.ReloadAgent:output_type -> cams.api.hub.None
+	55, // [55:71] is the sub-list for method output_type
+	39, // [39:55] is the sub-list for method input_type
+	39, // [39:39] is the sub-list for extension type_name
+	39, // [39:39] is the sub-list for extension extendee
+	0,  // [0:39] is the sub-list for field type_name
 }
 
 func init() { file_hub_proto_init() }
@@ -673,8 +3373,56 @@ func file_hub_proto_init() {
 				return nil
 			}
 		}
-		file_hub_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*StreamId); i {
+		file_hub_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StreamId); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hub_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*None); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hub_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PTZCommand); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hub_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PTZPreset); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hub_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DownstreamControlRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -685,8 +3433,8 @@ func file_hub_proto_init() {
 				return nil
 			}
 		}
-		file_hub_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*None); i {
+		file_hub_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UpstreamControlReply); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -697,8 +3445,8 @@ func file_hub_proto_init() {
 				return nil
 			}
 		}
-		file_hub_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*DownstreamControlRequest); i {
+		file_hub_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StreamStats); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -709,7 +3457,43 @@ func file_hub_proto_init() {
 				return nil
 			}
 		}
-		file_hub_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+		file_hub_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CameraStatus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hub_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CameraEvent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hub_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UpstreamControlMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hub_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*DownstreamMediaFrame); i {
 			case 0:
 				return &v.state
@@ -721,7 +3505,7 @@ func file_hub_proto_init() {
 				return nil
 			}
 		}
-		file_hub_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+		file_hub_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*RegisterRequest); i {
 			case 0:
 				return &v.state
@@ -733,7 +3517,67 @@ func file_hub_proto_init() {
 				return nil
 			}
 		}
-		file_hub_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+		file_hub_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RegistrationMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hub_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RegistrationRejection); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hub_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RegistrationAck); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hub_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CameraMetadata); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hub_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CameraProfile); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hub_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*PlayRequest); i {
 			case 0:
 				return &v.state
@@ -745,7 +3589,7 @@ func file_hub_proto_init() {
 				return nil
 			}
 		}
-		file_hub_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+		file_hub_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*PauseRequest); i {
 			case 0:
 				return &v.state
@@ -757,14 +3601,211 @@ func file_hub_proto_init() {
 				return nil
 			}
 		}
+		file_hub_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StatusRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hub_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TriggerRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hub_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EventsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hub_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EventsReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hub_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PTZMoveRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hub_proto_msgTypes[25].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PTZStopRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hub_proto_msgTypes[26].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PTZGotoPresetRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hub_proto_msgTypes[27].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PTZPresetsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hub_proto_msgTypes[28].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PTZPresetsReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hub_proto_msgTypes[29].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SnapshotRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hub_proto_msgTypes[30].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReloadAgentRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hub_proto_msgTypes[31].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StreamSnapshot); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hub_proto_msgTypes[32].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hub_proto_msgTypes[33].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StreamSummary); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hub_proto_msgTypes[34].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hub_proto_msgTypes[35].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StreamStatus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_hub_proto_msgTypes[10].OneofWrappers = []interface{}{
+		(*UpstreamControlMessage_Reply)(nil),
+		(*UpstreamControlMessage_Status)(nil),
+		(*UpstreamControlMessage_Event)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_hub_proto_rawDesc,
-			NumEnums:      2,
-			NumMessages:   8,
+			NumEnums:      7,
+			NumMessages:   37,
 			NumExtensions: 0,
 			NumServices:   4,
 		},
@@ -22,7 +22,8 @@ const _ = grpc.SupportPackageIsVersion7
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type ControllerClient interface {
-	// Stream of commands from the server to the client
+	// Stream of commands from the server to the client,
+	// and stream of replies from the client to the server.
 	Control(ctx context.Context, opts ...grpc.CallOption) (Controller_ControlClient, error)
 }
 
@@ -44,7 +45,7 @@ func (c *controllerClient) Control(ctx context.Context, opts ...grpc.CallOption)
 }
 
 type Controller_ControlClient interface {
-	Send(*None) error
+	Send(*UpstreamControlMessage) error
 	Recv() (*DownstreamControlRequest, error)
 	grpc.ClientStream
 }
@@ -53,7 +54,7 @@ type controllerControlClient struct {
 	grpc.ClientStream
 }
 
-func (x *controllerControlClient) Send(m *None) error {
+func (x *controllerControlClient) Send(m *UpstreamControlMessage) error {
 	return x.ClientStream.SendMsg(m)
 }
 
@@ -69,7 +70,8 @@ func (x *controllerControlClient) Recv() (*DownstreamControlRequest, error) {
 // All implementations must embed UnimplementedControllerServer
 // for forward compatibility
 type ControllerServer interface {
-	// Stream of commands from the server to the client
+	// Stream of commands from the server to the client,
+	// and stream of replies from the client to the server.
 	Control(Controller_ControlServer) error
 	mustEmbedUnimplementedControllerServer()
 }
@@ -100,7 +102,7 @@ func _Controller_Control_Handler(srv interface{}, stream grpc.ServerStream) erro
 
 type Controller_ControlServer interface {
 	Send(*DownstreamControlRequest) error
-	Recv() (*None, error)
+	Recv() (*UpstreamControlMessage, error)
 	grpc.ServerStream
 }
 
@@ -112,8 +114,8 @@ func (x *controllerControlServer) Send(m *DownstreamControlRequest) error {
 	return x.ServerStream.SendMsg(m)
 }
 
-func (x *controllerControlServer) Recv() (*None, error) {
-	m := new(None)
+func (x *controllerControlServer) Recv() (*UpstreamControlMessage, error) {
+	m := new(UpstreamControlMessage)
 	if err := x.ServerStream.RecvMsg(m); err != nil {
 		return nil, err
 	}
@@ -269,6 +271,10 @@ var Uploader_ServiceDesc = grpc.ServiceDesc{
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type RegistrarClient interface {
 	Register(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*None, error)
+	// Sync keeps the streams of the agent registered. The agent sends a snapshot
+	// of its cameras first, then the changes and heartbeats. Each message is
+	// applied atomically and acknowledged.
+	Sync(ctx context.Context, opts ...grpc.CallOption) (Registrar_SyncClient, error)
 }
 
 type registrarClient struct {
@@ -288,11 +294,46 @@ func (c *registrarClient) Register(ctx context.Context, in *RegisterRequest, opt
 	return out, nil
 }
 
+func (c *registrarClient) Sync(ctx context.Context, opts ...grpc.CallOption) (Registrar_SyncClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Registrar_ServiceDesc.Streams[0], "/cams.api.hub.Registrar/Sync", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &registrarSyncClient{stream}
+	return x, nil
+}
+
+type Registrar_SyncClient interface {
+	Send(*RegistrationMessage) error
+	Recv() (*RegistrationAck, error)
+	grpc.ClientStream
+}
+
+type registrarSyncClient struct {
+	grpc.ClientStream
+}
+
+func (x *registrarSyncClient) Send(m *RegistrationMessage) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *registrarSyncClient) Recv() (*RegistrationAck, error) {
+	m := new(RegistrationAck)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // RegistrarServer is the server API for Registrar service.
 // All implementations must embed UnimplementedRegistrarServer
 // for forward compatibility
 type RegistrarServer interface {
 	Register(context.Context, *RegisterRequest) (*None, error)
+	// Sync keeps the streams of the agent registered. The agent sends a snapshot
+	// of its cameras first, then the changes and heartbeats. Each message is
+	// applied atomically and acknowledged.
+	Sync(Registrar_SyncServer) error
 	mustEmbedUnimplementedRegistrarServer()
 }
 
@@ -303,6 +344,9 @@ type UnimplementedRegistrarServer struct {
 func (UnimplementedRegistrarServer) Register(context.Context, *RegisterRequest) (*None, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Register not implemented")
 }
+func (UnimplementedRegistrarServer) Sync(Registrar_SyncServer) error {
+	return status.Errorf(codes.Unimplemented, "method Sync not implemented")
+}
 func (UnimplementedRegistrarServer) mustEmbedUnimplementedRegistrarServer() {}
 
 // UnsafeRegistrarServer may be embedded to opt out of forward compatibility for this service.
@@ -334,6 +378,32 @@ func _Registrar_Register_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Registrar_Sync_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(RegistrarServer).Sync(&registrarSyncServer{stream})
+}
+
+type Registrar_SyncServer interface {
+	Send(*RegistrationAck) error
+	Recv() (*RegistrationMessage, error)
+	grpc.ServerStream
+}
+
+type registrarSyncServer struct {
+	grpc.ServerStream
+}
+
+func (x *registrarSyncServer) Send(m *RegistrationAck) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *registrarSyncServer) Recv() (*RegistrationMessage, error) {
+	m := new(RegistrationMessage)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // Registrar_ServiceDesc is the grpc.ServiceDesc for Registrar service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -346,7 +416,14 @@ var Registrar_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _Registrar_Register_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Sync",
+			Handler:       _Registrar_Sync_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
 	Metadata: "hub.proto",
 }
 
@@ -354,8 +431,34 @@ var Registrar_ServiceDesc = grpc.ServiceDesc{
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type ViewerClient interface {
+	// Play starts or renews the viewer session of the caller, identified by the
+	// "session-id" metadata. A session not renewed in time expires.
 	Play(ctx context.Context, in *PlayRequest, opts ...grpc.CallOption) (*None, error)
+	// Pause ends the viewer session of the caller. The stream is stopped once
+	// it lingered without any viewer.
 	Pause(ctx context.Context, in *PauseRequest, opts ...grpc.CallOption) (*None, error)
+	Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*StreamStatus, error)
+	// Trigger asks the agent to upload the recent past of the stream, and then
+	// its live media for a while. The agent must run in trigger mode.
+	Trigger(ctx context.Context, in *TriggerRequest, opts ...grpc.CallOption) (*None, error)
+	// Events lists the latest events of the stream, oldest first
+	Events(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (*EventsReply, error)
+	// The PTZ RPCs steer the camera of the stream. They require the caller,
+	// authenticated by the bearer token of its "authorization" metadata, to hold
+	// the PTZ right on the stream.
+	// That right is granted apart from viewing.
+	PTZMove(ctx context.Context, in *PTZMoveRequest, opts ...grpc.CallOption) (*None, error)
+	PTZStop(ctx context.Context, in *PTZStopRequest, opts ...grpc.CallOption) (*None, error)
+	PTZGotoPreset(ctx context.Context, in *PTZGotoPresetRequest, opts ...grpc.CallOption) (*None, error)
+	PTZPresets(ctx context.Context, in *PTZPresetsRequest, opts ...grpc.CallOption) (*PTZPresetsReply, error)
+	// Snapshot returns a JPEG image of the camera of the stream. The latest
+	// snapshot is served when the agent is offline.
+	Snapshot(ctx context.Context, in *SnapshotRequest, opts ...grpc.CallOption) (*StreamSnapshot, error)
+	// List returns the streams of a user, ordered by ID, with their thumbnail
+	List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListReply, error)
+	// ReloadAgent asks the agent to reload its configuration. The agent must be
+	// connected and belong to the user.
+	ReloadAgent(ctx context.Context, in *ReloadAgentRequest, opts ...grpc.CallOption) (*None, error)
 }
 
 type viewerClient struct {
@@ -384,12 +487,128 @@ func (c *viewerClient) Pause(ctx context.Context, in *PauseRequest, opts ...grpc
 	return out, nil
 }
 
+func (c *viewerClient) Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*StreamStatus, error) {
+	out := new(StreamStatus)
+	err := c.cc.Invoke(ctx, "/cams.api.hub.Viewer/Status", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *viewerClient) Trigger(ctx context.Context, in *TriggerRequest, opts ...grpc.CallOption) (*None, error) {
+	out := new(None)
+	err := c.cc.Invoke(ctx, "/cams.api.hub.Viewer/Trigger", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *viewerClient) Events(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (*EventsReply, error) {
+	out := new(EventsReply)
+	err := c.cc.Invoke(ctx, "/cams.api.hub.Viewer/Events", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *viewerClient) PTZMove(ctx context.Context, in *PTZMoveRequest, opts ...grpc.CallOption) (*None, error) {
+	out := new(None)
+	err := c.cc.Invoke(ctx, "/cams.api.hub.Viewer/PTZMove", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *viewerClient) PTZStop(ctx context.Context, in *PTZStopRequest, opts ...grpc.CallOption) (*None, error) {
+	out := new(None)
+	err := c.cc.Invoke(ctx, "/cams.api.hub.Viewer/PTZStop", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *viewerClient) PTZGotoPreset(ctx context.Context, in *PTZGotoPresetRequest, opts ...grpc.CallOption) (*None, error) {
+	out := new(None)
+	err := c.cc.Invoke(ctx, "/cams.api.hub.Viewer/PTZGotoPreset", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *viewerClient) PTZPresets(ctx context.Context, in *PTZPresetsRequest, opts ...grpc.CallOption) (*PTZPresetsReply, error) {
+	out := new(PTZPresetsReply)
+	err := c.cc.Invoke(ctx, "/cams.api.hub.Viewer/PTZPresets", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *viewerClient) Snapshot(ctx context.Context, in *SnapshotRequest, opts ...grpc.CallOption) (*StreamSnapshot, error) {
+	out := new(StreamSnapshot)
+	err := c.cc.Invoke(ctx, "/cams.api.hub.Viewer/Snapshot", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *viewerClient) List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListReply, error) {
+	out := new(ListReply)
+	err := c.cc.Invoke(ctx, "/cams.api.hub.Viewer/List", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *viewerClient) ReloadAgent(ctx context.Context, in *ReloadAgentRequest, opts ...grpc.CallOption) (*None, error) {
+	out := new(None)
+	err := c.cc.Invoke(ctx, "/cams.api.hub.Viewer/ReloadAgent", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ViewerServer is the server API for Viewer service.
 // All implementations must embed UnimplementedViewerServer
 // for forward compatibility
 type ViewerServer interface {
+	// Play starts or renews the viewer session of the caller, identified by the
+	// "session-id" metadata. A session not renewed in time expires.
 	Play(context.Context, *PlayRequest) (*None, error)
+	// Pause ends the viewer session of the caller. The stream is stopped once
+	// it lingered without any viewer.
 	Pause(context.Context, *PauseRequest) (*None, error)
+	Status(context.Context, *StatusRequest) (*StreamStatus, error)
+	// Trigger asks the agent to upload the recent past of the stream, and then
+	// its live media for a while. The agent must run in trigger mode.
+	Trigger(context.Context, *TriggerRequest) (*None, error)
+	// Events lists the latest events of the stream, oldest first
+	Events(context.Context, *EventsRequest) (*EventsReply, error)
+	// The PTZ RPCs steer the camera of the stream. They require the caller,
+	// authenticated by the bearer token of its "authorization" metadata, to hold
+	// the PTZ right on the stream.
+	// That right is granted apart from viewing.
+	PTZMove(context.Context, *PTZMoveRequest) (*None, error)
+	PTZStop(context.Context, *PTZStopRequest) (*None, error)
+	PTZGotoPreset(context.Context, *PTZGotoPresetRequest) (*None, error)
+	PTZPresets(context.Context, *PTZPresetsRequest) (*PTZPresetsReply, error)
+	// Snapshot returns a JPEG image of the camera of the stream. The latest
+	// snapshot is served when the agent is offline.
+	Snapshot(context.Context, *SnapshotRequest) (*StreamSnapshot, error)
+	// List returns the streams of a user, ordered by ID, with their thumbnail
+	List(context.Context, *ListRequest) (*ListReply, error)
+	// ReloadAgent asks the agent to reload its configuration. The agent must be
+	// connected and belong to the user.
+	ReloadAgent(context.Context, *ReloadAgentRequest) (*None, error)
 	mustEmbedUnimplementedViewerServer()
 }
 
@@ -403,6 +622,36 @@ func (UnimplementedViewerServer) Play(context.Context, *PlayRequest) (*None, err
 func (UnimplementedViewerServer) Pause(context.Context, *PauseRequest) (*None, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Pause not implemented")
 }
+func (UnimplementedViewerServer) Status(context.Context, *StatusRequest) (*StreamStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Status not implemented")
+}
+func (UnimplementedViewerServer) Trigger(context.Context, *TriggerRequest) (*None, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Trigger not implemented")
+}
+func (UnimplementedViewerServer) Events(context.Context, *EventsRequest) (*EventsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Events not implemented")
+}
+func (UnimplementedViewerServer) PTZMove(context.Context, *PTZMoveRequest) (*None, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PTZMove not implemented")
+}
+func (UnimplementedViewerServer) PTZStop(context.Context, *PTZStopRequest) (*None, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PTZStop not implemented")
+}
+func (UnimplementedViewerServer) PTZGotoPreset(context.Context, *PTZGotoPresetRequest) (*None, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PTZGotoPreset not implemented")
+}
+func (UnimplementedViewerServer) PTZPresets(context.Context, *PTZPresetsRequest) (*PTZPresetsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PTZPresets not implemented")
+}
+func (UnimplementedViewerServer) Snapshot(context.Context, *SnapshotRequest) (*StreamSnapshot, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Snapshot not implemented")
+}
+func (UnimplementedViewerServer) List(context.Context, *ListRequest) (*ListReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedViewerServer) ReloadAgent(context.Context, *ReloadAgentRequest) (*None, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReloadAgent not implemented")
+}
 func (UnimplementedViewerServer) mustEmbedUnimplementedViewerServer() {}
 
 // UnsafeViewerServer may be embedded to opt out of forward compatibility for this service.
@@ -452,6 +701,186 @@ func _Viewer_Pause_Handler(srv interface{}, ctx context.Context, dec func(interf
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Viewer_Status_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ViewerServer).Status(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cams.api.hub.Viewer/Status",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ViewerServer).Status(ctx, req.(*StatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Viewer_Trigger_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TriggerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ViewerServer).Trigger(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cams.api.hub.Viewer/Trigger",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ViewerServer).Trigger(ctx, req.(*TriggerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Viewer_Events_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ViewerServer).Events(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cams.api.hub.Viewer/Events",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ViewerServer).Events(ctx, req.(*EventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Viewer_PTZMove_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PTZMoveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ViewerServer).PTZMove(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cams.api.hub.Viewer/PTZMove",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ViewerServer).PTZMove(ctx, req.(*PTZMoveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Viewer_PTZStop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PTZStopRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ViewerServer).PTZStop(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cams.api.hub.Viewer/PTZStop",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ViewerServer).PTZStop(ctx, req.(*PTZStopRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Viewer_PTZGotoPreset_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PTZGotoPresetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ViewerServer).PTZGotoPreset(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cams.api.hub.Viewer/PTZGotoPreset",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ViewerServer).PTZGotoPreset(ctx, req.(*PTZGotoPresetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Viewer_PTZPresets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PTZPresetsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ViewerServer).PTZPresets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cams.api.hub.Viewer/PTZPresets",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ViewerServer).PTZPresets(ctx, req.(*PTZPresetsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Viewer_Snapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SnapshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ViewerServer).Snapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cams.api.hub.Viewer/Snapshot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ViewerServer).Snapshot(ctx, req.(*SnapshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Viewer_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ViewerServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cams.api.hub.Viewer/List",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ViewerServer).List(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Viewer_ReloadAgent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReloadAgentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ViewerServer).ReloadAgent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cams.api.hub.Viewer/ReloadAgent",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ViewerServer).ReloadAgent(ctx, req.(*ReloadAgentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Viewer_ServiceDesc is the grpc.ServiceDesc for Viewer service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -467,6 +896,46 @@ var Viewer_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Pause",
 			Handler:    _Viewer_Pause_Handler,
 		},
+		{
+			MethodName: "Status",
+			Handler:    _Viewer_Status_Handler,
+		},
+		{
+			MethodName: "Trigger",
+			Handler:    _Viewer_Trigger_Handler,
+		},
+		{
+			MethodName: "Events",
+			Handler:    _Viewer_Events_Handler,
+		},
+		{
+			MethodName: "PTZMove",
+			Handler:    _Viewer_PTZMove_Handler,
+		},
+		{
+			MethodName: "PTZStop",
+			Handler:    _Viewer_PTZStop_Handler,
+		},
+		{
+			MethodName: "PTZGotoPreset",
+			Handler:    _Viewer_PTZGotoPreset_Handler,
+		},
+		{
+			MethodName: "PTZPresets",
+			Handler:    _Viewer_PTZPresets_Handler,
+		},
+		{
+			MethodName: "Snapshot",
+			Handler:    _Viewer_Snapshot_Handler,
+		},
+		{
+			MethodName: "List",
+			Handler:    _Viewer_List_Handler,
+		},
+		{
+			MethodName: "ReloadAgent",
+			Handler:    _Viewer_ReloadAgent_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "hub.proto",
@@ -22,10 +22,13 @@ import (
 
 	"github.com/jfsmig/cams/go/rtsp1"
 	"github.com/jfsmig/cams/go/rtsp1/pkg/media"
+	"github.com/jfsmig/cams/go/rtsp1/pkg/rtpmjpeg"
 	"github.com/jfsmig/cams/go/rtsp1/pkg/url"
 	"github.com/jfsmig/cams/go/transport"
 	"github.com/jfsmig/cams/go/utils"
+	"github.com/jfsmig/onvif/networking"
 	"github.com/jfsmig/onvif/sdk"
+	"github.com/jfsmig/onvif/xsd/onvif"
 	"github.com/juju/errors"
 	"github.com/pion/rtcp"
 	"github.com/pion/rtp"
@@ -60,18 +63,59 @@ const (
 	CamCommandPause
 )
 
-// We assume only one stream per camera.
+var (
+	ErrCameraStopped = errors.New("camera agent stopped")
+	ErrCameraBusy    = errors.New("camera agent busy")
+)
+
+// Camera manages one stream: a device with several profiles is managed by
+// one Camera per profile.
 type Camera struct {
 	open UploadOpenFunc
 
-	ID            string
+	ID string
+
+	// The physical device, shared by the streams of its profiles
+	Device string
+
 	generation    uint32
 	singletonLock sync.Mutex
 	State         CamAgentState
 
+	// Protects State and lastError, that are read by other goroutines
+	statusLock sync.Mutex
+	lastError  string
+	stats      streamStats
+	observer   StatusObserver
+
+	// Where the media stream is
+	source Source
+
+	// The ONVIF device, nil for a plain RTSP source
 	onvifClient sdk.Appliance
 	rtspClient  rtsp1.Client
 
+	// The client beneath onvifClient, for the calls it doesn't wrap
+	onvifCalls *networking.Client
+
+	// The media profile streamed, empty for the default stream
+	profile StreamProfile
+
+	// The media profile the PTZ calls refer to, once known
+	ptzProfileToken onvif.ReferenceToken
+	ptzLock         sync.Mutex
+
+	// The latest image of a M-JPEG live stream, a fallback for the snapshots
+	lastFrame   []byte
+	lastFrameAt time.Time
+	frameLock   sync.Mutex
+
+	// The description of the device, shared by the streams of its profiles,
+	// and what the SDP of the stream tells about the video
+	description  *deviceDescription
+	streamFormat VideoFormat
+	metadataLock sync.Mutex
+
 	requests chan CamCommand
 
 	group utils.Swarm
@@ -79,20 +123,31 @@ type Camera struct {
 	flagRetry bool
 }
 
+// NewCamera manages an ONVIF device, streaming its first profile
 func NewCamera(open UploadOpenFunc, appliance sdk.Appliance) *Camera {
+	cam := NewSourceCamera(open, onvifSource{appliance: appliance})
+	cam.onvifClient = appliance
+	return cam
+}
+
+// NewSourceCamera manages a camera only known by its stream, the ONVIF
+// features are unavailable.
+func NewSourceCamera(open UploadOpenFunc, source Source) *Camera {
 	return &Camera{
-		open:        open,
-		ID:          appliance.GetUUID(),
-		generation:  0,
-		onvifClient: appliance,
+		open:       open,
+		ID:         source.ID(),
+		Device:     source.ID(),
+		generation: 0,
+		source:     source,
 		rtspClient: rtsp1.Client{
 			ReadTimeout:     5 * time.Second,
 			WriteTimeout:    5 * time.Second,
 			RedirectDisable: true,
 			AnyPortEnable:   true,
 		},
-		requests:  make(chan CamCommand, 8),
-		flagRetry: true,
+		requests:    make(chan CamCommand, 8),
+		description: &deviceDescription{},
+		flagRetry:   true,
 	}
 }
 
@@ -111,28 +166,28 @@ func (cam *Camera) Run(ctx context.Context) {
 	if cam.State != CamAgentOff {
 		panic("BUG: unexpected camera agent state")
 	}
-	cam.State = CamAgentIdle
+	cam.setState(CamAgentIdle)
 
 	defer func() {
 		cam.group = nil
-		cam.State = CamAgentOff
+		cam.setState(CamAgentOff)
+		cam.statusLock.Lock()
 		close(cam.requests)
 		cam.requests = nil
+		cam.statusLock.Unlock()
 	}()
 
 	for {
 		select {
 		case <-ctx.Done():
-			cam.group.Cancel()
-			cam.group.Wait()
+			cam.stopGroup()
 			return
 		case cmd := <-cam.requests:
 			switch cmd {
 			case CamCommandPing:
 				cam.onCmdPing(ctx)
 			case CamCommandExit:
-				cam.group.Cancel()
-				cam.group.Wait()
+				cam.stopGroup()
 				return
 			case CamCommandPlay:
 				cam.onCmdPlay(ctx)
@@ -143,11 +198,35 @@ func (cam *Camera) Run(ctx context.Context) {
 	}
 }
 
-func (cam *Camera) PK() string  { return cam.ID }
-func (cam *Camera) Ping()       { cam.requests <- CamCommandPing }
-func (cam *Camera) Exit()       { cam.requests <- CamCommandExit }
-func (cam *Camera) PlayStream() { cam.requests <- CamCommandPlay }
-func (cam *Camera) StopStream() { cam.requests <- CamCommandPause }
+// stopGroup stops the coroutines of the stream, if any, an idle camera has none
+func (cam *Camera) stopGroup() {
+	if cam.group != nil {
+		cam.group.Cancel()
+		cam.group.Wait()
+	}
+}
+
+func (cam *Camera) PK() string        { return cam.ID }
+func (cam *Camera) Ping() error       { return cam.command(CamCommandPing) }
+func (cam *Camera) Exit() error       { return cam.command(CamCommandExit) }
+func (cam *Camera) PlayStream() error { return cam.command(CamCommandPlay) }
+func (cam *Camera) StopStream() error { return cam.command(CamCommandPause) }
+
+// command queues a command for the camera agent, without blocking. It fails
+// once the agent has exited, or when the queue is full.
+func (cam *Camera) command(cmd CamCommand) error {
+	cam.statusLock.Lock()
+	defer cam.statusLock.Unlock()
+	if cam.requests == nil {
+		return ErrCameraStopped
+	}
+	select {
+	case cam.requests <- cmd:
+		return nil
+	default:
+		return ErrCameraBusy
+	}
+}
 
 func (cam *Camera) warn(err error) *zerolog.Event {
 	return utils.Logger.Warn().Str("url", cam.ID).Err(err)
@@ -158,11 +237,17 @@ func (cam *Camera) debug() *zerolog.Event {
 }
 
 func (cam *Camera) runStream(ctx context.Context) {
-	for ctx.Err() == nil {
+	for first := true; ctx.Err() == nil; first = false {
+		if !first {
+			cam.stats.restarts.Add(1)
+		}
 		cam.debug().Msg("cam stream starting")
 		err := cam.runStreamOnce(ctx)
 		if err != nil {
 			cam.debug().Err(err).Msg("cam stream aborted")
+			if ctx.Err() == nil {
+				cam.setLastError(err)
+			}
 		} else {
 			// Avoid a crazy loop
 			time.Sleep(time.Second)
@@ -178,10 +263,17 @@ func (cam *Camera) runStreamOnce(ctx context.Context) error {
 	var sourceUrl *url.URL
 	var err error
 
+	switch src := cam.source.(type) {
+	case Generator:
+		return cam.runGenerator(ctx, src)
+	case *ReplaySource:
+		return cam.runReplay(ctx, src)
+	}
+
 	// Prepare the camera RTSP side
-	sourceUrl, err = cam.queryMediaUrl(ctx)
+	sourceUrl, err = cam.source.StreamURL(ctx)
 	if err != nil {
-		return errors.Annotate(err, "queryMediaUrl")
+		return errors.Annotate(err, "stream URL")
 	}
 
 	cam.debug().Str("url", sourceUrl.Host).Msg("cam streaming")
@@ -201,6 +293,9 @@ func (cam *Camera) runStreamOnce(ctx context.Context) error {
 		Str("sdp", sdp).
 		Interface("medias", medias).
 		Msg("streams described")
+	if vf, ok := DescribeVideo(medias); ok {
+		cam.setStreamFormat(vf)
+	}
 
 	// Prepare the upstream side
 	upload, err := cam.open(ctx)
@@ -231,6 +326,9 @@ func (cam *Camera) runStreamOnce(ctx context.Context) error {
 	if err = upload.OnSDP(sdp); err != nil {
 		return errors.Annotate(err, "send sdp banner")
 	}
+	indexer := newMediaIndexer(medias)
+	var mjpeg rtpmjpeg.Decoder
+	mjpeg.Init()
 
 	g, ctx := errgroup.WithContext(ctx)
 	g.Go(func() error {
@@ -242,22 +340,15 @@ func (cam *Camera) runStreamOnce(ctx context.Context) error {
 			case <-ctx.Done():
 				return nil
 			case pkt := <-udpListener.GetMediaChannel():
-				decoded := rtp.Header{}
-				if _, err := decoded.Unmarshal(pkt); err != nil {
+				decoded := rtp.Packet{}
+				if err := decoded.Unmarshal(pkt); err != nil {
 					utils.Logger.Warn().Int("size", len(pkt)).Err(err).Msg("rtp")
-				} else {
-					if err = upload.OnRTP(pkt); err != nil {
-						return err
-					}
+				} else if err = cam.onRTP(upload, indexer, &mjpeg, &decoded, pkt); err != nil {
+					return err
 				}
 			case pkt := <-udpListener.GetControlChannel():
-				decoded := rtcp.Header{}
-				if err := decoded.Unmarshal(pkt); err != nil {
-					utils.Logger.Warn().Int("size", len(pkt)).Err(err).Msg("rtcp")
-				} else {
-					if err = upload.OnRTCP(pkt); err != nil {
-						return err
-					}
+				if err := cam.onRTCP(upload, indexer, pkt); err != nil {
+					return err
 				}
 			}
 		}
@@ -273,14 +364,40 @@ func (cam *Camera) runStreamOnce(ctx context.Context) error {
 	return g.Wait()
 }
 
-func (cam *Camera) queryMediaUrl(ctx context.Context) (*url.URL, error) {
-	streamURI := cam.onvifClient.FetchStreamURI(ctx)
-	sourceUrl, err := url.Parse(streamURI)
-	cam.debug().Str("source", sourceUrl.String()).Str("parsed", streamURI).Msg("STREAM")
-	if err != nil {
-		return nil, errors.Annotate(err, "parse")
+// onRTP forwards a RTP packet of the stream upstream, and keeps the M-JPEG
+// frames as a fallback for the snapshots
+func (cam *Camera) onRTP(upload UpstreamMedia, indexer *mediaIndexer, mjpeg *rtpmjpeg.Decoder, decoded *rtp.Packet, pkt []byte) error {
+	info := PacketInfo{
+		MediaIndex: indexer.indexRTP(decoded.PayloadType, decoded.SSRC),
+		ReceivedAt: time.Now(),
+		Keyframe:   indexer.keyframe(decoded),
+	}
+	if err := upload.OnRTP(info, pkt); err != nil {
+		return err
+	}
+	cam.countRTP(len(pkt))
+	if indexer.mjpegTypes[decoded.PayloadType] {
+		if img, err := mjpeg.Decode(decoded); err == nil {
+			cam.keepFrame(img)
+		}
+	}
+	return nil
+}
+
+// onRTCP forwards a RTCP packet of the stream upstream, the malformed ones are
+// dropped
+func (cam *Camera) onRTCP(upload UpstreamMedia, indexer *mediaIndexer, pkt []byte) error {
+	decoded := rtcp.Header{}
+	if err := decoded.Unmarshal(pkt); err != nil {
+		utils.Logger.Warn().Int("size", len(pkt)).Err(err).Msg("rtcp")
+		return nil
+	}
+	info := PacketInfo{MediaIndex: indexer.indexRTCP(pkt), ReceivedAt: time.Now()}
+	if err := upload.OnRTCP(info, pkt); err != nil {
+		return err
 	}
-	return sourceUrl, nil
+	cam.countRTCP()
+	return nil
 }
 
 func (cam *Camera) onCmdPlay(ctx context.Context) {
@@ -288,14 +405,14 @@ func (cam *Camera) onCmdPlay(ctx context.Context) {
 	case CamAgentOff:
 		panic("BUG unexpected state")
 	case CamAgentPausing, CamAgentResuming:
-		cam.State = CamAgentResuming
+		cam.setState(CamAgentResuming)
 		if cam.group.Count() > 0 {
 			return
 		}
-		cam.State = CamAgentIdle
+		cam.setState(CamAgentIdle)
 		fallthrough
 	case CamAgentIdle:
-		cam.State = CamAgentPlaying
+		cam.setState(CamAgentPlaying)
 		cam.group = utils.NewGroup(ctx)
 		cam.group.Run(func(c context.Context) { cam.runStream(c) })
 		cam.debug().Msg("camera restarted")
@@ -314,7 +431,7 @@ func (cam *Camera) onCmdStop() {
 	case CamAgentPlaying, CamAgentResuming:
 		// Trigger a stop of the coroutines
 		cam.group.Cancel()
-		cam.State = CamAgentPausing
+		cam.setState(CamAgentPausing)
 		fallthrough
 	case CamAgentPausing:
 		// Wait for the stop to finish
@@ -322,7 +439,7 @@ func (cam *Camera) onCmdStop() {
 			return
 		}
 		cam.group = nil
-		cam.State = CamAgentIdle
+		cam.setState(CamAgentIdle)
 		fallthrough
 	case CamAgentIdle:
 		// No-Op
@@ -342,12 +459,12 @@ func (cam *Camera) onCmdPing(ctx context.Context) {
 			return
 		}
 		cam.group = nil
-		cam.State = CamAgentIdle
+		cam.setState(CamAgentIdle)
 	case CamAgentResuming:
 		if cam.group.Count() > 0 {
 			return
 		}
-		cam.State = CamAgentIdle
+		cam.setState(CamAgentIdle)
 		cam.onCmdPlay(ctx)
 	default:
 		panic("BUG invalid state")
@@ -0,0 +1,306 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package camera
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jfsmig/cams/go/utils"
+	"github.com/jfsmig/onvif/gosoap"
+	"github.com/jfsmig/onvif/networking"
+	"github.com/juju/errors"
+)
+
+type EventKind uint32
+
+const (
+	EventUnknown EventKind = iota
+	EventMotion
+	EventTamper
+	EventInput
+)
+
+const (
+	// How long the camera keeps the subscription without renewal
+	eventsSubscriptionTTL = "PT60S"
+
+	// How often the subscription is renewed, well within its TTL
+	eventsRenewPeriod = 30 * time.Second
+
+	// How long a pull waits for events on the camera side
+	eventsPullTimeout = "PT5S"
+
+	eventsPullLimit = 32
+
+	actionPullMessages = "http://www.onvif.org/ver10/events/wsdl/PullPointSubscription/PullMessagesRequest"
+	actionRenew        = "http://docs.oasis-open.org/wsn/bw-2/SubscriptionManager/RenewRequest"
+	actionUnsubscribe  = "http://docs.oasis-open.org/wsn/bw-2/SubscriptionManager/UnsubscribeRequest"
+)
+
+// Event is an ONVIF event of a camera, normalised
+type Event struct {
+	CamID  string
+	Kind   EventKind
+	Active bool
+	Time   time.Time
+	Topic  string
+	Source map[string]string
+}
+
+// EventObserver is notified of each event of the camera. It must not block.
+type EventObserver func(event Event)
+
+// EventListener pulls the events of a camera from a PullPoint subscription
+// to its ONVIF event service.
+type EventListener struct {
+	camID      string
+	endpoint   string
+	auth       networking.ClientAuth
+	httpClient *http.Client
+}
+
+type simpleItem struct {
+	Name  string `xml:"Name,attr"`
+	Value string `xml:"Value,attr"`
+}
+
+type notificationMessage struct {
+	Topic   string `xml:"Topic"`
+	Message struct {
+		Message struct {
+			UtcTime           string       `xml:"UtcTime,attr"`
+			PropertyOperation string       `xml:"PropertyOperation,attr"`
+			Source            []simpleItem `xml:"Source>SimpleItem"`
+			Data              []simpleItem `xml:"Data>SimpleItem"`
+		} `xml:"Message"`
+	} `xml:"Message"`
+}
+
+type eventsEnvelope struct {
+	Body struct {
+		Fault *struct {
+			Reason string `xml:"Reason>Text"`
+		} `xml:"Fault"`
+		CreatePullPointSubscriptionResponse struct {
+			Address string `xml:"SubscriptionReference>Address"`
+		} `xml:"CreatePullPointSubscriptionResponse"`
+		PullMessagesResponse struct {
+			NotificationMessage []notificationMessage `xml:"NotificationMessage"`
+		} `xml:"PullMessagesResponse"`
+	} `xml:"Body"`
+}
+
+func (k EventKind) String() string {
+	switch k {
+	case EventMotion:
+		return "motion"
+	case EventTamper:
+		return "tamper"
+	case EventInput:
+		return "input"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseEventKind is the reverse of EventKind.String, for the known kinds only
+func ParseEventKind(name string) (EventKind, bool) {
+	for _, k := range []EventKind{EventMotion, EventTamper, EventInput} {
+		if k.String() == name {
+			return k, true
+		}
+	}
+	return EventUnknown, false
+}
+
+func NewEventListener(camID, endpoint string, auth networking.ClientAuth, httpClient *http.Client) *EventListener {
+	return &EventListener{
+		camID:      camID,
+		endpoint:   endpoint,
+		auth:       auth,
+		httpClient: httpClient,
+	}
+}
+
+// Run subscribes to the events of the camera and notifies them to the observer,
+// until the context is cancelled or the subscription fails.
+func (el *EventListener) Run(ctx context.Context, observer EventObserver) error {
+	address, err := el.subscribe(ctx)
+	if err != nil {
+		return errors.Annotate(err, "subscribe")
+	}
+	utils.Logger.Debug().Str("cam", el.camID).Str("address", address).Str("action", "subscribe").Msg("events")
+
+	defer func() {
+		// Best effort, the subscription expires anyway
+		ctx2, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, _ = el.call(ctx2, address, actionUnsubscribe, `<wsnt:Unsubscribe/>`)
+	}()
+
+	nextRenew := time.Now().Add(eventsRenewPeriod)
+	for ctx.Err() == nil {
+		if time.Now().After(nextRenew) {
+			body := `<wsnt:Renew><wsnt:TerminationTime>` + eventsSubscriptionTTL + `</wsnt:TerminationTime></wsnt:Renew>`
+			if _, err = el.call(ctx, address, actionRenew, body); err != nil {
+				return errors.Annotate(err, "renew")
+			}
+			nextRenew = time.Now().Add(eventsRenewPeriod)
+		}
+
+		body := fmt.Sprintf(`<tev:PullMessages><tev:Timeout>%s</tev:Timeout><tev:MessageLimit>%d</tev:MessageLimit></tev:PullMessages>`,
+			eventsPullTimeout, eventsPullLimit)
+		reply, err := el.call(ctx, address, actionPullMessages, body)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return errors.Annotate(err, "pull")
+		}
+		for _, msg := range reply.Body.PullMessagesResponse.NotificationMessage {
+			if event, ok := el.normalize(msg); ok {
+				observer(event)
+			}
+		}
+	}
+	return ctx.Err()
+}
+
+func (el *EventListener) subscribe(ctx context.Context) (string, error) {
+	body := `<tev:CreatePullPointSubscription><tev:InitialTerminationTime>` + eventsSubscriptionTTL +
+		`</tev:InitialTerminationTime></tev:CreatePullPointSubscription>`
+	reply, err := el.call(ctx, el.endpoint, "", body)
+	if err != nil {
+		return "", err
+	}
+	address := strings.TrimSpace(reply.Body.CreatePullPointSubscriptionResponse.Address)
+	if len(address) <= 0 {
+		return "", errors.New("no subscription address")
+	}
+	return address, nil
+}
+
+// call sends a SOAP request to the given address and parses the reply
+func (el *EventListener) call(ctx context.Context, address, action, body string) (eventsEnvelope, error) {
+	var reply eventsEnvelope
+
+	soap := gosoap.NewEmptySOAP()
+	soap.AddStringBodyContent(body)
+	soap.AddRootNamespaces(networking.Xlmns)
+	if len(action) > 0 {
+		// The subscription manager dispatches on the WS-Addressing headers
+		if err := soap.AddStringHeaderContent(`<wsa:Action>` + action + `</wsa:Action>`); err != nil {
+			return reply, errors.Trace(err)
+		}
+		if err := soap.AddStringHeaderContent(`<wsa:To>` + address + `</wsa:To>`); err != nil {
+			return reply, errors.Trace(err)
+		}
+	}
+	if len(el.auth.Username) > 0 && len(el.auth.Password) > 0 {
+		soap.AddWSSecurity(el.auth.Username, el.auth.Password)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, address, bytes.NewBufferString(soap.String()))
+	if err != nil {
+		return reply, errors.Trace(err)
+	}
+	req.Header.Set("Content-Type", "application/soap+xml; charset=utf-8")
+	rep, err := el.httpClient.Do(req)
+	if err != nil {
+		return reply, errors.Trace(err)
+	}
+	defer rep.Body.Close()
+
+	encoded, err := io.ReadAll(rep.Body)
+	if err != nil {
+		return reply, errors.Annotate(err, "read")
+	}
+	if err = xml.Unmarshal(encoded, &reply); err != nil && rep.StatusCode == http.StatusOK {
+		return reply, errors.Annotate(err, "decode")
+	}
+	if fault := reply.Body.Fault; fault != nil {
+		return reply, errors.Errorf("fault: %s", strings.TrimSpace(fault.Reason))
+	}
+	if rep.StatusCode != http.StatusOK {
+		return reply, errors.Errorf("http status %d", rep.StatusCode)
+	}
+	return reply, nil
+}
+
+// normalize maps a notification to an event of a well-known kind
+func (el *EventListener) normalize(msg notificationMessage) (Event, bool) {
+	topic := strings.TrimSpace(msg.Topic)
+	event := Event{
+		CamID:  el.camID,
+		Kind:   eventKind(topic),
+		Topic:  topic,
+		Source: make(map[string]string),
+	}
+	if event.Kind == EventUnknown {
+		return event, false
+	}
+
+	inner := msg.Message.Message
+	if t, err := time.Parse(time.RFC3339Nano, inner.UtcTime); err == nil {
+		event.Time = t
+	} else {
+		event.Time = time.Now()
+	}
+	for _, item := range inner.Source {
+		event.Source[item.Name] = item.Value
+	}
+
+	// The state is carried by the first boolean item (e.g. IsMotion, State, LogicalState)
+	for _, item := range inner.Data {
+		if active, ok := parseEventState(item.Value); ok {
+			event.Active = active
+			return event, true
+		}
+	}
+	return event, false
+}
+
+func eventKind(topic string) EventKind {
+	switch {
+	case strings.Contains(topic, "Motion"):
+		return EventMotion
+	case strings.Contains(topic, "Tamper"), strings.Contains(topic, "GlobalSceneChange"):
+		return EventTamper
+	case strings.Contains(topic, "DigitalInput"), strings.Contains(topic, "InputPort"):
+		return EventInput
+	default:
+		return EventUnknown
+	}
+}
+
+func parseEventState(value string) (bool, bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "active":
+		return true, true
+	case "inactive":
+		return false, true
+	}
+	b, err := strconv.ParseBool(strings.TrimSpace(value))
+	return b, err == nil
+}
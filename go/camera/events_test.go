@@ -0,0 +1,145 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package camera
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jfsmig/onvif/networking"
+)
+
+const fakeNotifications = `<?xml version="1.0" encoding="UTF-8"?>
+<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope" xmlns:tev="http://www.onvif.org/ver10/events/wsdl"
+  xmlns:wsnt="http://docs.oasis-open.org/wsn/b-2" xmlns:tt="http://www.onvif.org/ver10/schema">
+<env:Body><tev:PullMessagesResponse>
+  <tev:CurrentTime>2024-01-01T00:00:00Z</tev:CurrentTime>
+  <tev:TerminationTime>2024-01-01T00:01:00Z</tev:TerminationTime>
+  <wsnt:NotificationMessage>
+    <wsnt:Topic Dialect="http://www.onvif.org/ver10/tev/topicExpression/ConcreteSet">tns1:RuleEngine/CellMotionDetector/Motion</wsnt:Topic>
+    <wsnt:Message><tt:Message UtcTime="2024-01-01T00:00:01Z" PropertyOperation="Changed">
+      <tt:Source><tt:SimpleItem Name="VideoSourceConfigurationToken" Value="vsc0"/></tt:Source>
+      <tt:Data><tt:SimpleItem Name="IsMotion" Value="true"/></tt:Data>
+    </tt:Message></wsnt:Message>
+  </wsnt:NotificationMessage>
+  <wsnt:NotificationMessage>
+    <wsnt:Topic>tns1:Device/HardwareFailure/StorageFailure</wsnt:Topic>
+    <wsnt:Message><tt:Message UtcTime="2024-01-01T00:00:02Z">
+      <tt:Data><tt:SimpleItem Name="Failed" Value="true"/></tt:Data>
+    </tt:Message></wsnt:Message>
+  </wsnt:NotificationMessage>
+  <wsnt:NotificationMessage>
+    <wsnt:Topic>tns1:Device/Trigger/DigitalInput</wsnt:Topic>
+    <wsnt:Message><tt:Message UtcTime="2024-01-01T00:00:03Z">
+      <tt:Source><tt:SimpleItem Name="InputToken" Value="in1"/></tt:Source>
+      <tt:Data><tt:SimpleItem Name="LogicalState" Value="false"/></tt:Data>
+    </tt:Message></wsnt:Message>
+  </wsnt:NotificationMessage>
+</tev:PullMessagesResponse></env:Body></env:Envelope>`
+
+const fakeEmptyPull = `<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope"><env:Body><PullMessagesResponse/></env:Body></env:Envelope>`
+
+// fakeEventService mimics the event service of an ONVIF camera, that serves
+// the notifications once on its PullPoint.
+type fakeEventService struct {
+	server *httptest.Server
+
+	lock   sync.Mutex
+	pulled bool
+	calls  []string
+}
+
+func newFakeEventService() *fakeEventService {
+	fake := &fakeEventService{}
+	fake.server = httptest.NewServer(http.HandlerFunc(fake.serve))
+	return fake
+}
+
+func (fake *fakeEventService) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	request := string(body)
+
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+
+	switch {
+	case strings.Contains(request, "CreatePullPointSubscription"):
+		fake.calls = append(fake.calls, "subscribe")
+		_, _ = io.WriteString(w, `<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope"><env:Body>
+<tev:CreatePullPointSubscriptionResponse xmlns:tev="http://www.onvif.org/ver10/events/wsdl" xmlns:wsa="http://www.w3.org/2005/08/addressing">
+<tev:SubscriptionReference><wsa:Address>`+fake.server.URL+`/pullpoint/1</wsa:Address></tev:SubscriptionReference>
+</tev:CreatePullPointSubscriptionResponse></env:Body></env:Envelope>`)
+	case strings.Contains(request, "PullMessages"):
+		if r.URL.Path != "/pullpoint/1" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fake.calls = append(fake.calls, "pull")
+		if fake.pulled {
+			// Like the camera, wait a bit for events before replying empty
+			time.Sleep(10 * time.Millisecond)
+			_, _ = io.WriteString(w, fakeEmptyPull)
+		} else {
+			fake.pulled = true
+			_, _ = io.WriteString(w, fakeNotifications)
+		}
+	case strings.Contains(request, "Unsubscribe"):
+		fake.calls = append(fake.calls, "unsubscribe")
+		_, _ = io.WriteString(w, `<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope"><env:Body/></env:Envelope>`)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func TestEventListener_Pull(t *testing.T) {
+	fake := newFakeEventService()
+	defer fake.server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	events := make(chan Event, 8)
+	listener := NewEventListener("cam", fake.server.URL+"/onvif/events", networking.ClientAuth{Username: "u", Password: "p"}, http.DefaultClient)
+	done := make(chan error, 1)
+	go func() { done <- listener.Run(ctx, func(ev Event) { events <- ev }) }()
+
+	motion := <-events
+	if motion.Kind != EventMotion || !motion.Active || motion.Source["VideoSourceConfigurationToken"] != "vsc0" {
+		t.Fatal("unexpected event", motion)
+	}
+	if !motion.Time.Equal(time.Date(2024, 1, 1, 0, 0, 1, 0, time.UTC)) {
+		t.Fatal("unexpected time", motion.Time)
+	}
+	// The unknown topics are ignored
+	input := <-events
+	if input.Kind != EventInput || input.Active || input.Source["InputToken"] != "in1" {
+		t.Fatal("unexpected event", input)
+	}
+
+	cancel()
+	<-done
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+	if fake.calls[0] != "subscribe" || fake.calls[len(fake.calls)-1] != "unsubscribe" {
+		t.Fatal("unexpected calls", fake.calls)
+	}
+}
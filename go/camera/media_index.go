@@ -0,0 +1,89 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package camera
+
+import (
+	"encoding/binary"
+
+	"github.com/jfsmig/cams/go/rtsp1/pkg/format"
+	"github.com/jfsmig/cams/go/rtsp1/pkg/media"
+	"github.com/jfsmig/cams/go/utils"
+	"github.com/pion/rtp"
+)
+
+// mediaIndexer tells which media of the SDP a packet belongs to, since all the
+// medias of a camera share the same pair of UDP ports.
+// RTP packets are matched by payload type, and RTCP packets by the SSRC of their
+// sender, as learned from the RTP packets.
+type mediaIndexer struct {
+	byPayloadType map[uint8]uint32
+	bySSRC        map[uint32]uint32
+
+	// The payload types carrying H264, whose keyframes are detected
+	h264Types map[uint8]bool
+
+	// The payload types carrying M-JPEG, whose frames are all keyframes and
+	// may stand as snapshots
+	mjpegTypes map[uint8]bool
+}
+
+func newMediaIndexer(medias media.Medias) *mediaIndexer {
+	mi := &mediaIndexer{
+		byPayloadType: make(map[uint8]uint32),
+		bySSRC:        make(map[uint32]uint32),
+		h264Types:     make(map[uint8]bool),
+		mjpegTypes:    make(map[uint8]bool),
+	}
+	for idx, m := range medias {
+		for _, f := range m.Formats {
+			mi.byPayloadType[f.PayloadType()] = uint32(idx)
+			if _, ok := f.(*format.H264); ok {
+				mi.h264Types[f.PayloadType()] = true
+			}
+			if _, ok := f.(*format.MJPEG); ok {
+				mi.mjpegTypes[f.PayloadType()] = true
+			}
+		}
+	}
+	return mi
+}
+
+func (mi *mediaIndexer) indexRTP(payloadType uint8, ssrc uint32) uint32 {
+	idx := mi.byPayloadType[payloadType]
+	mi.bySSRC[ssrc] = idx
+	return idx
+}
+
+// keyframe tells if the RTP packet starts a frame a decoder may begin with:
+// an IDR with its parameter sets in H264, any image in M-JPEG.
+func (mi *mediaIndexer) keyframe(pkt *rtp.Packet) bool {
+	switch {
+	case mi.h264Types[pkt.PayloadType]:
+		return utils.IsKeyFrameH264(pkt.Payload)
+	case mi.mjpegTypes[pkt.PayloadType]:
+		return utils.IsFrameStartMJPEG(pkt.Payload)
+	default:
+		return false
+	}
+}
+
+func (mi *mediaIndexer) indexRTCP(pkt []byte) uint32 {
+	// All the RTCP packets start with the SSRC of their sender, after a 4 bytes header
+	if len(pkt) < 8 {
+		return 0
+	}
+	return mi.bySSRC[binary.BigEndian.Uint32(pkt[4:8])]
+}
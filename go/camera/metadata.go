@@ -0,0 +1,195 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package camera
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jfsmig/cams/go/rtsp1/pkg/codecs/h264"
+	"github.com/jfsmig/cams/go/rtsp1/pkg/format"
+	"github.com/jfsmig/cams/go/rtsp1/pkg/media"
+	"github.com/jfsmig/onvif/device"
+	"github.com/juju/errors"
+)
+
+// How long a camera that couldn't be described is left alone, the delay
+// doubles with each failure.
+const (
+	metadataBackoffMin = 30 * time.Second
+	metadataBackoffMax = 10 * time.Minute
+)
+
+// ErrMetadataBackoff tells the query of the description of the camera failed
+// recently and is postponed.
+var ErrMetadataBackoff = errors.New("metadata query postponed")
+
+// Metadata describes a camera to the viewers
+type Metadata struct {
+	Manufacturer string
+	Model        string
+	Firmware     string
+	Profiles     []Profile
+}
+
+// Profile describes a media profile of the camera
+type Profile struct {
+	Token  string
+	Name   string
+	Codec  string
+	Width  int
+	Height int
+	FPS    float64
+}
+
+// VideoFormat is what the SDP of a stream tells about its video
+type VideoFormat struct {
+	Codec  string
+	Width  int
+	Height int
+	FPS    float64
+}
+
+// deviceDescription is the description of a device, queried once for all the
+// streams of its profiles. After a failure, the query is postponed for a while.
+type deviceDescription struct {
+	lock     sync.Mutex
+	metadata *Metadata
+	retry    time.Time
+	backoff  time.Duration
+}
+
+func (dd *deviceDescription) get(ctx context.Context, query func(context.Context) (Metadata, error)) (Metadata, error) {
+	dd.lock.Lock()
+	defer dd.lock.Unlock()
+
+	if dd.metadata == nil {
+		if time.Now().Before(dd.retry) {
+			return Metadata{}, ErrMetadataBackoff
+		}
+		md, err := query(ctx)
+		if err != nil {
+			dd.backoff = min(max(2*dd.backoff, metadataBackoffMin), metadataBackoffMax)
+			dd.retry = time.Now().Add(dd.backoff)
+			return Metadata{}, err
+		}
+		dd.metadata = &md
+	}
+	return *dd.metadata, nil
+}
+
+// Metadata returns the description of the camera. The device and its profiles
+// are only queried once, while the details of the streamed profile are
+// refreshed by each description of the stream. The camera of a profile only
+// describes its profile.
+func (cam *Camera) Metadata(ctx context.Context) (Metadata, error) {
+	device, err := cam.description.get(ctx, cam.queryMetadata)
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	cam.metadataLock.Lock()
+	defer cam.metadataLock.Unlock()
+
+	out := device
+	out.Profiles = nil
+	for _, p := range device.Profiles {
+		if len(cam.profile.Token) <= 0 || p.Token == cam.profile.Token {
+			out.Profiles = append(out.Profiles, p)
+		}
+	}
+	if len(out.Profiles) <= 0 && len(cam.streamFormat.Codec) > 0 {
+		// No profile to describe the stream, e.g. for a plain RTSP source
+		out.Profiles = []Profile{{Name: "stream"}}
+	}
+	if len(out.Profiles) > 0 && len(cam.streamFormat.Codec) > 0 {
+		// The first profile is the one streamed, see FetchStreamURI, unless
+		// the camera streams a given profile
+		p := &out.Profiles[0]
+		p.Codec = cam.streamFormat.Codec
+		if cam.streamFormat.Width > 0 {
+			p.Width, p.Height = cam.streamFormat.Width, cam.streamFormat.Height
+		}
+		if cam.streamFormat.FPS > 0 {
+			p.FPS = cam.streamFormat.FPS
+		}
+	}
+	return out, nil
+}
+
+func (cam *Camera) queryMetadata(ctx context.Context) (Metadata, error) {
+	var md Metadata
+	if cam.onvifCalls == nil {
+		return md, nil
+	}
+
+	info, err := device.Call_GetDeviceInformation(ctx, cam.onvifCalls, device.GetDeviceInformation{})
+	if err != nil {
+		return md, errors.Annotate(err, "get device information")
+	}
+	md.Manufacturer = info.Manufacturer
+	md.Model = info.Model
+	md.Firmware = info.FirmwareVersion
+
+	if len(cam.onvifClient.GetEndpoint("media")) <= 0 {
+		return md, nil
+	}
+	profiles, err := queryProfiles(ctx, cam.onvifCalls)
+	if err != nil {
+		return md, err
+	}
+	for _, p := range profiles {
+		vec := p.VideoEncoder
+		md.Profiles = append(md.Profiles, Profile{
+			Token:  p.Token,
+			Name:   p.Name,
+			Codec:  vec.Encoding,
+			Width:  vec.Resolution.Width,
+			Height: vec.Resolution.Height,
+			FPS:    vec.RateControl.FrameRateLimit,
+		})
+	}
+	return md, nil
+}
+
+func (cam *Camera) setStreamFormat(vf VideoFormat) {
+	cam.metadataLock.Lock()
+	defer cam.metadataLock.Unlock()
+	cam.streamFormat = vf
+}
+
+// DescribeVideo extracts the codec of the first video media of a stream, and
+// its resolution and frame rate when the SDP carries the H264 parameters.
+func DescribeVideo(medias media.Medias) (VideoFormat, bool) {
+	for _, m := range medias {
+		if m.Type != media.TypeVideo || len(m.Formats) <= 0 {
+			continue
+		}
+		f := m.Formats[0]
+		vf := VideoFormat{Codec: f.String()}
+		if h, ok := f.(*format.H264); ok && len(h.SPS) > 0 {
+			var sps h264.SPS
+			if err := sps.Unmarshal(h.SPS); err == nil {
+				vf.Width = sps.Width()
+				vf.Height = sps.Height()
+				vf.FPS = sps.FPS()
+			}
+		}
+		return vf, true
+	}
+	return VideoFormat{}, false
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package camera
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jfsmig/cams/go/rtsp1/pkg/media"
+	"github.com/jfsmig/cams/go/rtsp1/pkg/sdp"
+	"github.com/jfsmig/onvif/networking"
+	"github.com/juju/errors"
+)
+
+func parseSDP(t *testing.T, body string) media.Medias {
+	var sd sdp.SessionDescription
+	if err := sd.Unmarshal([]byte(body)); err != nil {
+		t.Fatal(err)
+	}
+	var medias media.Medias
+	if err := medias.Unmarshal(sd.MediaDescriptions); err != nil {
+		t.Fatal(err)
+	}
+	return medias
+}
+
+func TestDescribeVideo_H264(t *testing.T) {
+	// The SPS of a Hikvision camera, 1280x960 at 25 FPS
+	sps := []byte{103, 100, 0, 32, 172, 23, 42, 1, 64, 30, 104, 64, 0, 1, 194, 0, 0, 87, 228, 33}
+	pps := []byte{104, 238, 60, 128}
+
+	medias := parseSDP(t, "v=0\r\n"+
+		"o=- 0 0 IN IP4 127.0.0.1\r\n"+
+		"s=cam\r\n"+
+		"t=0 0\r\n"+
+		"m=audio 0 RTP/AVP 0\r\n"+
+		"m=video 0 RTP/AVP 96\r\n"+
+		"a=rtpmap:96 H264/90000\r\n"+
+		"a=fmtp:96 packetization-mode=1; sprop-parameter-sets="+
+		base64.StdEncoding.EncodeToString(sps)+","+base64.StdEncoding.EncodeToString(pps)+"\r\n")
+
+	vf, ok := DescribeVideo(medias)
+	if !ok {
+		t.Fatal("no video")
+	}
+	if vf.Codec != "H264" || vf.Width != 1280 || vf.Height != 960 || vf.FPS != 25 {
+		t.Fatal("unexpected format", vf)
+	}
+}
+
+func TestDescribeVideo_MJPEG(t *testing.T) {
+	medias := parseSDP(t, "v=0\r\n"+
+		"o=- 0 0 IN IP4 127.0.0.1\r\n"+
+		"s=cam\r\n"+
+		"t=0 0\r\n"+
+		"m=video 0 RTP/AVP 26\r\n")
+
+	vf, ok := DescribeVideo(medias)
+	if !ok || vf.Codec != "M-JPEG" || vf.Width != 0 {
+		t.Fatal("unexpected format", vf)
+	}
+}
+
+func TestMetadata_Backoff(t *testing.T) {
+	client, appliance := connectFake(t, fakeMediaService(t, cameraProfiles))
+	cam := NewProfileCamera(nil, appliance, client, StreamProfile{Token: "p0", Name: "main"})
+	ctx := context.Background()
+
+	// The device stops answering
+	unreachable, err := networking.NewClient(networking.ClientInfo{Xaddr: "127.0.0.1:1"}, http.DefaultClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cam.SetClient(unreachable)
+	if _, err = cam.Metadata(ctx); err == nil || errors.Is(err, ErrMetadataBackoff) {
+		t.Fatal("unexpected error", err)
+	}
+	if _, err = cam.Metadata(ctx); !errors.Is(err, ErrMetadataBackoff) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// The delay doubles with each failure
+	cam.description.retry = time.Now()
+	if _, err = cam.Metadata(ctx); err == nil || errors.Is(err, ErrMetadataBackoff) {
+		t.Fatal("unexpected error", err)
+	}
+	if cam.description.backoff != 2*metadataBackoffMin {
+		t.Fatal("unexpected backoff", cam.description.backoff)
+	}
+
+	// The device is back once the delay elapsed
+	cam.SetClient(client)
+	if _, err = cam.Metadata(ctx); !errors.Is(err, ErrMetadataBackoff) {
+		t.Fatal("unexpected error", err)
+	}
+	cam.description.retry = time.Now()
+	md, err := cam.Metadata(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md.Manufacturer != "ACME" || len(md.Profiles) != 1 || md.Profiles[0].Token != "p0" {
+		t.Fatal("unexpected metadata", md)
+	}
+}
+
+func TestMetadata_Profiles(t *testing.T) {
+	server := fakeMediaService(t, cameraProfiles)
+	client, appliance := connectFake(t, server)
+	ctx := context.Background()
+
+	profiles, err := ListStreamProfiles(ctx, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	open := func(string) UploadOpenFunc { return nil }
+	cams := NewProfileCameras(open, appliance, client, profiles)
+	if len(cams) != 2 || cams[0].ID != "uuid" || cams[1].ID != "uuid/sub" {
+		t.Fatal("unexpected cameras", len(cams))
+	}
+
+	// The encoder settings of each profile, whatever their namespace
+	md, err := cams[1].Metadata(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Profile{Token: "p1", Name: "SubStream", Codec: "JPEG", Width: 640, Height: 360, FPS: 15}
+	if md.Manufacturer != "ACME" || len(md.Profiles) != 1 || md.Profiles[0] != expected {
+		t.Fatal("unexpected metadata", md)
+	}
+
+	// The device is queried once for all its streams
+	server.Close()
+	if md, err = cams[0].Metadata(ctx); err != nil {
+		t.Fatal(err)
+	}
+	expected = Profile{Token: "p0", Name: "MainStream", Codec: "H264", Width: 1920, Height: 1080, FPS: 25}
+	if len(md.Profiles) != 1 || md.Profiles[0] != expected {
+		t.Fatal("unexpected metadata", md)
+	}
+}
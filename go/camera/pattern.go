@@ -0,0 +1,199 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package camera
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"time"
+
+	"github.com/jfsmig/cams/go/rtsp1/pkg/format"
+	"github.com/jfsmig/cams/go/rtsp1/pkg/media"
+	"github.com/jfsmig/cams/go/rtsp1/pkg/rtpmjpeg"
+	"github.com/jfsmig/cams/go/rtsp1/pkg/url"
+	"github.com/juju/errors"
+	"github.com/pion/rtp"
+)
+
+const (
+	DefaultPatternWidth  = 640
+	DefaultPatternHeight = 480
+	DefaultPatternFPS    = 10
+
+	// The bounds of RFC 2435, in pixels
+	maxPatternSize = 2040
+
+	// Beyond, the period of the images rounds down to nothing
+	maxPatternFPS = 120
+)
+
+// The time burned in each image of the test pattern
+const patternTimeLayout = "2006-01-02 15:04:05.000"
+
+// The colors of the moving bars of the test pattern
+var patternBars = []color.RGBA{
+	{192, 192, 192, 255}, {192, 192, 0, 255}, {0, 192, 192, 255}, {0, 192, 0, 255},
+	{192, 0, 192, 255}, {192, 0, 0, 255}, {0, 0, 192, 255}, {16, 16, 16, 255},
+}
+
+// PatternSource is a camera without hardware, for the demos and the tests. It
+// streams moving color bars with the time burned in, as M-JPEG.
+type PatternSource struct {
+	id     string
+	width  int
+	height int
+	fps    float64
+}
+
+// NewPatternSource checks the format of the stream, the zero values stand for
+// the defaults. The dimensions must be multiples of 8, up to 2040 pixels, and
+// the frame rate up to 120 images per second.
+func NewPatternSource(id string, width, height int, fps float64) (*PatternSource, error) {
+	if len(id) <= 0 {
+		return nil, errors.New("missing ID")
+	}
+	if width == 0 {
+		width = DefaultPatternWidth
+	}
+	if height == 0 {
+		height = DefaultPatternHeight
+	}
+	if fps == 0 {
+		fps = DefaultPatternFPS
+	}
+	for _, dim := range []int{width, height} {
+		if dim < 0 || dim > maxPatternSize || dim%8 != 0 {
+			return nil, errors.Errorf("invalid size %dx%d", width, height)
+		}
+	}
+	if fps < 0 || fps > maxPatternFPS {
+		return nil, errors.Errorf("invalid frame rate %v", fps)
+	}
+	return &PatternSource{id: id, width: width, height: height, fps: fps}, nil
+}
+
+func (s *PatternSource) ID() string { return s.id }
+
+func (s *PatternSource) StreamURL(ctx context.Context) (*url.URL, error) {
+	return nil, errors.New("generated stream")
+}
+
+func (s *PatternSource) Describe() (media.Medias, VideoFormat) {
+	f := &format.MJPEG{}
+	medias := media.Medias{{Type: media.TypeVideo, Formats: []format.Format{f}}}
+	return medias, VideoFormat{Codec: f.String(), Width: s.width, Height: s.height, FPS: s.fps}
+}
+
+func (s *PatternSource) Generate(ctx context.Context, emit func(pkt *rtp.Packet) error) error {
+	var encoder rtpmjpeg.Encoder
+	encoder.Init()
+
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / s.fps))
+	defer ticker.Stop()
+
+	var buf bytes.Buffer
+	start := time.Now()
+	for frame := 0; ; frame++ {
+		now := time.Now()
+		buf.Reset()
+		if err := jpeg.Encode(&buf, s.render(frame, now), nil); err != nil {
+			return errors.Annotate(err, "jpeg")
+		}
+		pkts, err := encoder.Encode(buf.Bytes(), now.Sub(start))
+		if err != nil {
+			return errors.Annotate(err, "rtp/mjpeg")
+		}
+		for _, pkt := range pkts {
+			if err = emit(pkt); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// render draws the bars, shifted by the frame number, and the time at the
+// bottom left corner.
+func (s *PatternSource) render(frame int, now time.Time) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, s.width, s.height))
+
+	barWidth := (s.width + len(patternBars) - 1) / len(patternBars)
+	shift := frame * 4
+	for x := 0; x < s.width; x++ {
+		c := patternBars[((x+shift)/barWidth)%len(patternBars)]
+		for y := 0; y < s.height; y++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+
+	text := now.Format(patternTimeLayout)
+	scale := s.height / 120
+	if fit := s.width / (4*len(text) + 2); scale > fit {
+		scale = fit
+	}
+	if scale < 1 {
+		scale = 1
+	}
+	x0, y0 := scale, s.height-7*scale
+	fill(img, image.Rect(0, y0-scale, (4*len(text)+1)*scale, s.height), color.RGBA{A: 255})
+	for i, r := range text {
+		glyph := patternGlyphs[r]
+		for row := 0; row < 5; row++ {
+			for col := 0; col < 3; col++ {
+				if glyph[row]&(4>>col) == 0 {
+					continue
+				}
+				x, y := x0+(4*i+col)*scale, y0+row*scale
+				fill(img, image.Rect(x, y, x+scale, y+scale), color.RGBA{255, 255, 255, 255})
+			}
+		}
+	}
+	return img
+}
+
+func fill(img *image.RGBA, r image.Rectangle, c color.RGBA) {
+	r = r.Intersect(img.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
+
+// The glyphs of the burned-in time, 3x5 pixels, one row per byte
+var patternGlyphs = map[rune][5]byte{
+	'0': {7, 5, 5, 5, 7},
+	'1': {2, 6, 2, 2, 7},
+	'2': {7, 1, 7, 4, 7},
+	'3': {7, 1, 7, 1, 7},
+	'4': {5, 5, 7, 1, 1},
+	'5': {7, 4, 7, 1, 7},
+	'6': {7, 4, 7, 5, 7},
+	'7': {7, 1, 1, 1, 1},
+	'8': {7, 5, 7, 5, 7},
+	'9': {7, 5, 7, 1, 7},
+	'-': {0, 0, 7, 0, 0},
+	':': {0, 2, 0, 2, 0},
+	'.': {0, 0, 0, 0, 2},
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package camera
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image/jpeg"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingUpload keeps what a camera uploads
+type recordingUpload struct {
+	lock sync.Mutex
+	sdp  string
+	rtp  int
+
+	// The sequence number and the timestamp of each RTP packet
+	seqs   []uint16
+	stamps []uint32
+}
+
+func (up *recordingUpload) Close() {}
+
+func (up *recordingUpload) OnSDP(sdp string) error {
+	up.lock.Lock()
+	defer up.lock.Unlock()
+	up.sdp = sdp
+	return nil
+}
+
+func (up *recordingUpload) OnRTP(info PacketInfo, pkt []byte) error {
+	up.lock.Lock()
+	defer up.lock.Unlock()
+	up.rtp++
+	up.seqs = append(up.seqs, binary.BigEndian.Uint16(pkt[2:]))
+	up.stamps = append(up.stamps, binary.BigEndian.Uint32(pkt[4:]))
+	return nil
+}
+
+func (up *recordingUpload) OnRTCP(info PacketInfo, pkt []byte) error { return nil }
+
+func TestPatternSource_Invalid(t *testing.T) {
+	for _, dims := range [][2]int{{100, 80}, {640, 2048}, {-8, 480}} {
+		if _, err := NewPatternSource("test", dims[0], dims[1], 0); err == nil {
+			t.Fatal("unexpected success", dims)
+		}
+	}
+	if _, err := NewPatternSource("", 0, 0, 0); err == nil {
+		t.Fatal("unexpected success")
+	}
+	for _, fps := range []float64{-1, 121, 1e12} {
+		if _, err := NewPatternSource("test", 0, 0, fps); err == nil {
+			t.Fatal("unexpected success", fps)
+		}
+	}
+	if _, err := NewPatternSource("test", 0, 0, 120); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPatternSource_Stream(t *testing.T) {
+	src, err := NewPatternSource("test", 320, 240, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	up := &recordingUpload{}
+	cam := NewSourceCamera(func(ctx context.Context) (UpstreamMedia, error) { return up, nil }, src)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err = cam.runStreamOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(up.sdp, "m=video 0 RTP/AVP 26") || up.rtp <= 0 {
+		t.Fatal("unexpected upload", up.sdp, up.rtp)
+	}
+	if st := cam.Status(); st.Stats.RtpPackets != uint64(up.rtp) {
+		t.Fatal("unexpected stats", st.Stats)
+	}
+
+	// The last frame stands as the snapshot, it is a valid image
+	snap, _, err := cam.Snapshot(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := jpeg.Decode(bytes.NewReader(snap))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 320 || img.Bounds().Dy() != 240 {
+		t.Fatal("unexpected size", img.Bounds())
+	}
+
+	md, err := cam.Metadata(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(md.Profiles) != 1 || md.Profiles[0].Codec != "M-JPEG" || md.Profiles[0].Width != 320 {
+		t.Fatal("unexpected metadata", md)
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package camera
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jfsmig/onvif/media"
+	"github.com/jfsmig/onvif/networking"
+	"github.com/jfsmig/onvif/sdk"
+	"github.com/juju/errors"
+)
+
+// StreamProfile is a media profile of an ONVIF camera, streamed on its own
+type StreamProfile struct {
+	// The token of the profile in the camera, empty for the default stream
+	Token string
+
+	// Names the stream among those of its channel: main, sub, sub2...
+	Name string
+
+	// Names the channel of the profile, empty unless the device has several
+	// video sources, e.g. an NVR or an encoder
+	Channel string
+
+	// The video source of the profile and its configuration, to route the
+	// events of the device to the channel
+	Source       string
+	SourceConfig string
+}
+
+// onvifProfile is a media profile, as much as the agent needs to know.
+// The types of the ONVIF module miss the elements of the schema namespace,
+// the reply is decoded here.
+type onvifProfile struct {
+	Token string `xml:"token,attr"`
+	Name  string `xml:"Name"`
+
+	VideoSource struct {
+		Token       string `xml:"token,attr"`
+		SourceToken string `xml:"SourceToken"`
+	} `xml:"VideoSourceConfiguration"`
+
+	PTZ struct {
+		Token string `xml:"token,attr"`
+	} `xml:"PTZConfiguration"`
+
+	VideoEncoder struct {
+		Encoding   string `xml:"Encoding"`
+		Resolution struct {
+			Width  int `xml:"Width"`
+			Height int `xml:"Height"`
+		} `xml:"Resolution"`
+		RateControl struct {
+			FrameRateLimit float64 `xml:"FrameRateLimit"`
+		} `xml:"RateControl"`
+	} `xml:"VideoEncoderConfiguration"`
+}
+
+type profilesEnvelope struct {
+	Body struct {
+		GetProfilesResponse struct {
+			Profiles []onvifProfile `xml:"Profiles"`
+		} `xml:"GetProfilesResponse"`
+	} `xml:"Body"`
+}
+
+// ProfileStreamName names the stream of the profile at the given rank in its
+// channel, the cameras list their main profile first
+func ProfileStreamName(rank int) string {
+	switch rank {
+	case 0:
+		return "main"
+	case 1:
+		return "sub"
+	default:
+		return fmt.Sprintf("sub%d", rank)
+	}
+}
+
+// ProfileStreamID identifies the stream of a profile of the device. The main
+// profile keeps the ID of the device, known from before the profiles were
+// streamed apart, the others are "<uuid>/<name>". On a device with channels,
+// all are "<uuid>/<channel>/<name>".
+func ProfileStreamID(deviceID string, profile StreamProfile) string {
+	if len(profile.Channel) > 0 {
+		return deviceID + "/" + profile.Channel + "/" + profile.Name
+	}
+	if len(profile.Name) <= 0 || profile.Name == ProfileStreamName(0) {
+		return deviceID
+	}
+	return deviceID + "/" + profile.Name
+}
+
+// channelName derives the name of a channel from the token of its video
+// source, that is stable across the restarts of the device
+func channelName(source string) string {
+	if len(source) <= 0 {
+		return "default"
+	}
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '_'
+	}, source)
+}
+
+// queryProfiles lists the media profiles of the device
+func queryProfiles(ctx context.Context, client *networking.Client) ([]onvifProfile, error) {
+	rep, err := client.CallMethod(ctx, media.GetProfiles{})
+	if err != nil {
+		return nil, errors.Annotate(err, "get profiles")
+	}
+	defer rep.Body.Close()
+	if rep.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("get profiles: http status %d", rep.StatusCode)
+	}
+	var reply profilesEnvelope
+	if err = xml.NewDecoder(rep.Body).Decode(&reply); err != nil {
+		return nil, errors.Annotate(err, "get profiles: decode")
+	}
+	return reply.Body.GetProfilesResponse.Profiles, nil
+}
+
+// ListStreamProfiles queries the media profiles of the device. The profiles
+// are grouped in channels by video source, in the order of the device. A
+// device without media service has the default stream only.
+func ListStreamProfiles(ctx context.Context, client *networking.Client) ([]StreamProfile, error) {
+	if len(client.GetEndpoint("media")) <= 0 {
+		return []StreamProfile{{Name: ProfileStreamName(0)}}, nil
+	}
+	profiles, err := queryProfiles(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	var sources []string
+	bySource := make(map[string][]StreamProfile)
+	for _, p := range profiles {
+		if len(p.Token) <= 0 {
+			continue
+		}
+		source := p.VideoSource.SourceToken
+		if _, ok := bySource[source]; !ok {
+			sources = append(sources, source)
+		}
+		bySource[source] = append(bySource[source], StreamProfile{
+			Token:        p.Token,
+			Name:         ProfileStreamName(len(bySource[source])),
+			Source:       source,
+			SourceConfig: p.VideoSource.Token,
+		})
+	}
+	if len(sources) <= 0 {
+		return nil, errors.New("no profile")
+	}
+
+	out := make([]StreamProfile, 0, len(profiles))
+	for _, source := range sources {
+		for _, p := range bySource[source] {
+			if len(sources) > 1 {
+				p.Channel = channelName(source)
+			}
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+// NewProfileCamera manages a profile of an ONVIF device, see ProfileStreamID
+func NewProfileCamera(open UploadOpenFunc, appliance sdk.Appliance, client *networking.Client, profile StreamProfile) *Camera {
+	cam := NewSourceCamera(open, onvifSource{appliance: appliance, client: client, profile: profile})
+	cam.Device = appliance.GetUUID()
+	cam.onvifClient = appliance
+	cam.profile = profile
+	cam.SetClient(client)
+	return cam
+}
+
+// NewProfileCameras manages all the given profiles of an ONVIF device, that
+// share the description of the device.
+func NewProfileCameras(open func(streamID string) UploadOpenFunc, appliance sdk.Appliance, client *networking.Client, profiles []StreamProfile) []*Camera {
+	shared := &deviceDescription{}
+	out := make([]*Camera, 0, len(profiles))
+	for _, profile := range profiles {
+		cam := NewProfileCamera(open(ProfileStreamID(appliance.GetUUID(), profile)), appliance, client, profile)
+		cam.description = shared
+		out = append(out, cam)
+	}
+	return out
+}
+
+// ReportsEvent tells if the stream reports the event of its device: the main
+// stream of the channel whose video source, or its configuration, is the
+// source of the event
+func (cam *Camera) ReportsEvent(event Event) bool {
+	p := cam.profile
+	if len(p.Channel) <= 0 || p.Name != ProfileStreamName(0) {
+		return false
+	}
+	for _, v := range event.Source {
+		if len(v) > 0 && (v == p.Source || v == p.SourceConfig) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,209 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package camera
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jfsmig/onvif/networking"
+	"github.com/jfsmig/onvif/sdk"
+)
+
+const fakeEnvelope = `<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope"
+  xmlns:tds="http://www.onvif.org/ver10/device/wsdl" xmlns:trt="http://www.onvif.org/ver10/media/wsdl"
+  xmlns:tt="http://www.onvif.org/ver10/schema"><env:Body>%s</env:Body></env:Envelope>`
+
+// The profiles of a camera, a main and a sub profile of the same source
+const cameraProfiles = `<trt:Profiles token="p0"><tt:Name>MainStream</tt:Name>
+<tt:VideoSourceConfiguration token="vsc0"><tt:SourceToken>vs0</tt:SourceToken></tt:VideoSourceConfiguration>
+<tt:VideoEncoderConfiguration token="vec0"><tt:Encoding>H264</tt:Encoding>
+<tt:Resolution><tt:Width>1920</tt:Width><tt:Height>1080</tt:Height></tt:Resolution>
+<tt:RateControl><tt:FrameRateLimit>25</tt:FrameRateLimit></tt:RateControl></tt:VideoEncoderConfiguration></trt:Profiles>
+<trt:Profiles token="p1"><tt:Name>SubStream</tt:Name>
+<tt:VideoSourceConfiguration token="vsc0"><tt:SourceToken>vs0</tt:SourceToken></tt:VideoSourceConfiguration>
+<tt:VideoEncoderConfiguration token="vec1"><tt:Encoding>JPEG</tt:Encoding>
+<tt:Resolution><tt:Width>640</tt:Width><tt:Height>360</tt:Height></tt:Resolution>
+<tt:RateControl><tt:FrameRateLimit>15</tt:FrameRateLimit></tt:RateControl></tt:VideoEncoderConfiguration></trt:Profiles>`
+
+// The profiles of a NVR with two channels, the second has a PTZ head
+const nvrProfiles = `<trt:Profiles token="p0"><tt:Name>Ch1Main</tt:Name>
+<tt:VideoSourceConfiguration token="vsc1"><tt:SourceToken>VideoSource_1</tt:SourceToken></tt:VideoSourceConfiguration></trt:Profiles>
+<trt:Profiles token="p2"><tt:Name>Ch2Main</tt:Name>
+<tt:VideoSourceConfiguration token="vsc2"><tt:SourceToken>VideoSource 2</tt:SourceToken></tt:VideoSourceConfiguration>
+<tt:PTZConfiguration token="ptz2"/></trt:Profiles>
+<trt:Profiles token="p1"><tt:Name>Ch1Sub</tt:Name>
+<tt:VideoSourceConfiguration token="vsc1"><tt:SourceToken>VideoSource_1</tt:SourceToken></tt:VideoSourceConfiguration></trt:Profiles>`
+
+// fakeMediaService mimics the device, media and PTZ services of an ONVIF
+// device with the given profiles
+func fakeMediaService(t *testing.T, profiles string) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		request := string(body)
+
+		var reply string
+		switch {
+		case strings.Contains(request, "GetSystemDateAndTime"):
+			reply = `<tds:GetSystemDateAndTimeResponse/>`
+		case strings.Contains(request, "GetCapabilities"):
+			reply = `<tds:GetCapabilitiesResponse><tds:Capabilities>
+<tt:Media><tt:XAddr>` + server.URL + `/onvif/media</tt:XAddr></tt:Media>
+<tt:PTZ><tt:XAddr>` + server.URL + `/onvif/ptz</tt:XAddr></tt:PTZ>
+</tds:Capabilities></tds:GetCapabilitiesResponse>`
+		case strings.Contains(request, "GetDeviceInformation"):
+			reply = `<tds:GetDeviceInformationResponse><tds:Manufacturer>ACME</tds:Manufacturer></tds:GetDeviceInformationResponse>`
+		case strings.Contains(request, "GetProfiles"):
+			reply = `<trt:GetProfilesResponse>` + profiles + `</trt:GetProfilesResponse>`
+		case strings.Contains(request, "GetStreamUri"):
+			stream := "main"
+			if strings.Contains(request, ">p1<") {
+				stream = "sub"
+			}
+			reply = `<trt:GetStreamUriResponse><trt:MediaUri><tt:Uri>rtsp://10.0.0.5:554/` + stream + `</tt:Uri></trt:MediaUri></trt:GetStreamUriResponse>`
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = fmt.Fprintf(w, fakeEnvelope, reply)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestProfileStreamName(t *testing.T) {
+	for rank, expected := range []string{"main", "sub", "sub2"} {
+		if name := ProfileStreamName(rank); name != expected {
+			t.Fatal("unexpected name", rank, name)
+		}
+	}
+}
+
+// connectFake connects to the fake device as "uuid"
+func connectFake(t *testing.T, server *httptest.Server) (*networking.Client, sdk.Appliance) {
+	client, err := networking.NewClient(networking.ClientInfo{
+		Xaddr: strings.TrimPrefix(server.URL, "http://"),
+		Uuid:  "uuid",
+	}, http.DefaultClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appliance, err := sdk.WrapClient(context.Background(), client, networking.ClientAuth{Username: "admin", Password: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, appliance
+}
+
+func TestProfileCamera(t *testing.T) {
+	client, appliance := connectFake(t, fakeMediaService(t, cameraProfiles))
+	ctx := context.Background()
+
+	profiles, err := ListStreamProfiles(ctx, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := StreamProfile{Token: "p0", Name: "main", Source: "vs0", SourceConfig: "vsc0"}
+	if len(profiles) != 2 || profiles[0] != expected || profiles[1].Name != "sub" || len(profiles[1].Channel) > 0 {
+		t.Fatal("unexpected profiles", profiles)
+	}
+
+	// The main stream keeps the ID of the device
+	if main := NewProfileCamera(nil, appliance, client, profiles[0]); main.ID != "uuid" {
+		t.Fatal("unexpected ID", main.ID)
+	}
+
+	cam := NewProfileCamera(nil, appliance, client, profiles[1])
+	if cam.ID != "uuid/sub" || cam.Device != "uuid" {
+		t.Fatal("unexpected IDs", cam.ID, cam.Device)
+	}
+
+	u, err := cam.source.StreamURL(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if password, _ := u.User.Password(); u.Path != "/sub" || u.User.Username() != "admin" || password != "secret" {
+		t.Fatal("unexpected URL", u)
+	}
+
+	// The stream only describes its own profile
+	md, err := cam.Metadata(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md.Manufacturer != "ACME" || len(md.Profiles) != 1 || md.Profiles[0].Token != "p1" || md.Profiles[0].Name != "SubStream" {
+		t.Fatal("unexpected metadata", md)
+	}
+}
+
+func TestProfileCamera_Channels(t *testing.T) {
+	client, appliance := connectFake(t, fakeMediaService(t, nvrProfiles))
+	ctx := context.Background()
+
+	profiles, err := ListStreamProfiles(ctx, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The streams are grouped by channel, named after their video source
+	var cams []*Camera
+	for _, profile := range profiles {
+		cams = append(cams, NewProfileCamera(nil, appliance, client, profile))
+	}
+	expected := []struct{ id, token string }{
+		{"uuid/VideoSource_1/main", "p0"},
+		{"uuid/VideoSource_1/sub", "p1"},
+		{"uuid/VideoSource_2/main", "p2"},
+	}
+	if len(cams) != len(expected) {
+		t.Fatal("unexpected profiles", profiles)
+	}
+	for i, e := range expected {
+		if cams[i].ID != e.id || cams[i].Device != "uuid" || cams[i].profile.Token != e.token {
+			t.Fatal("unexpected stream", i, cams[i].ID, cams[i].profile)
+		}
+	}
+
+	// The events are reported by the main stream of their channel
+	event := Event{CamID: "uuid/VideoSource_1/main", Source: map[string]string{"VideoSourceConfigurationToken": "vsc2"}}
+	for i, reports := range []bool{false, false, true} {
+		if cams[i].ReportsEvent(event) != reports {
+			t.Fatal("unexpected event routing", cams[i].ID)
+		}
+	}
+
+	// Only the channel with a PTZ head moves
+	if _, err = cams[0].ptzProfile(ctx); err != ErrNoPTZ {
+		t.Fatal("unexpected PTZ", err)
+	}
+	if token, err := cams[2].ptzProfile(ctx); err != nil || token != "p2" {
+		t.Fatal("unexpected PTZ", token, err)
+	}
+}
+
+func TestChannelName(t *testing.T) {
+	for source, expected := range map[string]string{"": "default", "VideoSource_1": "VideoSource_1", "ch 2/a": "ch_2_a"} {
+		if name := channelName(source); name != expected {
+			t.Fatal("unexpected name", source, name)
+		}
+	}
+}
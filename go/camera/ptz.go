@@ -0,0 +1,162 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package camera
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jfsmig/onvif/networking"
+	"github.com/jfsmig/onvif/ptz"
+	"github.com/jfsmig/onvif/xsd"
+	"github.com/jfsmig/onvif/xsd/onvif"
+	"github.com/juju/errors"
+)
+
+// How long a continuous move lasts, when the caller didn't tell
+const ptzDefaultTimeout = time.Second
+
+var ErrNoPTZ = errors.New("no PTZ service")
+
+// PTZMove is a continuous move, with velocities in [-1,1]
+type PTZMove struct {
+	Pan, Tilt, Zoom float64
+
+	// The camera stops after that delay, unless stopped before
+	Timeout time.Duration
+}
+
+type PTZPreset struct {
+	Token string
+	Name  string
+}
+
+// SetClient registers the ONVIF client the appliance of the camera is built on,
+// that serves the calls not wrapped by the appliance. It must be called before Run.
+func (cam *Camera) SetClient(client *networking.Client) { cam.onvifCalls = client }
+
+// PTZContinuousMove starts moving the camera
+func (cam *Camera) PTZContinuousMove(ctx context.Context, move PTZMove) error {
+	profile, err := cam.ptzProfile(ctx)
+	if err != nil {
+		return err
+	}
+	if move.Timeout <= 0 {
+		move.Timeout = ptzDefaultTimeout
+	}
+	_, err = ptz.Call_ContinuousMove(ctx, cam.onvifCalls, ptz.ContinuousMove{
+		ProfileToken: profile,
+		Velocity: onvif.PTZSpeed{
+			PanTilt: onvif.Vector2D{X: clampVelocity(move.Pan), Y: clampVelocity(move.Tilt)},
+			Zoom:    onvif.Vector1D{X: clampVelocity(move.Zoom)},
+		},
+		Timeout: xsd.Duration(fmt.Sprintf("PT%.3fS", move.Timeout.Seconds())),
+	})
+	return errors.Annotate(err, "continuous move")
+}
+
+// PTZStop stops any move of the camera
+func (cam *Camera) PTZStop(ctx context.Context) error {
+	profile, err := cam.ptzProfile(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = ptz.Call_Stop(ctx, cam.onvifCalls, ptz.Stop{ProfileToken: profile, PanTilt: true, Zoom: true})
+	return errors.Annotate(err, "stop")
+}
+
+// PTZGotoPreset moves the camera to the position saved as the given preset
+func (cam *Camera) PTZGotoPreset(ctx context.Context, token string) error {
+	profile, err := cam.ptzProfile(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = ptz.Call_GotoPreset(ctx, cam.onvifCalls, ptz.GotoPreset{
+		ProfileToken: profile,
+		PresetToken:  onvif.ReferenceToken(token),
+		Speed: onvif.PTZSpeed{
+			PanTilt: onvif.Vector2D{X: 1, Y: 1},
+			Zoom:    onvif.Vector1D{X: 1},
+		},
+	})
+	return errors.Annotate(err, "goto preset")
+}
+
+// PTZPresets lists the presets saved in the camera
+func (cam *Camera) PTZPresets(ctx context.Context) ([]PTZPreset, error) {
+	profile, err := cam.ptzProfile(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := ptz.Call_GetPresets(ctx, cam.onvifCalls, ptz.GetPresets{ProfileToken: profile})
+	if err != nil {
+		return nil, errors.Annotate(err, "get presets")
+	}
+	out := make([]PTZPreset, 0, len(reply.Preset))
+	for _, p := range reply.Preset {
+		out = append(out, PTZPreset{Token: string(p.Token), Name: string(p.Name)})
+	}
+	return out, nil
+}
+
+// ptzProfile locates the media profile bound to a PTZ configuration, that the
+// PTZ calls refer to. It is only looked up once. The stream prefers its own
+// profile, and a channel of a device never moves the head of another channel.
+func (cam *Camera) ptzProfile(ctx context.Context) (onvif.ReferenceToken, error) {
+	if cam.onvifCalls == nil || len(cam.onvifClient.GetEndpoint("ptz")) <= 0 {
+		return "", ErrNoPTZ
+	}
+
+	cam.ptzLock.Lock()
+	defer cam.ptzLock.Unlock()
+	if len(cam.ptzProfileToken) > 0 {
+		return cam.ptzProfileToken, nil
+	}
+
+	profiles, err := queryProfiles(ctx, cam.onvifCalls)
+	if err != nil {
+		return "", err
+	}
+	var found onvif.ReferenceToken
+	for _, profile := range profiles {
+		if len(profile.PTZ.Token) <= 0 {
+			continue
+		}
+		if profile.Token == cam.profile.Token {
+			found = onvif.ReferenceToken(profile.Token)
+			break
+		}
+		if len(found) <= 0 && (len(cam.profile.Channel) <= 0 || profile.VideoSource.SourceToken == cam.profile.Source) {
+			found = onvif.ReferenceToken(profile.Token)
+		}
+	}
+	if len(found) <= 0 {
+		return "", ErrNoPTZ
+	}
+	cam.ptzProfileToken = found
+	return found, nil
+}
+
+func clampVelocity(v float64) float64 {
+	if v < -1 {
+		return -1
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
@@ -0,0 +1,391 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package camera
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/binary"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/jfsmig/cams/go/rtsp1/pkg/media"
+	"github.com/jfsmig/cams/go/rtsp1/pkg/rtpmjpeg"
+	"github.com/jfsmig/cams/go/rtsp1/pkg/sdp"
+	"github.com/jfsmig/cams/go/rtsp1/pkg/url"
+	"github.com/jfsmig/cams/go/utils"
+	"github.com/juju/errors"
+	"github.com/pion/rtp"
+)
+
+// The kinds of entries of a capture archive, named after their extension
+const (
+	captureSDP  = "sdp"
+	captureRTP  = "rtp"
+	captureRTCP = "rtcp"
+)
+
+// ReplaySource is a camera replaying a capture archive, as written by
+// `cams-cli cam play`: a tar file of numbered entries, the SDP banner first,
+// then the RTP and RTCP packets. The packets are paced after the time of their
+// entry.
+type ReplaySource struct {
+	id   string
+	path string
+	loop bool
+}
+
+// NewReplaySource checks that the archive starts with a valid SDP banner.
+// When loop is set, the capture restarts at its end, else the camera stays
+// silent until its stream is stopped.
+func NewReplaySource(id, path string, loop bool) (*ReplaySource, error) {
+	if len(id) <= 0 {
+		return nil, errors.New("missing ID")
+	}
+	if _, _, err := readCaptureSDP(path); err != nil {
+		return nil, err
+	}
+	return &ReplaySource{id: id, path: path, loop: loop}, nil
+}
+
+func (s *ReplaySource) ID() string { return s.id }
+
+func (s *ReplaySource) StreamURL(ctx context.Context) (*url.URL, error) {
+	return nil, errors.New("replayed stream")
+}
+
+func (s *ReplaySource) String() string { return s.path }
+
+// runReplay uploads the packets of the capture, just like those received from
+// a camera. The loops share the same upload.
+func (cam *Camera) runReplay(ctx context.Context, src *ReplaySource) error {
+	banner, medias, err := readCaptureSDP(src.path)
+	if err != nil {
+		return err
+	}
+	if vf, ok := DescribeVideo(medias); ok {
+		cam.setStreamFormat(vf)
+	}
+
+	upload, err := cam.open(ctx)
+	if err != nil {
+		return errors.Annotate(err, "dial")
+	}
+	defer upload.Close()
+
+	if err = upload.OnSDP(string(banner)); err != nil {
+		return errors.Annotate(err, "send sdp banner")
+	}
+
+	coarse, err := captureCoarse(src.path)
+	if err != nil {
+		return err
+	}
+	clockRates, err := utils.ClockRates(string(banner))
+	if err != nil {
+		return err
+	}
+	pacer := newReplayPacer(coarse, clockRates)
+
+	indexer := newMediaIndexer(medias)
+	var mjpeg rtpmjpeg.Decoder
+	mjpeg.Init()
+
+	for ctx.Err() == nil {
+		count, err := cam.replayOnce(ctx, src.path, upload, indexer, &mjpeg, pacer)
+		if err != nil {
+			return err
+		}
+		// A capture without packets would loop crazily
+		if !src.loop || count <= 0 {
+			cam.debug().Str("path", src.path).Msg("replay ended")
+			<-ctx.Done()
+		}
+	}
+	return nil
+}
+
+// replayOnce uploads the packets of the capture once, paced like they were
+// captured, and tells how many. It returns early when the context ends.
+func (cam *Camera) replayOnce(ctx context.Context, path string, upload UpstreamMedia, indexer *mediaIndexer, mjpeg *rtpmjpeg.Decoder, pacer *replayPacer) (int, error) {
+	capture, err := openCapture(path)
+	if err != nil {
+		return 0, err
+	}
+	defer capture.Close()
+
+	pacer.restart()
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+
+	count := 0
+	for {
+		kind, at, payload, err := capture.next()
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+		if kind == captureSDP {
+			continue
+		}
+
+		var decoded *rtp.Packet
+		if kind == captureRTP {
+			decoded = &rtp.Packet{}
+			if err = decoded.Unmarshal(payload); err != nil {
+				utils.Logger.Warn().Int("size", len(payload)).Err(err).Msg("rtp")
+				decoded = nil
+			}
+		}
+
+		if wait := time.Until(pacer.due(at, decoded)); wait > 0 {
+			timer.Reset(wait)
+			select {
+			case <-ctx.Done():
+				return count, nil
+			case <-timer.C:
+			}
+		}
+
+		switch kind {
+		case captureRTP:
+			if decoded == nil {
+				break
+			}
+			pacer.rewrite(decoded, payload)
+			if err = cam.onRTP(upload, indexer, mjpeg, decoded, payload); err != nil {
+				return count, err
+			}
+		case captureRTCP:
+			if err = cam.onRTCP(upload, indexer, payload); err != nil {
+				return count, err
+			}
+		}
+		count++
+	}
+}
+
+// replayPacer schedules the packets of a capture, and rewrites their sequence
+// numbers and timestamps so that the loops follow each other like one stream.
+type replayPacer struct {
+	// The capture only holds whole seconds, e.g. an archive in the GNU format:
+	// the RTP packets are paced after their timestamps.
+	coarse     bool
+	clockRates map[uint8]uint32
+
+	// The time of the first entry of the pass, and when it was replayed
+	origin, start time.Time
+
+	// When the previous packet was due
+	last time.Time
+
+	tracks map[uint32]*replayTrack
+}
+
+// replayTrack is the state of a RTP stream of the capture, by SSRC
+type replayTrack struct {
+	// Set once a packet of the pass has been paced, then its timestamp and when it was due
+	paced  bool
+	prevTS uint32
+	prevAt time.Time
+
+	// Set once the offsets of the pass are known
+	rewritten bool
+	seqOffset uint16
+	tsOffset  uint32
+
+	// The last packet sent, the next pass continues after it
+	sent   bool
+	sentSN uint16
+	sentTS uint32
+	sentAt time.Time
+}
+
+func newReplayPacer(coarse bool, clockRates map[uint8]uint32) *replayPacer {
+	return &replayPacer{coarse: coarse, clockRates: clockRates, tracks: make(map[uint32]*replayTrack)}
+}
+
+func (p *replayPacer) track(ssrc uint32) *replayTrack {
+	t, ok := p.tracks[ssrc]
+	if !ok {
+		t = &replayTrack{}
+		p.tracks[ssrc] = t
+	}
+	return t
+}
+
+// restart starts a pass of the capture
+func (p *replayPacer) restart() {
+	p.origin = time.Time{}
+	for _, t := range p.tracks {
+		t.paced, t.rewritten = false, false
+	}
+}
+
+// due tells when an entry of the capture is due, given its time and its RTP
+// packet if it is one
+func (p *replayPacer) due(at time.Time, pkt *rtp.Packet) time.Time {
+	if p.origin.IsZero() {
+		p.origin, p.start = at, time.Now()
+		p.last = p.start
+	}
+	due := p.start.Add(at.Sub(p.origin))
+
+	if p.coarse {
+		// The entries without a clock follow the previous packet
+		rate := uint32(0)
+		if pkt != nil {
+			rate = p.clockRates[pkt.PayloadType]
+		}
+		if rate <= 0 {
+			return p.last
+		}
+		t := p.track(pkt.SSRC)
+		if t.paced {
+			ticks := int64(int32(pkt.Timestamp - t.prevTS))
+			due = t.prevAt.Add(time.Duration(ticks * int64(time.Second) / int64(rate)))
+		}
+		t.paced, t.prevTS, t.prevAt = true, pkt.Timestamp, due
+	}
+
+	if due.After(p.last) {
+		p.last = due
+	}
+	return due
+}
+
+// rewrite shifts the sequence number and the timestamp of the packet, in its
+// decoded and raw forms, to follow the packets of the previous pass
+func (p *replayPacer) rewrite(pkt *rtp.Packet, raw []byte) {
+	t := p.track(pkt.SSRC)
+	if !t.rewritten {
+		t.rewritten = true
+		if t.sent {
+			ticks := uint32(1)
+			if rate := p.clockRates[pkt.PayloadType]; rate > 0 {
+				ticks = max(ticks, uint32(time.Since(t.sentAt).Seconds()*float64(rate)))
+			}
+			t.seqOffset = t.sentSN + 1 - pkt.SequenceNumber
+			t.tsOffset = t.sentTS + ticks - pkt.Timestamp
+		}
+	}
+
+	pkt.SequenceNumber += t.seqOffset
+	pkt.Timestamp += t.tsOffset
+	binary.BigEndian.PutUint16(raw[2:], pkt.SequenceNumber)
+	binary.BigEndian.PutUint32(raw[4:], pkt.Timestamp)
+	t.sent, t.sentSN, t.sentTS, t.sentAt = true, pkt.SequenceNumber, pkt.Timestamp, time.Now()
+}
+
+// captureReader iterates the entries of a capture archive
+type captureReader struct {
+	file    *os.File
+	archive *tar.Reader
+}
+
+func openCapture(path string) (*captureReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Annotate(err, "open")
+	}
+	return &captureReader{file: f, archive: tar.NewReader(f)}, nil
+}
+
+func (cr *captureReader) Close() { _ = cr.file.Close() }
+
+// next returns the kind, the time and the content of the next entry, or
+// io.EOF at the end of the archive
+func (cr *captureReader) next() (string, time.Time, []byte, error) {
+	for {
+		hdr, err := cr.archive.Next()
+		if err == io.EOF {
+			return "", time.Time{}, nil, err
+		}
+		if err != nil {
+			return "", time.Time{}, nil, errors.Annotate(err, "tar header")
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		_, kind, _ := strings.Cut(path.Base(hdr.Name), ".")
+		switch kind {
+		case captureSDP, captureRTP, captureRTCP:
+		default:
+			return "", time.Time{}, nil, errors.Errorf("unexpected entry %q", hdr.Name)
+		}
+		payload, err := io.ReadAll(cr.archive)
+		if err != nil {
+			return "", time.Time{}, nil, errors.Annotate(err, "tar body")
+		}
+		return kind, hdr.ModTime, payload, nil
+	}
+}
+
+// captureCoarse tells if the times of the entries of the capture are whole
+// seconds, too coarse to pace the packets
+func captureCoarse(path string) (bool, error) {
+	capture, err := openCapture(path)
+	if err != nil {
+		return false, err
+	}
+	defer capture.Close()
+
+	for {
+		_, at, _, err := capture.next()
+		if err == io.EOF {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if at.Nanosecond() != 0 {
+			return false, nil
+		}
+	}
+}
+
+// readCaptureSDP returns the SDP banner that starts a capture, and its medias
+func readCaptureSDP(path string) ([]byte, media.Medias, error) {
+	capture, err := openCapture(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer capture.Close()
+
+	kind, _, banner, err := capture.next()
+	if err == io.EOF || (err == nil && kind != captureSDP) {
+		return nil, nil, errors.New("capture without sdp banner")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var sd sdp.SessionDescription
+	if err = sd.Unmarshal(banner); err != nil {
+		return nil, nil, errors.Annotate(err, "sdp")
+	}
+	var medias media.Medias
+	if err = medias.Unmarshal(sd.MediaDescriptions); err != nil {
+		return nil, nil, errors.Annotate(err, "sdp medias")
+	}
+	return banner, medias, nil
+}
@@ -0,0 +1,239 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package camera
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jfsmig/cams/go/rtsp1/pkg/rtpmjpeg"
+	"github.com/pion/rtcp"
+)
+
+type captureEntry struct {
+	kind    string
+	at      time.Duration
+	payload []byte
+}
+
+// writeCapture writes the entries like `cams-cli cam play` does
+func writeCapture(t *testing.T, entries []captureEntry) string {
+	return writeCaptureFormat(t, entries, tar.FormatPAX)
+}
+
+// writeCaptureFormat writes the entries in the given tar format, the GNU
+// format only keeps whole seconds
+func writeCaptureFormat(t *testing.T, entries []captureEntry, format tar.Format) string {
+	p := filepath.Join(t.TempDir(), "capture.tar")
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	w := tar.NewWriter(f)
+	origin := time.Now()
+	if format != tar.FormatPAX {
+		origin = origin.Truncate(time.Second)
+	}
+	for i, e := range entries {
+		hdr := tar.Header{
+			Name:     fmt.Sprintf("%06d.%s", i+1, e.kind),
+			Size:     int64(len(e.payload)),
+			ModTime:  origin.Add(e.at).Truncate(time.Second),
+			Mode:     0644,
+			Typeflag: tar.TypeReg,
+			Format:   format,
+		}
+		if format == tar.FormatPAX {
+			hdr.ModTime = origin.Add(e.at)
+		}
+		if err = w.WriteHeader(&hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = w.Write(e.payload); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// mjpegCapture is an M-JPEG stream of 3 frames over 200ms, and a RTCP report
+func mjpegCapture(t *testing.T) ([]captureEntry, int) {
+	pattern, err := NewPatternSource("test", 64, 48, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	medias, _ := pattern.Describe()
+	banner, err := medias.Marshal(false).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := []captureEntry{{kind: captureSDP, payload: banner}}
+
+	var encoder rtpmjpeg.Encoder
+	encoder.Init()
+	packets := 0
+	for i := 0; i < 3; i++ {
+		var buf bytes.Buffer
+		if err = jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 64, 48)), nil); err != nil {
+			t.Fatal(err)
+		}
+		at := time.Duration(i) * 100 * time.Millisecond
+		pkts, err := encoder.Encode(buf.Bytes(), at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, pkt := range pkts {
+			raw, err := pkt.Marshal()
+			if err != nil {
+				t.Fatal(err)
+			}
+			entries = append(entries, captureEntry{kind: captureRTP, at: at, payload: raw})
+			packets++
+		}
+	}
+
+	report, err := (&rtcp.SenderReport{SSRC: *encoder.SSRC}).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries = append(entries, captureEntry{kind: captureRTCP, at: 200 * time.Millisecond, payload: report})
+	return entries, packets
+}
+
+func TestReplaySource_Invalid(t *testing.T) {
+	if _, err := NewReplaySource("test", filepath.Join(t.TempDir(), "missing.tar"), false); err == nil {
+		t.Fatal("unexpected success")
+	}
+	noBanner := writeCapture(t, []captureEntry{{kind: captureRTP, payload: []byte{0x80, 26}}})
+	if _, err := NewReplaySource("test", noBanner, false); err == nil {
+		t.Fatal("unexpected success")
+	}
+	if _, err := NewReplaySource("", noBanner, false); err == nil {
+		t.Fatal("unexpected success")
+	}
+}
+
+func TestReplaySource_Sample(t *testing.T) {
+	src, err := NewReplaySource("sample", "../../cams-capture-879216526.tar", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, medias, err := readCaptureSDP(src.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vf, ok := DescribeVideo(medias); len(medias) != 2 || !ok || vf.Codec != "H264" {
+		t.Fatal("unexpected medias", medias)
+	}
+}
+
+func TestReplaySource_Once(t *testing.T) {
+	entries, packets := mjpegCapture(t)
+	src, err := NewReplaySource("test", writeCapture(t, entries), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	up := &recordingUpload{}
+	cam := NewSourceCamera(func(ctx context.Context) (UpstreamMedia, error) { return up, nil }, src)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err = cam.runStreamOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 500*time.Millisecond {
+		t.Fatal("replay ended early", elapsed)
+	}
+
+	if up.sdp != string(entries[0].payload) || up.rtp != packets {
+		t.Fatal("unexpected upload", up.rtp, packets)
+	}
+	if st := cam.Status(); st.Stats.RtcpPackets != 1 {
+		t.Fatal("unexpected stats", st.Stats)
+	}
+	if _, _, err = cam.Snapshot(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReplaySource_Loop(t *testing.T) {
+	entries, packets := mjpegCapture(t)
+	src, err := NewReplaySource("test", writeCapture(t, entries), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	up := &recordingUpload{}
+	cam := NewSourceCamera(func(ctx context.Context) (UpstreamMedia, error) { return up, nil }, src)
+
+	// The pass lasts 200ms and the loops follow each other: the third pass
+	// started with its first frame
+	ctx, cancel := context.WithTimeout(context.Background(), 450*time.Millisecond)
+	defer cancel()
+	if err = cam.runStreamOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if up.rtp <= 2*packets || up.rtp >= 3*packets {
+		t.Fatal("unexpected pacing", up.rtp, packets)
+	}
+
+	// The loops follow each other like a single stream
+	for i := 1; i < len(up.seqs); i++ {
+		if up.seqs[i] != up.seqs[i-1]+1 || int32(up.stamps[i]-up.stamps[i-1]) < 0 {
+			t.Fatal("discontinuity", i, up.seqs[i-1], up.seqs[i], up.stamps[i-1], up.stamps[i])
+		}
+	}
+}
+
+func TestReplaySource_Coarse(t *testing.T) {
+	// The 3 frames over 200ms fall in the same second of the archive
+	entries, packets := mjpegCapture(t)
+	src, err := NewReplaySource("test", writeCaptureFormat(t, entries, tar.FormatGNU), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if coarse, err := captureCoarse(src.path); err != nil || !coarse {
+		t.Fatal("capture not coarse", err)
+	}
+
+	up := &recordingUpload{}
+	cam := NewSourceCamera(func(ctx context.Context) (UpstreamMedia, error) { return up, nil }, src)
+
+	// Paced after the RTP timestamps, the last frame is still due
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	if err = cam.runStreamOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if up.rtp <= 0 || up.rtp >= packets {
+		t.Fatal("unexpected pacing", up.rtp, packets)
+	}
+}
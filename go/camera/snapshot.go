@@ -0,0 +1,119 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package camera
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/jfsmig/onvif/media"
+	"github.com/jfsmig/onvif/xsd/onvif"
+	"github.com/juju/errors"
+)
+
+const (
+	// Larger images are rejected rather than forwarded to the hub
+	snapshotMaxSize = 2 * 1024 * 1024
+
+	// How old a frame of the live stream may be to stand as a snapshot
+	snapshotMaxAge = 5 * time.Second
+)
+
+var ErrNoSnapshot = errors.New("no snapshot available")
+
+// Snapshot returns a JPEG image of the camera, taken by the camera itself when
+// it exposes a snapshot URI, or else the latest frame of its live stream when
+// that stream is M-JPEG.
+func (cam *Camera) Snapshot(ctx context.Context) ([]byte, time.Time, error) {
+	img, err := cam.snapshotOnvif(ctx)
+	if err == nil {
+		return img, time.Now(), nil
+	}
+	cam.debug().Err(err).Msg("onvif snapshot")
+
+	cam.frameLock.Lock()
+	defer cam.frameLock.Unlock()
+	if cam.lastFrame != nil && time.Since(cam.lastFrameAt) < snapshotMaxAge {
+		return cam.lastFrame, cam.lastFrameAt, nil
+	}
+	return nil, time.Time{}, ErrNoSnapshot
+}
+
+func (cam *Camera) snapshotOnvif(ctx context.Context) ([]byte, error) {
+	if cam.onvifCalls == nil || len(cam.onvifClient.GetEndpoint("media")) <= 0 {
+		return nil, ErrNoSnapshot
+	}
+
+	token := onvif.ReferenceToken(cam.profile.Token)
+	if len(token) <= 0 {
+		profiles, err := media.Call_GetProfiles(ctx, cam.onvifCalls, media.GetProfiles{})
+		if err != nil {
+			return nil, errors.Annotate(err, "get profiles")
+		}
+		if len(profiles.Profiles) <= 0 {
+			return nil, errors.Annotate(ErrNoSnapshot, "no profile")
+		}
+		token = profiles.Profiles[0].Token
+	}
+	reply, err := media.Call_GetSnapshotUri(ctx, cam.onvifCalls, media.GetSnapshotUri{
+		ProfileToken: token,
+	})
+	if err != nil {
+		return nil, errors.Annotate(err, "get snapshot uri")
+	}
+	if len(reply.MediaUri.Uri) <= 0 {
+		return nil, errors.Annotate(ErrNoSnapshot, "empty uri")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, string(reply.MediaUri.Uri), nil)
+	if err != nil {
+		return nil, errors.Annotate(err, "request")
+	}
+	if auth := cam.onvifCalls.GetAuth(); len(auth.Username) > 0 {
+		req.SetBasicAuth(auth.Username, auth.Password)
+	}
+	rep, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, errors.Annotate(err, "get snapshot")
+	}
+	defer rep.Body.Close()
+	if rep.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("get snapshot: status %d", rep.StatusCode)
+	}
+
+	img, err := io.ReadAll(io.LimitReader(rep.Body, snapshotMaxSize+1))
+	if err != nil {
+		return nil, errors.Annotate(err, "read snapshot")
+	}
+	if len(img) > snapshotMaxSize {
+		return nil, errors.New("snapshot too large")
+	}
+	if !bytes.HasPrefix(img, []byte{0xFF, 0xD8}) {
+		return nil, errors.New("snapshot is not a JPEG")
+	}
+	return img, nil
+}
+
+// keepFrame remembers the latest image of a M-JPEG live stream
+func (cam *Camera) keepFrame(img []byte) {
+	cam.frameLock.Lock()
+	defer cam.frameLock.Unlock()
+	cam.lastFrame = img
+	cam.lastFrameAt = time.Now()
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package camera
+
+import (
+	"context"
+	neturl "net/url"
+
+	"github.com/jfsmig/cams/go/rtsp1/pkg/media"
+	"github.com/jfsmig/cams/go/rtsp1/pkg/rtpmjpeg"
+	"github.com/jfsmig/cams/go/rtsp1/pkg/url"
+	onvifmedia "github.com/jfsmig/onvif/media"
+	"github.com/jfsmig/onvif/networking"
+	"github.com/jfsmig/onvif/sdk"
+	"github.com/jfsmig/onvif/xsd/onvif"
+	"github.com/juju/errors"
+	"github.com/pion/rtp"
+)
+
+// Source tells a camera where its media stream is
+type Source interface {
+	// ID identifies the camera, it must be stable across the restarts
+	ID() string
+
+	// StreamURL returns the RTSP URL of the stream, with its credentials
+	StreamURL(ctx context.Context) (*url.URL, error)
+}
+
+// Generator is a Source that produces its RTP packets itself, without any
+// RTSP server. Its StreamURL is not called.
+type Generator interface {
+	Source
+
+	// Describe tells the medias of the stream, and more about its video
+	Describe() (media.Medias, VideoFormat)
+
+	// Generate produces the packets of the stream until the context ends or
+	// emit fails
+	Generate(ctx context.Context, emit func(pkt *rtp.Packet) error) error
+}
+
+// runGenerator uploads the packets of a generated stream, just like those
+// received from a camera.
+func (cam *Camera) runGenerator(ctx context.Context, gen Generator) error {
+	medias, vf := gen.Describe()
+	cam.setStreamFormat(vf)
+
+	sdp, err := medias.Marshal(false).Marshal()
+	if err != nil {
+		return errors.Annotate(err, "sdp")
+	}
+
+	upload, err := cam.open(ctx)
+	if err != nil {
+		return errors.Annotate(err, "dial")
+	}
+	defer upload.Close()
+
+	if err = upload.OnSDP(string(sdp)); err != nil {
+		return errors.Annotate(err, "send sdp banner")
+	}
+
+	indexer := newMediaIndexer(medias)
+	var mjpeg rtpmjpeg.Decoder
+	mjpeg.Init()
+
+	return gen.Generate(ctx, func(pkt *rtp.Packet) error {
+		raw, err := pkt.Marshal()
+		if err != nil {
+			return errors.Annotate(err, "rtp")
+		}
+		return cam.onRTP(upload, indexer, &mjpeg, pkt, raw)
+	})
+}
+
+// onvifSource asks the ONVIF device for the URL of a profile, or of its
+// default stream when the profile is unknown
+type onvifSource struct {
+	appliance sdk.Appliance
+	client    *networking.Client
+	profile   StreamProfile
+}
+
+func (s onvifSource) ID() string {
+	return ProfileStreamID(s.appliance.GetUUID(), s.profile)
+}
+
+func (s onvifSource) StreamURL(ctx context.Context) (*url.URL, error) {
+	if s.client == nil || len(s.profile.Token) <= 0 {
+		sourceUrl, err := url.Parse(s.appliance.FetchStreamURI(ctx))
+		if err != nil {
+			return nil, errors.Annotate(err, "parse")
+		}
+		return sourceUrl, nil
+	}
+
+	reply, err := onvifmedia.Call_GetStreamUri(ctx, s.client, onvifmedia.GetStreamUri{
+		StreamSetup: onvif.StreamSetup{
+			Stream:    "RTP-Unicast",
+			Transport: onvif.Transport{Protocol: "RTSP"},
+		},
+		ProfileToken: onvif.ReferenceToken(s.profile.Token),
+	})
+	if err != nil {
+		return nil, errors.Annotate(err, "get stream uri")
+	}
+	sourceUrl, err := url.Parse(string(reply.MediaUri.Uri))
+	if err != nil {
+		return nil, errors.Annotate(err, "parse")
+	}
+	if auth := s.client.GetAuth(); len(auth.Username) > 0 {
+		sourceUrl.User = neturl.UserPassword(auth.Username, auth.Password)
+	}
+	return sourceUrl, nil
+}
+
+// RTSPSource is a camera without ONVIF, streaming at a fixed RTSP URL
+type RTSPSource struct {
+	id  string
+	url *url.URL
+}
+
+// NewRTSPSource validates the URL of the stream. The credentials, when given,
+// replace those of the URL.
+func NewRTSPSource(id, rawURL, user, password string) (*RTSPSource, error) {
+	if len(id) <= 0 {
+		return nil, errors.New("missing ID")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.Annotate(err, "parse")
+	}
+	if len(user) > 0 {
+		u = u.Clone()
+		u.User = neturl.UserPassword(user, password)
+	}
+	return &RTSPSource{id: id, url: u}, nil
+}
+
+func (s *RTSPSource) ID() string { return s.id }
+
+func (s *RTSPSource) StreamURL(ctx context.Context) (*url.URL, error) {
+	return s.url.Clone(), nil
+}
+
+// String describes the source without its credentials
+func (s *RTSPSource) String() string { return s.url.CloneWithoutCredentials().String() }
@@ -0,0 +1,147 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package camera
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats are the counters of a camera, accumulated over all its stream sessions
+type Stats struct {
+	RtpPackets  uint64
+	RtpBytes    uint64
+	RtcpPackets uint64
+	Restarts    uint64
+	LastPacket  time.Time
+}
+
+// Status is a snapshot of the state of a camera
+type Status struct {
+	ID        string
+	State     CamAgentState
+	LastError string
+	Stats     Stats
+}
+
+// StatusObserver is notified on each state transition of a camera and on each stream error.
+// It is called from the goroutines of the camera and must not block.
+type StatusObserver func(status Status)
+
+type streamStats struct {
+	rtpPackets  atomic.Uint64
+	rtpBytes    atomic.Uint64
+	rtcpPackets atomic.Uint64
+	restarts    atomic.Uint64
+	lastPacket  atomic.Int64
+
+	// Set while lastError is, to clear it as soon as the stream flows again
+	failed atomic.Bool
+}
+
+func (s CamAgentState) String() string {
+	switch s {
+	case CamAgentOff:
+		return "off"
+	case CamAgentIdle:
+		return "idle"
+	case CamAgentPlaying:
+		return "playing"
+	case CamAgentPausing:
+		return "pausing"
+	case CamAgentResuming:
+		return "resuming"
+	default:
+		return "unknown"
+	}
+}
+
+// SetObserver registers the callback notified of the changes of the camera status.
+// It must be called before Run.
+func (cam *Camera) SetObserver(observer StatusObserver) { cam.observer = observer }
+
+// Status returns a snapshot of the state of the camera
+func (cam *Camera) Status() Status {
+	cam.statusLock.Lock()
+	defer cam.statusLock.Unlock()
+	return cam.statusLocked()
+}
+
+func (cam *Camera) statusLocked() Status {
+	out := Status{
+		ID:        cam.ID,
+		State:     cam.State,
+		LastError: cam.lastError,
+		Stats: Stats{
+			RtpPackets:  cam.stats.rtpPackets.Load(),
+			RtpBytes:    cam.stats.rtpBytes.Load(),
+			RtcpPackets: cam.stats.rtcpPackets.Load(),
+			Restarts:    cam.stats.restarts.Load(),
+		},
+	}
+	if last := cam.stats.lastPacket.Load(); last > 0 {
+		out.Stats.LastPacket = time.UnixMilli(last)
+	}
+	return out
+}
+
+func (cam *Camera) setState(state CamAgentState) {
+	cam.statusLock.Lock()
+	changed := cam.State != state
+	cam.State = state
+	status := cam.statusLocked()
+	cam.statusLock.Unlock()
+
+	if changed {
+		cam.notify(status)
+	}
+}
+
+func (cam *Camera) setLastError(err error) {
+	cam.statusLock.Lock()
+	if err != nil {
+		cam.lastError = err.Error()
+	} else {
+		cam.lastError = ""
+	}
+	cam.stats.failed.Store(err != nil)
+	status := cam.statusLocked()
+	cam.statusLock.Unlock()
+
+	cam.notify(status)
+}
+
+func (cam *Camera) notify(status Status) {
+	if cam.observer != nil {
+		cam.observer(status)
+	}
+}
+
+// countRTP accounts a media packet. The first one after a failure tells the
+// stream is back, the error is then cleared.
+func (cam *Camera) countRTP(size int) {
+	cam.stats.rtpPackets.Add(1)
+	cam.stats.rtpBytes.Add(uint64(size))
+	cam.stats.lastPacket.Store(time.Now().UnixMilli())
+	if cam.stats.failed.Load() {
+		cam.setLastError(nil)
+	}
+}
+
+func (cam *Camera) countRTCP() {
+	cam.stats.rtcpPackets.Add(1)
+	cam.stats.lastPacket.Store(time.Now().UnixMilli())
+}
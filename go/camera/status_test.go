@@ -0,0 +1,60 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package camera
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStatus_Transitions(t *testing.T) {
+	src, err := NewPatternSource("test", 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cam := NewSourceCamera(nil, src)
+	seen := make([]Status, 0)
+	cam.SetObserver(func(status Status) { seen = append(seen, status) })
+
+	// Only the changes of state are notified
+	cam.setState(CamAgentIdle)
+	cam.setState(CamAgentIdle)
+	cam.setState(CamAgentPlaying)
+	if len(seen) != 2 || seen[0].State != CamAgentIdle || seen[1].State != CamAgentPlaying {
+		t.Fatal("unexpected notifications", seen)
+	}
+
+	// Each error is notified, even in the same state
+	cam.setLastError(errors.New("describe: timeout"))
+	cam.setLastError(errors.New("start: refused"))
+	if len(seen) != 4 || seen[3].LastError != "start: refused" || seen[3].State != CamAgentPlaying {
+		t.Fatal("unexpected notifications", seen)
+	}
+	if st := cam.Status(); st.ID != "test" || st.LastError != "start: refused" {
+		t.Fatal("unexpected status", st)
+	}
+
+	// The error is cleared once the stream flows again, and only then
+	cam.countRTP(100)
+	cam.countRTP(100)
+	if len(seen) != 5 || len(seen[4].LastError) != 0 || seen[4].Stats.RtpPackets != 1 {
+		t.Fatal("unexpected notifications", seen)
+	}
+	st := cam.Status()
+	if len(st.LastError) != 0 || st.Stats.RtpPackets != 2 || st.Stats.RtpBytes != 200 || st.Stats.LastPacket.IsZero() {
+		t.Fatal("unexpected status", st)
+	}
+}
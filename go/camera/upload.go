@@ -17,13 +17,27 @@ package camera
 
 import (
 	"context"
+	"time"
 )
 
+// PacketInfo describes the reception of a packet from the camera
+type PacketInfo struct {
+	// Position of the media in the SDP banner
+	MediaIndex uint32
+
+	// When the agent received the packet
+	ReceivedAt time.Time
+
+	// Whether the RTP packet starts a keyframe, only detected for known codecs
+	Keyframe bool
+}
+
+// UpstreamMedia receives the frames of one streaming session of a camera.
 type UpstreamMedia interface {
 	Close()
 	OnSDP(sdp string) error
-	OnRTP(pkt []byte) error
-	OnRTCP(pkt []byte) error
+	OnRTP(info PacketInfo, pkt []byte) error
+	OnRTCP(info PacketInfo, pkt []byte) error
 }
 
 type UploadOpenFunc func(ctx context.Context) (UpstreamMedia, error)
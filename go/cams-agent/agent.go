@@ -19,6 +19,7 @@ import (
 	"context"
 	"net/http"
 	"regexp"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -26,11 +27,15 @@ import (
 	"github.com/jfsmig/cams/go/camera"
 	"github.com/jfsmig/cams/go/utils"
 	"github.com/jfsmig/go-bags"
+	"github.com/jfsmig/onvif/device"
 	"github.com/jfsmig/onvif/networking"
 	"github.com/jfsmig/onvif/sdk"
 	"github.com/juju/errors"
 )
 
+// How long a camera waits before subscribing again to its events, after a failure
+const eventsRetryPeriod = 5 * time.Second
+
 type Agent struct {
 	Config AgentConfig
 
@@ -48,13 +53,49 @@ type Agent struct {
 	devices    bags.SortedObj[string, *camera.Camera]
 	interfaces bags.SortedObj[string, *Nic]
 
+	// Whether each stream is expected to play, as requested by the hub.
+	// It outlives the cameras so that a camera discovered late catches up.
+	desired map[string]bool
+
 	// Fields extracted from the configuration
 	devicesStatic              []CameraConfig
+	streamsStatic              []StreamConfig
+	patternsStatic             []PatternConfig
+	replaysStatic              []ReplayConfig
 	interfacesStatic           []string
 	interfacesDiscoverPatterns []string
 
 	nicsGroup utils.Swarm
+	nicsSwarm utils.Swarm
 	camsSwarm utils.Swarm
+
+	// The single media upload shared by all the cameras
+	uploads *uploadMux
+
+	// The spool of each camera, kept when a camera is forgotten so that a camera
+	// discovered again finds its spool and its forwarder.
+	spools map[string]*cameraSpool
+
+	// The rolling buffer of each camera, in trigger mode
+	triggers map[string]*cameraTrigger
+
+	// Closed to stop the event subscription of each camera, when it is forgotten
+	eventsStop map[string]chan struct{}
+
+	// Status changes of the cameras, to be reported upstream
+	statuses chan camera.Status
+
+	// Events of the cameras, to be reported upstream
+	events chan camera.Event
+
+	// Signals the changes of the set of cameras, to be registered upstream
+	devicesChanged chan struct{}
+
+	// The IDs reported by the static cameras, by address
+	staticIDs map[string]string
+
+	// Wakes the connection to the static cameras up, with the generation of the scan
+	staticTrigger chan uint32
 }
 
 func NewLanAgent(cfg AgentConfig) *Agent {
@@ -64,49 +105,108 @@ func NewLanAgent(cfg AgentConfig) *Agent {
 
 		devices:    make([]*camera.Camera, 0),
 		interfaces: make([]*Nic, 0),
+		desired:    make(map[string]bool),
+		spools:     make(map[string]*cameraSpool),
+		triggers:   make(map[string]*cameraTrigger),
+		eventsStop: make(map[string]chan struct{}),
 
 		interfacesDiscoverPatterns: []string{},
 		interfacesStatic:           []string{},
 		devicesStatic:              []CameraConfig{},
-	}
 
-	for _, itf := range cfg.Interfaces {
-		lan.registerInterface(itf)
+		statuses: make(chan camera.Status, 64),
+		events:   make(chan camera.Event, 64),
+
+		devicesChanged: make(chan struct{}, 1),
+		staticIDs:      make(map[string]string),
+		staticTrigger:  make(chan uint32, 1),
 	}
 
 	lan.interfacesDiscoverPatterns = cfg.DiscoverPatterns
 	lan.interfacesStatic = cfg.Interfaces
 	lan.devicesStatic = cfg.Cameras
+	lan.streamsStatic = cfg.Streams
+	lan.patternsStatic = cfg.Patterns
+	lan.replaysStatic = cfg.Replays
 
 	return lan
 }
 
 // UpdateStreamExpectation implements a
 func (lan *Agent) UpdateStreamExpectation(camId string, cmd camera.CamCommand) error {
-	// Locate the camera
+	if cmd != camera.CamCommandPlay && cmd != camera.CamCommandPause {
+		return errors.New("BUG: unexpected command")
+	}
+
+	// Locate the camera and remember the expectation, even for an unknown camera
 	cam := func(camId string) *camera.Camera {
 		lan.dataLock.Lock()
 		defer lan.dataLock.Unlock()
+		lan.desired[camId] = cmd == camera.CamCommandPlay
 		cam, _ := lan.devices.Get(camId)
 		return cam
 	}(camId)
 
 	if cam == nil {
-		return errors.New("no such camera")
+		return ErrNoSuchCamera
+	}
+	lan.applyExpectation(cam, cmd == camera.CamCommandPlay)
+	return nil
+}
+
+// Camera locates a known camera
+func (lan *Agent) Camera(camId string) (*camera.Camera, error) {
+	lan.dataLock.Lock()
+	defer lan.dataLock.Unlock()
+	if cam, ok := lan.devices.Get(camId); ok {
+		return cam, nil
 	}
+	return nil, ErrNoSuchCamera
+}
 
-	switch cmd {
-	case camera.CamCommandPlay:
-		if cam.State == camera.CamAgentOff {
-			lan.camsSwarm.Run(runCam(cam))
+// Reconcile aligns all the cameras on the complete set of streams expected to play.
+// The streams not listed are stopped.
+func (lan *Agent) Reconcile(playing []string) {
+	cams := func() []*camera.Camera {
+		lan.dataLock.Lock()
+		defer lan.dataLock.Unlock()
+		lan.desired = make(map[string]bool)
+		for _, id := range playing {
+			lan.desired[id] = true
 		}
-		cam.PlayStream()
-		return nil
-	case camera.CamCommandPause:
-		cam.StopStream()
-		return nil
-	default:
-		return errors.New("BUG: unexpected command")
+		return append([]*camera.Camera{}, lan.devices...)
+	}()
+
+	for _, cam := range cams {
+		lan.applyExpectation(cam, lan.isDesired(cam.PK()))
+	}
+}
+
+func (lan *Agent) isDesired(camId string) bool {
+	lan.dataLock.Lock()
+	defer lan.dataLock.Unlock()
+	return lan.desired[camId]
+}
+
+func (lan *Agent) applyExpectation(cam *camera.Camera, play bool) {
+	// In trigger mode, the cameras always stream into their rolling buffer and
+	// the expectation of the hub only pins them live.
+	if ct := lan.trigger(cam.PK()); ct != nil {
+		ct.Pin(play)
+		play = true
+	}
+
+	// The agent of a camera is started as soon as the camera is known, and it
+	// is never restarted once it has exited: the camera is then forgotten.
+	var err error
+	if play {
+		err = cam.PlayStream()
+	} else {
+		err = cam.StopStream()
+	}
+	if err != nil {
+		utils.Logger.Warn().Str("cam", cam.PK()).Bool("play", play).Str("state", cam.Status().State.String()).
+			Err(err).Str("action", "expect").Msg("lan")
 	}
 }
 
@@ -118,31 +218,38 @@ func (lan *Agent) Run(ctx context.Context) {
 
 	utils.Logger.Info().Str("action", "start").Msg("lan")
 
+	cfg := lan.config()
+	uploads := newUploadMux(ctx, cfg.User, cfg.AgentID, cfg.UpstreamMedia.Address)
+
 	// Cameras may come ang go, so a simple goroutine swarm if enough.
 	lan.camsSwarm = utils.NewSwarm(ctx)
 	defer lan.camsSwarm.Cancel()
 
-	// ... This is not the case for network interfaces that are rather stable.
+	// ... This is not the case for the main loops of the agent.
 	lan.nicsGroup = utils.NewGroup(ctx)
 	defer lan.nicsGroup.Cancel()
 
+	// The network interfaces only change with the configuration
+	nicsSwarm := utils.NewSwarm(ctx)
+	defer func() {
+		nicsSwarm.Cancel()
+		nicsSwarm.Wait()
+	}()
+
+	// A reload may happen as soon as the agent starts
+	lan.dataLock.Lock()
+	lan.uploads = uploads
+	lan.nicsSwarm = nicsSwarm
+	lan.dataLock.Unlock()
+
 	// Perform a first discovery of the local interfaces.
 	// No need to do it periodically, interfaces are unlikely plug & play
-	if err := lan.discoverNics(); err != nil {
+	if err := lan.applyNics(); err != nil {
 		utils.Logger.Error().Err(err).Msg("disc")
 		return
 	}
 
-	// Spawn one goroutine per registered interface, for concurrent discoveries
-	fn := func(ctx0 context.Context, gen uint32, devs []networking.ClientInfo) {
-		lan.learnAllCamerasSync(ctx0, gen, devs)
-	}
-	for _, itf := range lan.interfaces {
-		func(itf *Nic) {
-			lan.nicsGroup.Run(func(c context.Context) { itf.RunRescanLoop(c, fn) })
-		}(itf)
-	}
-
+	lan.nicsGroup.Run(func(c context.Context) { lan.runStaticCameras(c) })
 	lan.nicsGroup.Run(func(c context.Context) { lan.runTimers(c) })
 
 	utils.Logger.Info().Str("action", "wait nics").Msg("lan")
@@ -168,6 +275,116 @@ func (lan *Agent) Cameras() []*camera.Camera {
 	return out
 }
 
+// Interfaces returns the names of the interfaces scanned
+func (lan *Agent) Interfaces() []string {
+	lan.dataLock.Lock()
+	defer lan.dataLock.Unlock()
+	out := make([]string, 0, len(lan.interfaces))
+	for _, itf := range lan.interfaces {
+		out = append(out, itf.PK())
+	}
+	return out
+}
+
+// Statuses exposes the status changes of the cameras
+func (lan *Agent) Statuses() <-chan camera.Status { return lan.statuses }
+
+// onCameraStatus queues a status change without blocking the camera. Dropped changes
+// are not lost for long because the whole status is periodically reported.
+func (lan *Agent) onCameraStatus(status camera.Status) {
+	select {
+	case lan.statuses <- status:
+	default:
+		utils.Logger.Debug().Str("cam", status.ID).Str("action", "drop").Msg("status")
+	}
+}
+
+// DevicesChanged signals that cameras appeared or disappeared. Several changes
+// may be signaled once, the receiver is expected to compare the Cameras.
+func (lan *Agent) DevicesChanged() <-chan struct{} { return lan.devicesChanged }
+
+func (lan *Agent) notifyDevicesChanged() {
+	select {
+	case lan.devicesChanged <- struct{}{}:
+	default:
+	}
+}
+
+// Events exposes the events notified by the cameras
+func (lan *Agent) Events() <-chan camera.Event { return lan.events }
+
+// onCameraEvent queues an event without blocking the subscription of the camera,
+// and applies the trigger rules to it
+func (lan *Agent) onCameraEvent(event camera.Event) {
+	event.CamID = lan.eventStream(event)
+	select {
+	case lan.events <- event:
+	default:
+		utils.Logger.Warn().Str("cam", event.CamID).Str("kind", event.Kind.String()).Str("action", "drop").Msg("event")
+	}
+
+	cfg := lan.config().Trigger
+	if !cfg.Enabled {
+		return
+	}
+	for _, rule := range cfg.Rules {
+		if rule.Fires(event) {
+			if err := lan.Trigger(event.CamID, rule.GetDuration()); err != nil {
+				utils.Logger.Warn().Str("cam", event.CamID).Str("kind", event.Kind.String()).Err(err).Msg("trigger")
+			}
+			return
+		}
+	}
+}
+
+// eventStream tells which stream reports the event. A device subscribes once
+// to its events, on its first stream, and the events of a device with several
+// channels are reported on the main stream of their channel.
+func (lan *Agent) eventStream(event camera.Event) string {
+	lan.dataLock.Lock()
+	defer lan.dataLock.Unlock()
+
+	dev, ok := lan.devices.Get(event.CamID)
+	if !ok {
+		return event.CamID
+	}
+	for _, stream := range lan.streamsOf(dev.Device) {
+		if stream.ReportsEvent(event) {
+			return stream.PK()
+		}
+	}
+	return event.CamID
+}
+
+// runEvents keeps the camera subscribed to its ONVIF events, until the camera is forgotten
+func (lan *Agent) runEvents(camID, endpoint string, auth networking.ClientAuth, stop <-chan struct{}) utils.SwarmFunc {
+	return func(ctx context.Context) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go func() {
+			select {
+			case <-stop:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		listener := camera.NewEventListener(camID, endpoint, auth, &HttpClient)
+		for {
+			err := listener.Run(ctx, lan.onCameraEvent)
+			if ctx.Err() != nil {
+				return
+			}
+			utils.Logger.Warn().Str("cam", camID).Err(err).Msg("events")
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(eventsRetryPeriod):
+			}
+		}
+	}
+}
+
 // runTimers runs the main loop of the agent to trigger periodical actions
 func (lan *Agent) runTimers(ctx context.Context) {
 	nextScan := time.After(0)
@@ -179,42 +396,92 @@ func (lan *Agent) runTimers(ctx context.Context) {
 			return
 		case <-nextScan:
 			lan.triggerRescanAsync(ctx)
-			nextScan = time.After(lan.Config.GetScanPeriod())
+			cfg := lan.config()
+			nextScan = time.After(cfg.GetScanPeriod())
 		case <-nextCheck:
 			HttpClient.CloseIdleConnections()
+			lan.dataLock.Lock()
 			utils.Logger.Info().
 				Str("action", "check").
 				Int("devices", len(lan.devices)).
 				Int("interfaces", len(lan.interfaces)).
 				Msg("lan")
-			nextCheck = time.After(lan.Config.GetCheckPeriod())
+			lan.dataLock.Unlock()
+			cfg := lan.config()
+			nextCheck = time.After(cfg.GetCheckPeriod())
 		}
 	}
 }
 
-// discoverNics does the discovery from the output of a given function.
-// It helps to test the logic.
-func (lan *Agent) discoverNics() error {
+// wantedNics tells the interfaces to scan: the interfaces of the system that
+// match the discovery patterns, plus the interfaces forced by the configuration.
+func (lan *Agent) wantedNics() (map[string]bool, error) {
 	itfs, err := utils.DiscoverSystemNics()
 	if err != nil {
-		return errors.Trace(err)
+		return nil, errors.Trace(err)
 	}
 
 	utils.Logger.Trace().Strs("interfaces", itfs).Msg("disc")
 
+	lan.dataLock.Lock()
+	defer lan.dataLock.Unlock()
+
+	out := make(map[string]bool)
 	for _, itf := range itfs {
-		lan.maybeRegisterInterface(itf)
+		if matchInterface(lan.interfacesDiscoverPatterns, itf) {
+			out[itf] = true
+		}
 	}
-
 	for _, itf := range lan.interfacesStatic {
 		utils.Logger.Info().Str("itf", itf).Str("action", "force").Msg("disc")
-		lan.registerInterface(itf)
+		out[itf] = true
+	}
+	return out, nil
+}
+
+// applyNics aligns the rescan loops on the interfaces to scan, one goroutine per
+// interface for concurrent discoveries. The loops of the interfaces still
+// scanned are not disturbed.
+func (lan *Agent) applyNics() error {
+	wanted, err := lan.wantedNics()
+	if err != nil {
+		return err
+	}
+
+	fn := func(ctx0 context.Context, gen uint32, devs []networking.ClientInfo) {
+		lan.learnAllCamerasSync(ctx0, gen, devs)
+	}
+
+	lan.dataLock.Lock()
+	defer lan.dataLock.Unlock()
+
+	if lan.nicsSwarm == nil {
+		return nil // Not running yet, the start will apply the interfaces
+	}
+	for i := len(lan.interfaces); i > 0; i-- {
+		itf := lan.interfaces[i-1]
+		if !wanted[itf.PK()] {
+			utils.Logger.Info().Str("itf", itf.PK()).Str("action", "stop").Msg("disc")
+			lan.interfaces.Remove(itf.PK())
+			itf.Stop()
+		}
+	}
+	for name := range wanted {
+		if lan.interfaces.Has(name) {
+			continue
+		}
+		utils.Logger.Info().Str("itf", name).Str("action", "add").Msg("disc")
+		itf := NewNIC(name)
+		lan.interfaces.Add(itf)
+		lan.nicsSwarm.Run(func(c context.Context) { itf.RunRescanLoop(c, fn) })
 	}
 	return nil
 }
 
-func (lan *Agent) maybeRegisterInterface(itf string) {
-	for _, pattern0 := range lan.interfacesDiscoverPatterns {
+// matchInterface tells if the interface is to be scanned, after the first
+// pattern that matches its name. A pattern starting with '!' excludes.
+func matchInterface(patterns []string, itf string) bool {
+	for _, pattern0 := range patterns {
 		if len(pattern0) < 2 {
 			continue
 		}
@@ -229,52 +496,248 @@ func (lan *Agent) maybeRegisterInterface(itf string) {
 			continue
 		} else if !not {
 			utils.Logger.Info().Str("pattern", pattern0).Str("itf", itf).Str("action", "add").Msg("disc")
-			lan.registerInterface(itf)
+			return true
 		} else {
 			utils.Logger.Debug().Str("pattern", pattern0).Str("itf", itf).Str("action", "skip").Msg("disc")
+			return false
 		}
-		return
 	}
+	return false
 }
 
-func (lan *Agent) registerInterface(itf string) {
-	lan.interfaces.Add(NewNIC(itf))
+// Trigger uploads the rolling buffer of the camera, then its live media for
+// the given duration (or the configured default if zero).
+func (lan *Agent) Trigger(camId string, d time.Duration) error {
+	if !lan.config().Trigger.Enabled {
+		return errors.New("trigger mode disabled")
+	}
+	ct := lan.trigger(camId)
+	if ct == nil {
+		return ErrNoSuchCamera
+	}
+	utils.Logger.Info().Str("cam", camId).Dur("duration", d).Str("action", "fire").Msg("trigger")
+	ct.Fire(d)
+	return nil
+}
+
+func (lan *Agent) trigger(camId string) *cameraTrigger {
+	lan.dataLock.Lock()
+	defer lan.dataLock.Unlock()
+	return lan.triggers[camId]
+}
+
+// uploadOpener returns how the camera uploads its media: through its rolling
+// buffer in trigger mode, then through its spool if enabled, or straight to the hub.
+func (lan *Agent) uploadOpener(camID string) camera.UploadOpenFunc {
+	lan.dataLock.Lock()
+	defer lan.dataLock.Unlock()
+
+	open := lan.uploads.Opener(camID)
+
+	if len(lan.Config.Spool.Dir) > 0 {
+		cs, ok := lan.spools[camID]
+		if !ok {
+			var err error
+			if cs, err = newCameraSpool(lan.Config.Spool, lan.uploads, camID); err != nil {
+				utils.Logger.Warn().Str("cam", camID).Err(err).Msg("spool")
+			} else {
+				lan.spools[camID] = cs
+				lan.camsSwarm.Run(cs.Run)
+			}
+		}
+		if cs != nil {
+			open = cs.Opener()
+		}
+	}
+
+	if lan.Config.Trigger.Enabled {
+		ct, ok := lan.triggers[camID]
+		if !ok {
+			ct = newCameraTrigger(lan.Config.Trigger, open, camID)
+			lan.triggers[camID] = ct
+		}
+		ct.Pin(lan.desired[camID])
+		open = ct.Opener()
+	}
+	return open
 }
 
 func runCam(cam *camera.Camera) utils.SwarmFunc {
 	return func(ctx context.Context) { cam.Run(ctx) }
 }
 
+// refreshGeneration tells if the device is already known, and then marks the
+// streams of all its profiles as seen in the given generation.
+func (lan *Agent) refreshGeneration(deviceID string, generation uint32) bool {
+	lan.dataLock.Lock()
+	defer lan.dataLock.Unlock()
+	streams := lan.streamsOf(deviceID)
+	for _, dev := range streams {
+		if generation > dev.GetGeneration() {
+			dev.SetGeneration(generation)
+		}
+	}
+	return len(streams) > 0
+}
+
+// streamsOf returns the cameras managing the streams of the device. The caller
+// holds the dataLock.
+func (lan *Agent) streamsOf(deviceID string) []*camera.Camera {
+	var out []*camera.Camera
+	for _, dev := range lan.devices {
+		if dev.Device == deviceID {
+			out = append(out, dev)
+		}
+	}
+	return out
+}
+
+// connectCamera prepares the ONVIF client of a camera, and keeps the client
+// beneath the appliance for the calls the appliance doesn't wrap.
+func connectCamera(ctx context.Context, info networking.ClientInfo, auth networking.ClientAuth) (*networking.Client, sdk.Appliance, error) {
+	client, err := networking.NewClient(info, &HttpClient)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	appliance, err := sdk.WrapClient(ctx, client, auth)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	return client, appliance, nil
+}
+
 func (lan *Agent) learnSingleCameraSync(ctx context.Context, generation uint32, discovered networking.ClientInfo) error {
 	// Preliminary check of the existence of the camera, before starting expensive queries
-	lan.dataLock.Lock()
-	devInPlace, already := lan.devices.Get(discovered.Uuid)
-	if already && generation > devInPlace.GetGeneration() {
-		devInPlace.SetGeneration(generation)
+	if lan.refreshGeneration(discovered.Uuid, generation) {
+		return nil
 	}
-	lan.dataLock.Unlock()
-	if already {
+
+	cfg := lan.config()
+	auth, ok := cfg.CredentialsFor(discovered.Xaddr, lookupMAC)
+	if !ok {
+		utils.Logger.Debug().Str("endpoint", discovered.Xaddr).Msg("no credentials matched")
+	}
+	client, appliance, err := connectCamera(ctx, discovered, auth)
+	if err != nil {
+		return err
+	}
+	return lan.adoptCamera(ctx, generation, discovered.Xaddr, client, appliance, auth)
+}
+
+// learnStaticCameraSync connects to a camera of the configuration. Its ID is
+// queried to the camera unless configured, then remembered.
+func (lan *Agent) learnStaticCameraSync(ctx context.Context, generation uint32, cc CameraConfig) error {
+	camID := cc.ID
+	if len(camID) <= 0 {
+		lan.dataLock.Lock()
+		camID = lan.staticIDs[cc.Address]
+		lan.dataLock.Unlock()
+	}
+	if len(camID) > 0 && lan.refreshGeneration(camID, generation) {
 		return nil
 	}
 
-	appliance, err := sdk.NewDevice(ctx, discovered, networking.ClientAuth{
-		Username: User,
-		Password: Password,
-	}, &HttpClient)
+	auth := networking.ClientAuth{Username: cc.User, Password: cc.Password}
+	client, appliance, err := connectCamera(ctx, networking.ClientInfo{Xaddr: cc.Address, Uuid: camID}, auth)
 	if err != nil {
-		return errors.Trace(err)
+		return err
 	}
+	if len(camID) <= 0 {
+		ref, err := device.Call_GetEndpointReference(ctx, client, device.GetEndpointReference{})
+		if err != nil {
+			return errors.Annotate(err, "get endpoint reference")
+		}
+		if camID = strings.TrimPrefix(ref.GUID, "urn:uuid:"); len(camID) <= 0 {
+			return errors.New("no endpoint reference, configure the camera ID")
+		}
+		client.SetUUID(camID)
+		lan.dataLock.Lock()
+		lan.staticIDs[cc.Address] = camID
+		lan.dataLock.Unlock()
 
-	// Here come the http requests
-	uploadOpener := NewGrpcUploadMaker(lan.Config.User, appliance.GetUUID(), lan.Config.UpstreamMedia.Address)
-	dev := camera.NewCamera(uploadOpener, appliance)
+		// The camera may also have been discovered meanwhile
+		if lan.refreshGeneration(camID, generation) {
+			return nil
+		}
+	}
+	return lan.adoptCamera(ctx, generation, cc.Address, client, appliance, auth)
+}
+
+// adoptCamera starts managing a camera freshly connected, with one stream per
+// media profile. The profiles of a NVR or an encoder are grouped in channels,
+// one per video source, that live independently. The events of the device are
+// subscribed on its first stream.
+func (lan *Agent) adoptCamera(ctx context.Context, generation uint32, endpoint string, client *networking.Client, appliance sdk.Appliance, auth networking.ClientAuth) error {
+	profiles, err := camera.ListStreamProfiles(ctx, client)
+	if err != nil {
+		return err
+	}
+	for i, dev := range camera.NewProfileCameras(lan.uploadOpener, appliance, client, profiles) {
+		eventsEndpoint := ""
+		if i == 0 {
+			eventsEndpoint = appliance.GetEndpoint("events")
+		}
+		lan.startCamera(generation, endpoint, dev, eventsEndpoint, auth)
+	}
+	return nil
+}
+
+// learnStreamSync starts managing a camera without ONVIF, at the first
+// generation. It is then seen in each generation.
+func (lan *Agent) learnStreamSync(generation uint32, sc StreamConfig) error {
+	if lan.refreshGeneration(sc.ID, generation) {
+		return nil
+	}
+	source, err := camera.NewRTSPSource(sc.ID, sc.URL, sc.User, sc.Password)
+	if err != nil {
+		return err
+	}
+	dev := camera.NewSourceCamera(lan.uploadOpener(sc.ID), source)
+	lan.startCamera(generation, source.String(), dev, "", networking.ClientAuth{})
+	return nil
+}
+
+// learnPatternSync starts managing a synthetic camera, at the first
+// generation. It is then seen in each generation.
+func (lan *Agent) learnPatternSync(generation uint32, pc PatternConfig) error {
+	if lan.refreshGeneration(pc.ID, generation) {
+		return nil
+	}
+	source, err := camera.NewPatternSource(pc.ID, pc.Width, pc.Height, pc.FPS)
+	if err != nil {
+		return err
+	}
+	dev := camera.NewSourceCamera(lan.uploadOpener(pc.ID), source)
+	lan.startCamera(generation, "pattern", dev, "", networking.ClientAuth{})
+	return nil
+}
+
+// learnReplaySync starts managing a camera replaying a capture, at the first
+// generation. It is then seen in each generation.
+func (lan *Agent) learnReplaySync(generation uint32, rc ReplayConfig) error {
+	if lan.refreshGeneration(rc.ID, generation) {
+		return nil
+	}
+	source, err := camera.NewReplaySource(rc.ID, rc.Path, rc.Loop)
+	if err != nil {
+		return err
+	}
+	dev := camera.NewSourceCamera(lan.uploadOpener(rc.ID), source)
+	lan.startCamera(generation, source.String(), dev, "", networking.ClientAuth{})
+	return nil
+}
+
+// startCamera starts managing a camera, unless it is already known. Its
+// events are subscribed when it has an events endpoint.
+func (lan *Agent) startCamera(generation uint32, endpoint string, dev *camera.Camera, eventsEndpoint string, auth networking.ClientAuth) {
+	dev.SetObserver(lan.onCameraStatus)
 
 	lan.dataLock.Lock()
 	defer lan.dataLock.Unlock()
 
 	// If the camera is already know, it's very unlikely, but it may happen in case of a stale discovery,
 	// let's just update its generation counter
-	devInPlace, already = lan.devices.Get(dev.PK())
+	devInPlace, already := lan.devices.Get(dev.PK())
 	if already {
 		if generation > devInPlace.GetGeneration() {
 			devInPlace.SetGeneration(generation)
@@ -284,14 +747,62 @@ func (lan *Agent) learnSingleCameraSync(ctx context.Context, generation uint32,
 		lan.devices.Add(dev)
 		utils.Logger.Info().
 			Str("key", dev.PK()).
-			Str("endpoint", discovered.Xaddr).
+			Str("endpoint", endpoint).
 			Uint32("gen", generation).
 			Str("action", "add").
 			Msg("device")
 
 		lan.camsSwarm.Run(runCam(dev))
+		if len(eventsEndpoint) > 0 {
+			stop := make(chan struct{})
+			lan.eventsStop[dev.PK()] = stop
+			lan.camsSwarm.Run(lan.runEvents(dev.PK(), eventsEndpoint, auth, stop))
+		}
+		if lan.desired[dev.PK()] || lan.Config.Trigger.Enabled {
+			dev.PlayStream()
+		}
+		lan.notifyDevicesChanged()
+	}
+}
+
+// runStaticCameras connects to the cameras of the configuration, at each
+// generation of the scans. They are seen in each generation they are reachable,
+// while the cameras without ONVIF, the synthetic ones and the replays are seen
+// in each generation.
+func (lan *Agent) runStaticCameras(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case generation := <-lan.staticTrigger:
+			lan.dataLock.Lock()
+			static := append([]CameraConfig{}, lan.devicesStatic...)
+			streams := append([]StreamConfig{}, lan.streamsStatic...)
+			patterns := append([]PatternConfig{}, lan.patternsStatic...)
+			replays := append([]ReplayConfig{}, lan.replaysStatic...)
+			lan.dataLock.Unlock()
+			for _, cc := range static {
+				if err := lan.learnStaticCameraSync(ctx, generation, cc); err != nil {
+					utils.Logger.Warn().Str("url", cc.Address).Err(err).Msg("static camera unreachable")
+				}
+			}
+			for _, sc := range streams {
+				if err := lan.learnStreamSync(generation, sc); err != nil {
+					utils.Logger.Warn().Str("cam", sc.ID).Err(err).Msg("invalid stream")
+				}
+			}
+			for _, pc := range patterns {
+				if err := lan.learnPatternSync(generation, pc); err != nil {
+					utils.Logger.Warn().Str("cam", pc.ID).Err(err).Msg("invalid pattern")
+				}
+			}
+			for _, rc := range replays {
+				if err := lan.learnReplaySync(generation, rc); err != nil {
+					utils.Logger.Warn().Str("cam", rc.ID).Err(err).Msg("invalid replay")
+				}
+			}
+		}
 	}
-	return nil
 }
 
 func (lan *Agent) learnAllCamerasSync(ctx context.Context, gen uint32, discovered []networking.ClientInfo) {
@@ -309,10 +820,23 @@ func (lan *Agent) learnAllCamerasSync(ctx context.Context, gen uint32, discovere
 
 	for _, dev := range toBePurged {
 		lan.dataLock.Lock()
-		lan.devices.Remove(dev.PK())
-		dev.StopStream()
+		lan.forgetCamera(dev)
 		lan.dataLock.Unlock()
 	}
+	if len(toBePurged) > 0 {
+		lan.notifyDevicesChanged()
+	}
+}
+
+// forgetCamera stops the camera and its event subscription. Its spool and its
+// rolling buffer are kept. The caller holds the dataLock.
+func (lan *Agent) forgetCamera(dev *camera.Camera) {
+	lan.devices.Remove(dev.PK())
+	dev.StopStream()
+	if stop, ok := lan.eventsStop[dev.PK()]; ok {
+		close(stop)
+		delete(lan.eventsStop, dev.PK())
+	}
 }
 
 func (lan *Agent) camsToBePurged(gen uint32) []*camera.Camera {
@@ -351,9 +875,22 @@ func delta[T Unsigned](hi, lo T) T {
 
 func (lan *Agent) triggerRescanAsync(ctx context.Context) {
 	gen := atomic.AddUint32(&lan.generation, 1)
-	for _, itf := range lan.interfaces {
+
+	lan.dataLock.Lock()
+	itfs := append([]*Nic{}, lan.interfaces...)
+	static := len(lan.devicesStatic)+len(lan.streamsStatic)+len(lan.patternsStatic)+len(lan.replaysStatic) > 0
+	lan.dataLock.Unlock()
+
+	for _, itf := range itfs {
 		itf.TriggerRescanAsync(ctx, gen)
 	}
+	if static {
+		select {
+		case lan.staticTrigger <- gen:
+		default:
+			// a connection round is already pending
+		}
+	}
 }
 
 func (lan *Agent) PK() string { return "lan" }
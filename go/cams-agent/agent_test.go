@@ -0,0 +1,60 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jfsmig/cams/go/camera"
+)
+
+func TestAgent_ReconcileStopped(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AgentID = "agent"
+	lan := NewLanAgent(cfg)
+
+	src, err := camera.NewPatternSource("cam", 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cam := camera.NewSourceCamera(nil, src)
+	lan.devices.Add(cam)
+
+	// The agent of the camera has exited
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cam.Run(ctx)
+	if err = cam.PlayStream(); err != camera.ErrCameraStopped {
+		t.Fatal("unexpected error", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		lan.Reconcile([]string{"cam"})
+		lan.Reconcile(nil)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("reconcile blocked")
+	}
+	if st := cam.Status(); st.State != camera.CamAgentOff {
+		t.Fatal("camera restarted", st.State)
+	}
+}
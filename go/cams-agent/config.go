@@ -18,11 +18,19 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"net"
 	"os"
+	"path"
+	"regexp"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/jfsmig/cams/go/camera"
+	"github.com/jfsmig/cams/go/rtsp1/pkg/url"
 	"github.com/juju/errors"
 )
 
@@ -31,6 +39,15 @@ const (
 	DefaultRegisterPeriod  = 5
 	DefaultCheckPeriod     = 10
 	DefaultUpstreamTimeout = 30
+
+	DefaultSpoolMaxBytes    = 256 * 1024 * 1024
+	DefaultSpoolCatchUpRate = 1024 * 1024
+
+	DefaultTriggerPreEvent  = 10
+	DefaultTriggerPostEvent = 30
+	DefaultTriggerMaxBytes  = 16 * 1024 * 1024
+
+	DefaultLocalAddress = "127.0.0.1:6080"
 )
 
 type UpstreamConfig struct {
@@ -38,15 +55,120 @@ type UpstreamConfig struct {
 	Timeout int64  `json:"timeout"`
 }
 
+// SpoolConfig drives the buffering of the media on disk while the hub is unreachable
+type SpoolConfig struct {
+	// Where the media are buffered, one subdirectory per camera. Disabled if empty.
+	Dir string `json:"dir,omitempty"`
+
+	// Bound of the buffer of each camera, the oldest media are dropped beyond
+	MaxBytes int64 `json:"max_bytes,omitempty"`
+
+	// How fast the buffered media are uploaded once the hub is back, in bytes per second
+	CatchUpRate int64 `json:"catch_up_rate,omitempty"`
+}
+
+// TriggerConfig drives the trigger mode, where the cameras only upload when
+// something happens, preceded by a rolling buffer of their recent past.
+type TriggerConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Duration of the rolling buffer, in seconds
+	PreEvent int64 `json:"pre_event,omitempty"`
+
+	// Default duration of the upload after a trigger, in seconds
+	PostEvent int64 `json:"post_event,omitempty"`
+
+	// Bound of the rolling buffer of each camera, in bytes
+	MaxBytes int `json:"max_bytes,omitempty"`
+
+	// The local rules firing the trigger upon the events of the cameras
+	Rules []TriggerRule `json:"rules,omitempty"`
+}
+
+// TriggerRule fires the trigger of a camera when it notifies an event of the
+// given kind, e.g. "motion on the door fires for 20 seconds"
+type TriggerRule struct {
+	// The camera watched, any camera if empty
+	Camera string `json:"camera,omitempty"`
+
+	// The kind of event: motion, tamper or input
+	Event string `json:"event"`
+
+	// Duration of the upload, in seconds, the post-event duration if zero
+	Duration int64 `json:"duration,omitempty"`
+}
+
+// LocalConfig drives the HTTP API of the agent, for the troubleshooting on site
+type LocalConfig struct {
+	// Where the API listens, disabled if empty
+	Address string `json:"address"`
+
+	// The bearer token required by the API, none if empty
+	Token string `json:"token,omitempty"`
+
+	// The name of the token in the secrets file, instead of Token
+	TokenSecret string `json:"token_secret,omitempty"`
+}
+
+// CameraConfig is a camera reached directly at its address (host[:port]),
+// without discovery.
 type CameraConfig struct {
 	Address  string `json:"address"`
 	User     string `json:"user,omitempty"`
 	Password string `json:"password,omitempty"`
+
+	// The name of the password in the secrets file, instead of Password
+	PasswordSecret string `json:"password_secret,omitempty"`
+
+	// Identifies the camera, queried to the camera if empty
+	ID string `json:"id,omitempty"`
+}
+
+// StreamConfig is a camera without ONVIF, streaming at a fixed RTSP URL
+type StreamConfig struct {
+	// Identifies the camera, chosen by the user
+	ID string `json:"id"`
+
+	URL      string `json:"url"`
+	User     string `json:"user,omitempty"`
+	Password string `json:"password,omitempty"`
+
+	// The name of the password in the secrets file, instead of Password
+	PasswordSecret string `json:"password_secret,omitempty"`
+}
+
+// PatternConfig is a synthetic camera streaming a test pattern, for the demos
+// and the tests without hardware. The zero values stand for the defaults.
+type PatternConfig struct {
+	// Identifies the camera, chosen by the user
+	ID string `json:"id"`
+
+	Width  int     `json:"width,omitempty"`
+	Height int     `json:"height,omitempty"`
+	FPS    float64 `json:"fps,omitempty"`
+}
+
+// ReplayConfig is a camera replaying a capture of `cams-cli cam play`, to
+// reproduce against a hub the issues met on site
+type ReplayConfig struct {
+	// Identifies the camera, chosen by the user
+	ID string `json:"id"`
+
+	// The path of the capture archive
+	Path string `json:"path"`
+
+	// Whether the capture restarts at its end
+	Loop bool `json:"loop,omitempty"`
 }
 
 type AgentConfig struct {
 	User string `json:"user"`
 
+	// Identifies the agent among all the agents of the user. It is generated
+	// on the first run and persisted at IdentityPath when not configured.
+	AgentID      string `json:"agent_id,omitempty"`
+	IdentityPath string `json:"identity_path,omitempty"`
+
 	DiscoverPatterns []string `json:"discover"`
 	ScanPeriod       int64    `json:"scan_period"`
 	CheckPeriod      int64    `json:"check_period"`
@@ -55,8 +177,29 @@ type AgentConfig struct {
 	Interfaces []string       `json:"interfaces"`
 	Cameras    []CameraConfig `json:"cameras"`
 
+	// The cameras without ONVIF
+	Streams []StreamConfig `json:"streams,omitempty"`
+
+	// The synthetic cameras
+	Patterns []PatternConfig `json:"patterns,omitempty"`
+
+	// The cameras replaying captures
+	Replays []ReplayConfig `json:"replays,omitempty"`
+
+	// The credentials of the discovered cameras
+	Credentials []CredentialConfig `json:"credentials,omitempty"`
+
+	// The file holding the passwords referenced by name, see LoadSecrets
+	SecretsPath string `json:"secrets,omitempty"`
+
 	UpstreamControl UpstreamConfig `json:"control"`
 	UpstreamMedia   UpstreamConfig `json:"media"`
+
+	Spool SpoolConfig `json:"spool"`
+
+	Trigger TriggerConfig `json:"trigger"`
+
+	Local LocalConfig `json:"local"`
 }
 
 func DefaultConfig() AgentConfig {
@@ -68,6 +211,13 @@ func DefaultConfig() AgentConfig {
 		RegisterPeriod:   DefaultRegisterPeriod,
 		UpstreamControl:  UpstreamConfig{Address: "127.0.0.1:6000", Timeout: DefaultUpstreamTimeout},
 		UpstreamMedia:    UpstreamConfig{Address: "127.0.0.1:6000", Timeout: DefaultUpstreamTimeout},
+		Spool:            SpoolConfig{MaxBytes: DefaultSpoolMaxBytes, CatchUpRate: DefaultSpoolCatchUpRate},
+		Trigger: TriggerConfig{
+			PreEvent:  DefaultTriggerPreEvent,
+			PostEvent: DefaultTriggerPostEvent,
+			MaxBytes:  DefaultTriggerMaxBytes,
+		},
+		Local: LocalConfig{Address: DefaultLocalAddress},
 	}
 }
 
@@ -85,15 +235,180 @@ func (cfg *AgentConfig) LoadFile(path string) error {
 }
 
 func (cfg *AgentConfig) LoadBytes(encoded []byte) error {
-	if err := json.NewDecoder(bytes.NewReader(encoded)).Decode(cfg); err != nil {
+	return cfg.load(bytes.NewReader(encoded))
+}
+
+func (cfg *AgentConfig) LoadString(encoded string) error {
+	return cfg.load(strings.NewReader(encoded))
+}
+
+// load decodes the configuration, rejecting the unknown fields that are
+// likely typos
+func (cfg *AgentConfig) load(in io.Reader) error {
+	decoder := json.NewDecoder(in)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(cfg); err != nil {
 		return errors.Annotate(err, "decode")
 	}
 	return nil
 }
 
-func (cfg *AgentConfig) LoadString(encoded string) error {
-	if err := json.NewDecoder(strings.NewReader(encoded)).Decode(cfg); err != nil {
-		return errors.Annotate(err, "decode")
+// Environment variables overriding the configuration, the lists are comma-separated
+const (
+	EnvUser       = "CAMS_AGENT_USER"
+	EnvAgentID    = "CAMS_AGENT_ID"
+	EnvControl    = "CAMS_AGENT_CONTROL"
+	EnvMedia      = "CAMS_AGENT_MEDIA"
+	EnvDiscover   = "CAMS_AGENT_DISCOVER"
+	EnvInterfaces = "CAMS_AGENT_INTERFACES"
+	EnvSecrets    = "CAMS_AGENT_SECRETS"
+)
+
+// LoadEnv overrides the configuration with the environment variables that are set
+func (cfg *AgentConfig) LoadEnv(lookup func(string) (string, bool)) {
+	if v, ok := lookup(EnvUser); ok {
+		cfg.User = v
+	}
+	if v, ok := lookup(EnvAgentID); ok {
+		cfg.AgentID = v
+	}
+	if v, ok := lookup(EnvControl); ok {
+		cfg.UpstreamControl.Address = v
+	}
+	if v, ok := lookup(EnvMedia); ok {
+		cfg.UpstreamMedia.Address = v
+	}
+	if v, ok := lookup(EnvDiscover); ok {
+		cfg.DiscoverPatterns = splitList(v)
+	}
+	if v, ok := lookup(EnvInterfaces); ok {
+		cfg.Interfaces = splitList(v)
+	}
+	if v, ok := lookup(EnvSecrets); ok {
+		cfg.SecretsPath = v
+	}
+}
+
+func splitList(v string) []string {
+	out := make([]string, 0)
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// Validate checks the whole configuration and reports all its errors at once
+func (cfg *AgentConfig) Validate() error {
+	var errs []string
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Sprintf(format, args...))
+	}
+
+	if len(cfg.User) <= 0 {
+		fail("user: missing")
+	}
+	for i, pattern := range cfg.DiscoverPatterns {
+		// See matchInterface
+		if len(pattern) < 2 {
+			fail("discover[%d]: pattern %q too short", i, pattern)
+		} else if _, err := regexp.Compile(strings.TrimPrefix(pattern, "!")); err != nil {
+			fail("discover[%d]: invalid regex %q: %v", i, pattern, err)
+		}
+	}
+	for _, period := range []struct {
+		name  string
+		value int64
+	}{
+		{"scan_period", cfg.ScanPeriod},
+		{"check_period", cfg.CheckPeriod},
+		{"register_period", cfg.RegisterPeriod},
+		{"control.timeout", cfg.UpstreamControl.Timeout},
+		{"media.timeout", cfg.UpstreamMedia.Timeout},
+	} {
+		if period.value < 0 {
+			fail("%s: negative value %d", period.name, period.value)
+		}
+	}
+	for name, addr := range map[string]string{"control": cfg.UpstreamControl.Address, "media": cfg.UpstreamMedia.Address} {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			fail("%s.address: invalid address %q: %v", name, addr, err)
+		}
+	}
+	if len(cfg.Local.Address) > 0 {
+		if _, _, err := net.SplitHostPort(cfg.Local.Address); err != nil {
+			fail("local.address: invalid address %q: %v", cfg.Local.Address, err)
+		}
+	}
+	for i, cam := range cfg.Cameras {
+		if len(cam.Address) <= 0 {
+			fail("cameras[%d].address: missing", i)
+		}
+	}
+	streamIDs := make(map[string]bool)
+	for i, sc := range cfg.Streams {
+		if len(sc.ID) <= 0 {
+			fail("streams[%d].id: missing", i)
+		} else if streamIDs[sc.ID] {
+			fail("streams[%d].id: duplicated %q", i, sc.ID)
+		}
+		streamIDs[sc.ID] = true
+		if _, err := url.Parse(sc.URL); err != nil {
+			fail("streams[%d].url: invalid URL %q: %v", i, sc.URL, err)
+		}
+	}
+	for i, pc := range cfg.Patterns {
+		if len(pc.ID) <= 0 {
+			fail("patterns[%d].id: missing", i)
+		} else if streamIDs[pc.ID] {
+			fail("patterns[%d].id: duplicated %q", i, pc.ID)
+		}
+		streamIDs[pc.ID] = true
+		// The format only, the ID is checked above
+		if _, err := camera.NewPatternSource("-", pc.Width, pc.Height, pc.FPS); err != nil {
+			fail("patterns[%d]: %v", i, err)
+		}
+	}
+	for i, rc := range cfg.Replays {
+		if len(rc.ID) <= 0 {
+			fail("replays[%d].id: missing", i)
+		} else if streamIDs[rc.ID] {
+			fail("replays[%d].id: duplicated %q", i, rc.ID)
+		}
+		streamIDs[rc.ID] = true
+		if len(rc.Path) <= 0 {
+			fail("replays[%d].path: missing", i)
+		}
+	}
+	for i, set := range cfg.Credentials {
+		if len(set.User) <= 0 {
+			fail("credentials[%d].user: missing", i)
+		}
+		for name, pattern := range map[string]string{"address": set.Address, "mac": set.MAC} {
+			if _, err := path.Match(pattern, ""); err != nil {
+				fail("credentials[%d].%s: invalid pattern %q", i, name, pattern)
+			}
+		}
+	}
+	if cfg.Spool.MaxBytes < 0 || cfg.Spool.CatchUpRate < 0 {
+		fail("spool: negative bounds")
+	}
+	if cfg.Trigger.Enabled && (cfg.Trigger.PreEvent <= 0 || cfg.Trigger.MaxBytes <= 0) {
+		fail("trigger: the rolling buffer must be bounded")
+	}
+	for i, rule := range cfg.Trigger.Rules {
+		if _, ok := camera.ParseEventKind(rule.Event); !ok {
+			fail("trigger.rules[%d].event: unknown %q", i, rule.Event)
+		}
+		if rule.Duration < 0 {
+			fail("trigger.rules[%d].duration: negative", i)
+		}
+	}
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return errors.New("invalid configuration: " + strings.Join(errs, "; "))
 	}
 	return nil
 }
@@ -105,3 +420,21 @@ func (cfg *AgentConfig) GetScanPeriod() time.Duration {
 func (cfg *AgentConfig) GetCheckPeriod() time.Duration {
 	return time.Duration(cfg.CheckPeriod) * time.Second
 }
+
+func (cfg *TriggerConfig) GetPreEvent() time.Duration {
+	return time.Duration(cfg.PreEvent) * time.Second
+}
+
+func (cfg *TriggerConfig) GetPostEvent() time.Duration {
+	return time.Duration(cfg.PostEvent) * time.Second
+}
+
+// Fires tells if the rule fires the trigger of the camera upon the event
+func (rule *TriggerRule) Fires(event camera.Event) bool {
+	return event.Active && event.Kind.String() == rule.Event &&
+		(len(rule.Camera) <= 0 || rule.Camera == event.CamID)
+}
+
+func (rule *TriggerRule) GetDuration() time.Duration {
+	return time.Duration(rule.Duration) * time.Second
+}
@@ -16,6 +16,7 @@
 package main
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -113,3 +114,132 @@ func TestConfig_Override(t *testing.T) {
 		UpstreamMedia:   UpstreamConfig{Address: "127.0.0.1:6000", Timeout: 10},
 	})
 }
+
+func TestConfig_Strict(t *testing.T) {
+	var cfg AgentConfig
+	if err := cfg.LoadString(`{"discovery": ["eth*"]}`); err == nil {
+		t.Fatal("unexpected success")
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	cfg := DefaultConfig()
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.DiscoverPatterns = []string{"!lo", "eth(", "x"}
+	cfg.UpstreamMedia.Address = "nowhere"
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("unexpected success")
+	}
+	for _, expected := range []string{"discover[1]", "discover[2]", "media.address"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatal("unreported error", expected, err)
+		}
+	}
+}
+
+func TestConfig_ValidateStreams(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Streams = []StreamConfig{
+		{ID: "door", URL: "rtsp://10.0.0.5:554/live"},
+		{ID: "door", URL: "rtsp://10.0.0.6:554/live"},
+		{URL: "http://10.0.0.7/live"},
+	}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("unexpected success")
+	}
+	for _, expected := range []string{"streams[1].id", "streams[2].id", "streams[2].url"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatal("unreported error", expected, err)
+		}
+	}
+	if strings.Contains(err.Error(), "streams[0]") {
+		t.Fatal("unexpected error", err)
+	}
+}
+
+func TestConfig_ValidatePatterns(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Streams = []StreamConfig{{ID: "door", URL: "rtsp://10.0.0.5:554/live"}}
+	cfg.Patterns = []PatternConfig{
+		{ID: "demo"},
+		{ID: "door"},
+		{ID: "odd", Width: 100},
+		{ID: "slow", FPS: -1},
+		{ID: "fast", FPS: 1e12},
+	}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("unexpected success")
+	}
+	for _, expected := range []string{"patterns[1].id", "patterns[2]", "patterns[3]", "patterns[4]"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatal("unreported error", expected, err)
+		}
+	}
+	if strings.Contains(err.Error(), "patterns[0]") {
+		t.Fatal("unexpected error", err)
+	}
+}
+
+func TestConfig_ValidateReplays(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Patterns = []PatternConfig{{ID: "demo"}}
+	cfg.Replays = []ReplayConfig{
+		{ID: "bug", Path: "/tmp/capture.tar"},
+		{ID: "demo", Path: "/tmp/capture.tar"},
+		{ID: "nowhere"},
+	}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("unexpected success")
+	}
+	for _, expected := range []string{"replays[1].id", "replays[2].path"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatal("unreported error", expected, err)
+		}
+	}
+	if strings.Contains(err.Error(), "replays[0]") {
+		t.Fatal("unexpected error", err)
+	}
+}
+
+func TestConfig_ValidateTriggerRules(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Trigger.Rules = []TriggerRule{
+		{Camera: "door", Event: "motion", Duration: 20},
+		{Event: "doorbell"},
+		{Event: "tamper", Duration: -1},
+	}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("unexpected success")
+	}
+	for _, expected := range []string{"trigger.rules[1].event", "trigger.rules[2].duration"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatal("unreported error", expected, err)
+		}
+	}
+	if strings.Contains(err.Error(), "rules[0]") {
+		t.Fatal("unexpected error", err)
+	}
+}
+
+func TestConfig_Env(t *testing.T) {
+	env := map[string]string{
+		EnvUser:     "u",
+		EnvControl:  "10.0.0.1:6000",
+		EnvDiscover: "eth.*, !lo",
+	}
+	cfg := DefaultConfig()
+	cfg.LoadEnv(func(k string) (string, bool) { v, ok := env[k]; return v, ok })
+
+	assertValue(t, cfg.User, "u")
+	assertValue(t, cfg.UpstreamControl.Address, "10.0.0.1:6000")
+	assertValue(t, cfg.UpstreamMedia.Address, DefaultConfig().UpstreamMedia.Address)
+	assertArrays(t, cfg.DiscoverPatterns, []string{"eth.*", "!lo"})
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/jfsmig/onvif/networking"
+	"github.com/juju/errors"
+)
+
+// CredentialConfig is a set of credentials for the discovered cameras whose
+// address and MAC match the glob patterns (e.g. "192.168.1.*", "00:11:22:*").
+// A pattern left empty matches any camera.
+type CredentialConfig struct {
+	Address  string `json:"address,omitempty"`
+	MAC      string `json:"mac,omitempty"`
+	User     string `json:"user"`
+	Password string `json:"password,omitempty"`
+
+	// The name of the password in the secrets file, instead of Password
+	PasswordSecret string `json:"password_secret,omitempty"`
+}
+
+// Where the MAC addresses of the neighbours are looked up
+var arpTablePath = "/proc/net/arp"
+
+// LoadSecrets resolves the passwords referenced by name, from the secrets
+// file that maps each name to a password.
+func (cfg *AgentConfig) LoadSecrets() error {
+	secrets := make(map[string]string)
+	if len(cfg.SecretsPath) > 0 {
+		encoded, err := os.ReadFile(cfg.SecretsPath)
+		if err != nil {
+			return errors.Annotate(err, "secrets")
+		}
+		if err = json.Unmarshal(encoded, &secrets); err != nil {
+			return errors.Annotate(err, "secrets decode")
+		}
+	}
+
+	resolve := func(name string, password *string) error {
+		if len(name) <= 0 {
+			return nil
+		}
+		secret, ok := secrets[name]
+		if !ok {
+			return errors.Errorf("secret %q not found", name)
+		}
+		*password = secret
+		return nil
+	}
+	for i := range cfg.Cameras {
+		if err := resolve(cfg.Cameras[i].PasswordSecret, &cfg.Cameras[i].Password); err != nil {
+			return errors.Annotatef(err, "cameras[%d]", i)
+		}
+	}
+	for i := range cfg.Streams {
+		if err := resolve(cfg.Streams[i].PasswordSecret, &cfg.Streams[i].Password); err != nil {
+			return errors.Annotatef(err, "streams[%d]", i)
+		}
+	}
+	for i := range cfg.Credentials {
+		if err := resolve(cfg.Credentials[i].PasswordSecret, &cfg.Credentials[i].Password); err != nil {
+			return errors.Annotatef(err, "credentials[%d]", i)
+		}
+	}
+	if err := resolve(cfg.Local.TokenSecret, &cfg.Local.Token); err != nil {
+		return errors.Annotate(err, "local")
+	}
+	return nil
+}
+
+// CredentialsFor returns the credentials of the camera at the given address:
+// those of the static camera with that address, or else those of the first set
+// that matches. The MAC is only looked up when a set needs it.
+func (cfg *AgentConfig) CredentialsFor(xaddr string, mac func(host string) string) (networking.ClientAuth, bool) {
+	for _, cam := range cfg.Cameras {
+		if cam.Address == xaddr {
+			return networking.ClientAuth{Username: cam.User, Password: cam.Password}, true
+		}
+	}
+
+	host := xaddr
+	if h, _, err := net.SplitHostPort(xaddr); err == nil {
+		host = h
+	}
+	var hwaddr *string
+	for _, set := range cfg.Credentials {
+		if len(set.Address) > 0 {
+			if ok, _ := path.Match(set.Address, host); !ok {
+				continue
+			}
+		}
+		if len(set.MAC) > 0 {
+			if hwaddr == nil {
+				m := strings.ToLower(mac(host))
+				hwaddr = &m
+			}
+			if ok, _ := path.Match(strings.ToLower(set.MAC), *hwaddr); !ok {
+				continue
+			}
+		}
+		return networking.ClientAuth{Username: set.User, Password: set.Password}, true
+	}
+	return networking.ClientAuth{}, false
+}
+
+// lookupMAC finds the MAC address of a neighbour in the ARP table of the
+// system, empty if not found.
+func lookupMAC(host string) string {
+	fin, err := os.Open(arpTablePath)
+	if err != nil {
+		return ""
+	}
+	defer fin.Close()
+
+	scanner := bufio.NewScanner(fin)
+	scanner.Scan() // skip the header
+	for scanner.Scan() {
+		// IP address, HW type, Flags, HW address, Mask, Device
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 4 && fields[0] == host {
+			return fields[3]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCredentials_Match(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Cameras = []CameraConfig{{Address: "10.0.0.9:8080", User: "static", Password: "s"}}
+	cfg.Credentials = []CredentialConfig{
+		{MAC: "00:11:22:*", User: "vendor", Password: "v"},
+		{Address: "192.168.1.*", User: "lan", Password: "l"},
+	}
+	macs := map[string]string{"192.168.1.7": "00:11:22:33:44:55", "192.168.1.8": "aa:bb:cc:dd:ee:ff"}
+	mac := func(host string) string { return macs[host] }
+
+	for xaddr, expected := range map[string]string{
+		"10.0.0.9:8080":  "static",
+		"192.168.1.7":    "vendor",
+		"192.168.1.8:80": "lan",
+		"10.0.0.10":      "",
+	} {
+		auth, ok := cfg.CredentialsFor(xaddr, mac)
+		if ok != (len(expected) > 0) || auth.Username != expected {
+			t.Fatal("unexpected credentials", xaddr, auth)
+		}
+	}
+}
+
+func TestCredentials_Secrets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.json")
+	if err := os.WriteFile(path, []byte(`{"lan": "secret"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.SecretsPath = path
+	cfg.Credentials = []CredentialConfig{{User: "admin", PasswordSecret: "lan"}}
+	if err := cfg.LoadSecrets(); err != nil {
+		t.Fatal(err)
+	}
+	assertValue(t, cfg.Credentials[0].Password, "secret")
+
+	cfg.Cameras = []CameraConfig{{Address: "10.0.0.9", PasswordSecret: "missing"}}
+	if err := cfg.LoadSecrets(); err == nil {
+		t.Fatal("unexpected success")
+	}
+}
+
+func TestCredentials_ARP(t *testing.T) {
+	arpTablePath = filepath.Join(t.TempDir(), "arp")
+	defer func() { arpTablePath = "/proc/net/arp" }()
+	table := "IP address       HW type     Flags       HW address            Mask     Device\n" +
+		"192.168.1.7      0x1         0x2         00:11:22:33:44:55     *        eth0\n"
+	if err := os.WriteFile(arpTablePath, []byte(table), 0600); err != nil {
+		t.Fatal(err)
+	}
+	assertValue(t, lookupMAC("192.168.1.7"), "00:11:22:33:44:55")
+	assertValue(t, lookupMAC("192.168.1.8"), "")
+}
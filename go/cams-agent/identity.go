@@ -0,0 +1,62 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/juju/errors"
+)
+
+const identityFileName = "agent-id"
+
+// defaultIdentityPath locates the identity of the agent in the configuration
+// directory of the user, or in the working directory as a fallback.
+func defaultIdentityPath() string {
+	if dir, err := os.UserConfigDir(); err == nil {
+		return filepath.Join(dir, "cams", identityFileName)
+	}
+	return "." + identityFileName
+}
+
+// loadOrCreateAgentID returns the agent ID persisted at the given path.
+// A new ID is generated and persisted if none exists yet.
+func loadOrCreateAgentID(path string) (string, error) {
+	if len(path) <= 0 {
+		path = defaultIdentityPath()
+	}
+
+	encoded, err := os.ReadFile(path)
+	if err == nil {
+		if id := strings.TrimSpace(string(encoded)); len(id) > 0 {
+			return id, nil
+		}
+	} else if !os.IsNotExist(err) {
+		return "", errors.Annotate(err, "read")
+	}
+
+	id := uuid.NewString()
+	if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", errors.Annotate(err, "mkdir")
+	}
+	if err = os.WriteFile(path, []byte(id+"\n"), 0o600); err != nil {
+		return "", errors.Annotate(err, "write")
+	}
+	return id, nil
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestAgentID_Persisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "agent-id")
+
+	id0, err := loadOrCreateAgentID(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(id0) <= 0 {
+		t.Fatal("empty agent ID")
+	}
+
+	id1, err := loadOrCreateAgentID(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id0 != id1 {
+		t.Fatal("agent ID not persisted", id0, id1)
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jfsmig/cams/go/camera"
+	"github.com/jfsmig/cams/go/utils"
+	"github.com/juju/errors"
+)
+
+// localAPI is the HTTP/JSON API of the agent, for the troubleshooting on site.
+//
+//	GET  /v1/status              everything below at once
+//	GET  /v1/interfaces          the interfaces scanned
+//	GET  /v1/cameras             the cameras known, with their state
+//	GET  /v1/upstream            the connection to the hub
+//	POST /v1/cameras/<id>/play   plays the stream of the camera, e.g. <uuid>/sub
+//	POST /v1/cameras/<id>/stop   stops the stream of the camera
+type localAPI struct {
+	token    string
+	lan      *Agent
+	upstream *upstreamAgent
+}
+
+// cameraView describes a camera to the local API
+type cameraView struct {
+	ID          string    `json:"id"`
+	Generation  uint32    `json:"generation"`
+	State       string    `json:"state"`
+	Desired     bool      `json:"desired"`
+	LastError   string    `json:"last_error,omitempty"`
+	RtpPackets  uint64    `json:"rtp_packets"`
+	RtpBytes    uint64    `json:"rtp_bytes"`
+	RtcpPackets uint64    `json:"rtcp_packets"`
+	Restarts    uint64    `json:"restarts"`
+	LastPacket  time.Time `json:"last_packet"`
+}
+
+type statusView struct {
+	Agent      string         `json:"agent"`
+	User       string         `json:"user"`
+	Interfaces []string       `json:"interfaces"`
+	Cameras    []cameraView   `json:"cameras"`
+	Upstream   upstreamStatus `json:"upstream"`
+}
+
+// runLocalAPI serves the local API until the context ends
+func runLocalAPI(ctx context.Context, cfg LocalConfig, lan *Agent, upstream *upstreamAgent) {
+	listener, err := net.Listen("tcp", cfg.Address)
+	if err != nil {
+		utils.Logger.Warn().Err(err).Str("action", "listen").Msg("local")
+		return
+	}
+
+	api := &localAPI{token: cfg.Token, lan: lan, upstream: upstream}
+	srv := http.Server{Handler: api}
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+
+	utils.Logger.Info().Str("addr", cfg.Address).Bool("token", len(cfg.Token) > 0).Str("action", "start").Msg("local")
+	if err := srv.Serve(listener); err != nil && err != http.ErrServerClosed {
+		utils.Logger.Warn().Err(err).Msg("local error")
+	}
+}
+
+func (api *localAPI) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if !api.sameOrigin(req) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	if !api.authorized(req) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	path := strings.TrimSuffix(req.URL.Path, "/")
+	switch path {
+	case "/v1/status":
+		api.get(w, req, func() interface{} { return api.status() })
+	case "/v1/interfaces":
+		api.get(w, req, func() interface{} { return api.lan.Interfaces() })
+	case "/v1/cameras":
+		api.get(w, req, func() interface{} { return api.cameras() })
+	case "/v1/upstream":
+		api.get(w, req, func() interface{} { return api.upstream.Status() })
+	default:
+		// The IDs of the streams of the profiles contain a slash
+		rest, ok := strings.CutPrefix(path, "/v1/cameras/")
+		i := strings.LastIndexByte(rest, '/')
+		if !ok || i <= 0 {
+			http.NotFound(w, req)
+			return
+		}
+		camID, action := rest[:i], rest[i+1:]
+		var cmd camera.CamCommand
+		switch action {
+		case "play":
+			cmd = camera.CamCommandPlay
+		case "stop":
+			cmd = camera.CamCommandPause
+		default:
+			http.NotFound(w, req)
+			return
+		}
+		if req.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		api.command(w, camID, action, cmd)
+	}
+}
+
+// authorized checks the bearer token of the request, when one is configured
+func (api *localAPI) authorized(req *http.Request) bool {
+	if len(api.token) <= 0 {
+		return true
+	}
+	token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(api.token)) == 1
+}
+
+// sameOrigin rejects the requests of the web pages open on the host: a foreign
+// Origin, e.g. a cross-site POST, or, without token, a Host other than the
+// loopback, e.g. after a DNS rebinding.
+func (api *localAPI) sameOrigin(req *http.Request) bool {
+	if origin := req.Header.Get("Origin"); len(origin) > 0 {
+		u, err := url.Parse(origin)
+		if err != nil || u.Host != req.Host {
+			return false
+		}
+	}
+	if len(api.token) > 0 {
+		return true
+	}
+	host, _, err := net.SplitHostPort(req.Host)
+	if err != nil {
+		host = req.Host
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && ip.IsLoopback()
+}
+
+func (api *localAPI) get(w http.ResponseWriter, req *http.Request, body func() interface{}) {
+	if req.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, body())
+}
+
+func (api *localAPI) command(w http.ResponseWriter, camID, action string, cmd camera.CamCommand) {
+	utils.Logger.Info().Str("cam", camID).Str("action", action).Msg("local")
+
+	err := api.lan.UpdateStreamExpectation(camID, cmd)
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, ErrNoSuchCamera):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (api *localAPI) status() statusView {
+	cfg := api.lan.config()
+	return statusView{
+		Agent:      cfg.AgentID,
+		User:       cfg.User,
+		Interfaces: api.lan.Interfaces(),
+		Cameras:    api.cameras(),
+		Upstream:   api.upstream.Status(),
+	}
+}
+
+func (api *localAPI) cameras() []cameraView {
+	out := make([]cameraView, 0)
+	for _, cam := range api.lan.Cameras() {
+		status := cam.Status()
+		api.lan.dataLock.Lock()
+		generation, desired := cam.GetGeneration(), api.lan.desired[cam.ID]
+		api.lan.dataLock.Unlock()
+		out = append(out, cameraView{
+			ID:          cam.ID,
+			Generation:  generation,
+			State:       status.State.String(),
+			Desired:     desired,
+			LastError:   status.LastError,
+			RtpPackets:  status.Stats.RtpPackets,
+			RtpBytes:    status.Stats.RtpBytes,
+			RtcpPackets: status.Stats.RtcpPackets,
+			Restarts:    status.Stats.Restarts,
+			LastPacket:  status.Stats.LastPacket,
+		})
+	}
+	return out
+}
+
+func writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		utils.Logger.Warn().Err(err).Msg("local")
+	}
+}
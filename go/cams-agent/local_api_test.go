@@ -0,0 +1,154 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestLocalAPI(token string) *localAPI {
+	cfg := DefaultConfig()
+	cfg.AgentID = "agent"
+	lan := NewLanAgent(cfg)
+	lan.interfaces.Add(NewNIC("eth0"))
+	return &localAPI{token: token, lan: lan, upstream: NewUpstreamAgent(cfg)}
+}
+
+func serveLocal(api *localAPI, method, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.Host = "127.0.0.1:6080"
+	if len(token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	api.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestLocalAPI_Status(t *testing.T) {
+	api := newTestLocalAPI("")
+
+	rec := serveLocal(api, http.MethodGet, "/v1/status", "")
+	if rec.Code != http.StatusOK {
+		t.Fatal("unexpected code", rec.Code)
+	}
+	var status statusView
+	if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+		t.Fatal(err)
+	}
+	if status.Agent != "agent" || len(status.Interfaces) != 1 || status.Interfaces[0] != "eth0" {
+		t.Fatal("unexpected status", status)
+	}
+	if status.Upstream.Connected || status.Upstream.Address != DefaultConfig().UpstreamControl.Address {
+		t.Fatal("unexpected upstream", status.Upstream)
+	}
+
+	for path, code := range map[string]int{
+		"/v1/cameras":           http.StatusOK,
+		"/v1/nope":              http.StatusNotFound,
+		"/v1/cameras/cam/pause": http.StatusNotFound,
+	} {
+		if rec = serveLocal(api, http.MethodGet, path, ""); rec.Code != code {
+			t.Fatal("unexpected code", path, rec.Code)
+		}
+	}
+}
+
+func TestLocalAPI_Commands(t *testing.T) {
+	api := newTestLocalAPI("")
+
+	if rec := serveLocal(api, http.MethodGet, "/v1/cameras/cam/play", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Fatal("unexpected code", rec.Code)
+	}
+	if rec := serveLocal(api, http.MethodPost, "/v1/cameras/cam/play", ""); rec.Code != http.StatusNotFound {
+		t.Fatal("unexpected code", rec.Code)
+	}
+	// The expectation is kept for a camera discovered later
+	if !api.lan.isDesired("cam") {
+		t.Fatal("expectation lost")
+	}
+
+	// The streams of the profiles of a camera
+	if rec := serveLocal(api, http.MethodPost, "/v1/cameras/uuid/sub/play", ""); rec.Code != http.StatusNotFound {
+		t.Fatal("unexpected code", rec.Code)
+	}
+	if !api.lan.isDesired("uuid/sub") || api.lan.isDesired("uuid") {
+		t.Fatal("unexpected expectation")
+	}
+}
+
+func TestLocalAPI_Token(t *testing.T) {
+	api := newTestLocalAPI("secret")
+
+	for token, code := range map[string]int{
+		"":       http.StatusUnauthorized,
+		"wrong":  http.StatusUnauthorized,
+		"secret": http.StatusOK,
+	} {
+		if rec := serveLocal(api, http.MethodGet, "/v1/interfaces", token); rec.Code != code {
+			t.Fatal("unexpected code", token, rec.Code)
+		}
+	}
+}
+
+func TestLocalAPI_Origin(t *testing.T) {
+	api := newTestLocalAPI("")
+
+	for _, tc := range []struct {
+		host, origin string
+		code         int
+	}{
+		{"127.0.0.1:6080", "", http.StatusOK},
+		{"localhost:6080", "http://localhost:6080", http.StatusOK},
+		{"[::1]:6080", "", http.StatusOK},
+		// A cross-site request
+		{"127.0.0.1:6080", "http://evil.example", http.StatusForbidden},
+		// A DNS rebinding
+		{"evil.example:6080", "", http.StatusForbidden},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/interfaces", nil)
+		req.Host = tc.host
+		if len(tc.origin) > 0 {
+			req.Header.Set("Origin", tc.origin)
+		}
+		rec := httptest.NewRecorder()
+		api.ServeHTTP(rec, req)
+		if rec.Code != tc.code {
+			t.Fatal("unexpected code", tc.host, tc.origin, rec.Code)
+		}
+	}
+
+	// With a token, the API may be reached from afar, still not cross-site
+	api = newTestLocalAPI("secret")
+	req := httptest.NewRequest(http.MethodPost, "/v1/cameras/cam/play", nil)
+	req.Host = "agent.lan:6080"
+	req.Header.Set("Origin", "http://evil.example")
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	api.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatal("unexpected code", rec.Code)
+	}
+	req.Header.Del("Origin")
+	rec = httptest.NewRecorder()
+	api.ServeHTTP(rec, req)
+	if rec.Code == http.StatusForbidden || rec.Code == http.StatusUnauthorized {
+		t.Fatal("unexpected code", rec.Code)
+	}
+}
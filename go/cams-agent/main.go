@@ -17,16 +17,89 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"io"
 	"os"
 	"os/signal"
+	"sync"
+	"syscall"
 
 	"github.com/jfsmig/cams/go/utils"
+	"github.com/juju/errors"
 	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
+// configFlags are the command line options that build the configuration
+type configFlags struct {
+	path       string
+	user       string
+	agentID    string
+	identity   string
+	control    string
+	media      string
+	discover   []string
+	interfaces []string
+	secrets    string
+}
+
+func (cf *configFlags) bind(flags *pflag.FlagSet) {
+	flags.StringVarP(&cf.path, "config", "c", "", "Path to the JSON configuration file")
+	flags.StringVar(&cf.user, "user", "", "User owning the agent (env "+EnvUser+")")
+	flags.StringVar(&cf.agentID, "agent-id", "", "Identifier of the agent (env "+EnvAgentID+")")
+	flags.StringVar(&cf.identity, "identity", "", "File persisting the generated identifier of the agent")
+	flags.StringVar(&cf.control, "control", "", "Address of the control endpoint of the hub (env "+EnvControl+")")
+	flags.StringVar(&cf.media, "media", "", "Address of the media endpoint of the hub (env "+EnvMedia+")")
+	flags.StringSliceVar(&cf.discover, "discover", nil, "Patterns of the interfaces to scan, '!' excludes (env "+EnvDiscover+")")
+	flags.StringSliceVar(&cf.interfaces, "interface", nil, "Interface to scan in any case (env "+EnvInterfaces+")")
+	flags.StringVar(&cf.secrets, "secrets", "", "Path to the JSON file of the passwords referenced by name (env "+EnvSecrets+")")
+}
+
+// load builds the configuration from the defaults, then the configuration
+// file, the environment and the command line, each overriding the former.
+func (cf *configFlags) load(flags *pflag.FlagSet) (AgentConfig, error) {
+	cfg := DefaultConfig()
+	if len(cf.path) > 0 {
+		if err := cfg.LoadFile(cf.path); err != nil {
+			return cfg, errors.Annotate(err, cf.path)
+		}
+	}
+	cfg.LoadEnv(os.LookupEnv)
+
+	if flags.Changed("user") {
+		cfg.User = cf.user
+	}
+	if flags.Changed("agent-id") {
+		cfg.AgentID = cf.agentID
+	}
+	if flags.Changed("identity") {
+		cfg.IdentityPath = cf.identity
+	}
+	if flags.Changed("control") {
+		cfg.UpstreamControl.Address = cf.control
+	}
+	if flags.Changed("media") {
+		cfg.UpstreamMedia.Address = cf.media
+	}
+	if flags.Changed("discover") {
+		cfg.DiscoverPatterns = cf.discover
+	}
+	if flags.Changed("interface") {
+		cfg.Interfaces = cf.interfaces
+	}
+	if flags.Changed("secrets") {
+		cfg.SecretsPath = cf.secrets
+	}
+	if err := cfg.LoadSecrets(); err != nil {
+		return cfg, err
+	}
+	return cfg, cfg.Validate()
+}
+
 func main() {
 	var flagSpeed bool
+	var cf configFlags
 
 	cmd := &cobra.Command{
 		Use:   "agent",
@@ -35,22 +108,43 @@ func main() {
 		//Args:  cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			zerolog.SetGlobalLevel(zerolog.TraceLevel)
-			// FIXME(jfs): load an external configuration file or CLI options
-			cfg := DefaultConfig()
-			if flagSpeed {
-				cfg.RegisterPeriod = 1
-				cfg.ScanPeriod = 0
-				cfg.CheckPeriod = 1
+			load := func() (AgentConfig, error) {
+				cfg, err := cf.load(cmd.Flags())
+				if err == nil && flagSpeed {
+					cfg.RegisterPeriod = 1
+					cfg.ScanPeriod = 0
+					cfg.CheckPeriod = 1
+				}
+				return cfg, err
 			}
 			ctx, cancel := signal.NotifyContext(context.Background(), os.Kill, os.Interrupt)
 			defer cancel()
 
-			return runAgent(ctx, cfg)
+			return runAgent(ctx, load)
 		},
 	}
 
+	cf.bind(cmd.PersistentFlags())
 	cmd.Flags().BoolVarP(&flagSpeed, "speed", "s", false, "TEST with fast loops")
 
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Configuration helpers",
+	}
+	configCmd.AddCommand(&cobra.Command{
+		Use:   "check",
+		Short: "Validate the configuration and print its effective value",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := cf.load(cmd.Flags())
+			if err != nil {
+				return err
+			}
+			return printConfig(cmd.OutOrStdout(), cfg)
+		},
+	})
+	cmd.AddCommand(configCmd)
+
 	if err := cmd.Execute(); err != nil {
 		utils.Logger.Fatal().Err(err).Str("action", "aborting").Msg("agent")
 	} else {
@@ -58,15 +152,99 @@ func main() {
 	}
 }
 
-func runAgent(ctx context.Context, cfg AgentConfig) error {
+// printConfig dumps the configuration as JSON, without the passwords
+func printConfig(out io.Writer, cfg AgentConfig) error {
+	cfg.Cameras = append([]CameraConfig(nil), cfg.Cameras...)
+	for i := range cfg.Cameras {
+		if len(cfg.Cameras[i].Password) > 0 {
+			cfg.Cameras[i].Password = "****"
+		}
+	}
+	cfg.Streams = append([]StreamConfig(nil), cfg.Streams...)
+	for i := range cfg.Streams {
+		if len(cfg.Streams[i].Password) > 0 {
+			cfg.Streams[i].Password = "****"
+		}
+	}
+	cfg.Credentials = append([]CredentialConfig(nil), cfg.Credentials...)
+	for i := range cfg.Credentials {
+		if len(cfg.Credentials[i].Password) > 0 {
+			cfg.Credentials[i].Password = "****"
+		}
+	}
+	if len(cfg.Local.Token) > 0 {
+		cfg.Local.Token = "****"
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(cfg)
+}
+
+// runAgent runs the agent until the context ends. The configuration is loaded
+// again on SIGHUP or on the request of the hub.
+func runAgent(ctx context.Context, load func() (AgentConfig, error)) error {
+	cfg, err := load()
+	if err != nil {
+		return err
+	}
+	if len(cfg.AgentID) <= 0 {
+		id, err := loadOrCreateAgentID(cfg.IdentityPath)
+		if err != nil {
+			return errors.Annotate(err, "agent identity")
+		}
+		cfg.AgentID = id
+	}
+
 	lan := NewLanAgent(cfg)
 	upstream := NewUpstreamAgent(cfg)
 
-	utils.Logger.Info().Str("action", "starting").Msg("agent")
+	var reloadLock sync.Mutex
+	reload := func() error {
+		reloadLock.Lock()
+		defer reloadLock.Unlock()
+		cfg, err := load()
+		if err != nil {
+			return errors.Annotate(err, "reload")
+		}
+		diff := lan.Reload(ctx, cfg)
+		upstream.Reload(cfg)
+		utils.Logger.Info().
+			Bool("nics", diff.nics).
+			Int("added", len(diff.camerasAdded)).
+			Int("removed", len(diff.camerasRemoved)).
+			Bool("control", diff.controlMoved).
+			Bool("media", diff.mediaMoved).
+			Str("action", "reload").
+			Msg("agent")
+		return nil
+	}
+	upstream.reload = reload
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	utils.Logger.Info().Str("agent", cfg.AgentID).Str("action", "starting").Msg("agent")
+
+	if len(cfg.Local.Address) > 0 {
+		go runLocalAPI(ctx, cfg.Local, lan, upstream)
+	}
 
 	utils.GroupRun(ctx,
 		func(c context.Context) { upstream.Run(c, lan) },
 		func(c context.Context) { lan.Run(c) },
+		func(c context.Context) {
+			for {
+				select {
+				case <-c.Done():
+					return
+				case <-hup:
+					if err := reload(); err != nil {
+						utils.Logger.Warn().Err(err).Msg("agent")
+					}
+				}
+			}
+		},
 	)
 
 	return nil
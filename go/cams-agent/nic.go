@@ -17,6 +17,7 @@ package main
 
 import (
 	"context"
+	"sync"
 
 	"github.com/jfsmig/cams/go/utils"
 	"github.com/jfsmig/onvif/networking"
@@ -27,15 +28,23 @@ import (
 type Nic struct {
 	ItfName string
 	trigger chan uint32
+
+	// Closed to stop the rescan loop, when the interface isn't scanned anymore
+	stop     chan struct{}
+	stopOnce sync.Once
 }
 
 func NewNIC(name string) *Nic {
 	return &Nic{
 		ItfName: name,
 		trigger: make(chan uint32, 1),
+		stop:    make(chan struct{}),
 	}
 }
 
+// Stop asks the rescan loop to exit, without waiting for it
+func (ls *Nic) Stop() { ls.stopOnce.Do(func() { close(ls.stop) }) }
+
 func (ls *Nic) PK() string { return ls.ItfName }
 
 func (ls *Nic) debug() *zerolog.Event { return utils.Logger.Debug().Str("itf", ls.PK()) }
@@ -53,7 +62,10 @@ func (ls *Nic) RunRescanLoop(ctx context.Context, register RegistrationFunc) {
 
 		case <-ctx.Done():
 			ls.debug().Msg("nic stopping")
-			close(ls.trigger)
+			return
+
+		case <-ls.stop:
+			ls.debug().Msg("nic stopping")
 			return
 
 		case generation := <-ls.trigger:
@@ -0,0 +1,208 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/jfsmig/cams/go/utils"
+)
+
+// configDiff is what a reload of the configuration changes
+type configDiff struct {
+	// The interfaces to scan may change
+	nics bool
+
+	// The static cameras, a camera whose settings changed is removed then added
+	camerasAdded   []CameraConfig
+	camerasRemoved []CameraConfig
+
+	// The cameras without ONVIF, likewise
+	streamsAdded   []StreamConfig
+	streamsRemoved []StreamConfig
+
+	// The synthetic cameras, likewise
+	patternsAdded   []PatternConfig
+	patternsRemoved []PatternConfig
+
+	// The replays of captures, likewise
+	replaysAdded   []ReplayConfig
+	replaysRemoved []ReplayConfig
+
+	controlMoved bool
+	mediaMoved   bool
+
+	// The fields that changed but only apply at the next start of the agent
+	restartOnly []string
+}
+
+func (diff configDiff) empty() bool {
+	return !diff.nics && !diff.controlMoved && !diff.mediaMoved &&
+		len(diff.camerasAdded)+len(diff.camerasRemoved)+
+			len(diff.streamsAdded)+len(diff.streamsRemoved)+
+			len(diff.patternsAdded)+len(diff.patternsRemoved)+
+			len(diff.replaysAdded)+len(diff.replaysRemoved)+len(diff.restartOnly) <= 0
+}
+
+// diffConfig compares the running configuration with a reloaded one. An
+// agent ID left empty in the reloaded configuration keeps the running one.
+func diffConfig(old, cfg AgentConfig) configDiff {
+	var diff configDiff
+
+	diff.nics = !reflect.DeepEqual(old.DiscoverPatterns, cfg.DiscoverPatterns) ||
+		!reflect.DeepEqual(old.Interfaces, cfg.Interfaces)
+
+	diff.camerasAdded, diff.camerasRemoved = diffByKey(old.Cameras, cfg.Cameras, func(cc CameraConfig) string { return cc.Address })
+	diff.streamsAdded, diff.streamsRemoved = diffByKey(old.Streams, cfg.Streams, func(sc StreamConfig) string { return sc.ID })
+	diff.patternsAdded, diff.patternsRemoved = diffByKey(old.Patterns, cfg.Patterns, func(pc PatternConfig) string { return pc.ID })
+	diff.replaysAdded, diff.replaysRemoved = diffByKey(old.Replays, cfg.Replays, func(rc ReplayConfig) string { return rc.ID })
+
+	diff.controlMoved = old.UpstreamControl.Address != cfg.UpstreamControl.Address
+	diff.mediaMoved = old.UpstreamMedia.Address != cfg.UpstreamMedia.Address
+
+	if old.User != cfg.User {
+		diff.restartOnly = append(diff.restartOnly, "user")
+	}
+	if len(cfg.AgentID) > 0 && old.AgentID != cfg.AgentID {
+		diff.restartOnly = append(diff.restartOnly, "agent_id")
+	}
+	if old.IdentityPath != cfg.IdentityPath {
+		diff.restartOnly = append(diff.restartOnly, "identity_path")
+	}
+	if old.Spool != cfg.Spool {
+		diff.restartOnly = append(diff.restartOnly, "spool")
+	}
+	// The trigger rules apply to the next events, unlike the buffers
+	oldTrigger, newTrigger := old.Trigger, cfg.Trigger
+	oldTrigger.Rules, newTrigger.Rules = nil, nil
+	if !reflect.DeepEqual(oldTrigger, newTrigger) {
+		diff.restartOnly = append(diff.restartOnly, "trigger")
+	}
+	if old.Local != cfg.Local {
+		diff.restartOnly = append(diff.restartOnly, "local")
+	}
+	return diff
+}
+
+// diffByKey compares two lists of cameras, a camera whose settings changed is
+// both removed and added.
+func diffByKey[T comparable](old, cfg []T, key func(T) string) (added, removed []T) {
+	olds := make(map[string]T)
+	for _, item := range old {
+		olds[key(item)] = item
+	}
+	news := make(map[string]T)
+	for _, item := range cfg {
+		news[key(item)] = item
+		if known, ok := olds[key(item)]; !ok || known != item {
+			added = append(added, item)
+		}
+	}
+	for _, item := range old {
+		if known, ok := news[key(item)]; !ok || known != item {
+			removed = append(removed, item)
+		}
+	}
+	return added, removed
+}
+
+// keepRestartOnly returns the reloaded configuration with the fields that
+// cannot change while the agent runs.
+func keepRestartOnly(old, cfg AgentConfig) AgentConfig {
+	cfg.User = old.User
+	cfg.AgentID = old.AgentID
+	cfg.IdentityPath = old.IdentityPath
+	cfg.Spool = old.Spool
+	rules := cfg.Trigger.Rules
+	cfg.Trigger = old.Trigger
+	cfg.Trigger.Rules = rules
+	cfg.Local = old.Local
+	return cfg
+}
+
+// config returns the running configuration, that a reload may replace
+func (lan *Agent) config() AgentConfig {
+	lan.dataLock.Lock()
+	defer lan.dataLock.Unlock()
+	return lan.Config
+}
+
+// Reload applies a new configuration: the rescan loops of the interfaces and
+// the static cameras follow the changes, the media upload moves to its new
+// address. The sessions of the cameras left unchanged are not disturbed.
+func (lan *Agent) Reload(ctx context.Context, cfg AgentConfig) configDiff {
+	lan.dataLock.Lock()
+	diff := diffConfig(lan.Config, cfg)
+	lan.Config = keepRestartOnly(lan.Config, cfg)
+	lan.interfacesDiscoverPatterns = cfg.DiscoverPatterns
+	lan.interfacesStatic = cfg.Interfaces
+	lan.devicesStatic = cfg.Cameras
+	lan.streamsStatic = cfg.Streams
+	lan.patternsStatic = cfg.Patterns
+	lan.replaysStatic = cfg.Replays
+
+	forgotten := 0
+	var removedIDs []string
+	for _, sc := range diff.streamsRemoved {
+		removedIDs = append(removedIDs, sc.ID)
+	}
+	for _, pc := range diff.patternsRemoved {
+		removedIDs = append(removedIDs, pc.ID)
+	}
+	for _, rc := range diff.replaysRemoved {
+		removedIDs = append(removedIDs, rc.ID)
+	}
+	for _, camID := range removedIDs {
+		for _, dev := range lan.streamsOf(camID) {
+			utils.Logger.Info().Str("cam", dev.ID).Str("action", "forget").Msg("reload")
+			lan.forgetCamera(dev)
+			forgotten++
+		}
+	}
+	for _, cc := range diff.camerasRemoved {
+		camID := cc.ID
+		if len(camID) <= 0 {
+			camID = lan.staticIDs[cc.Address]
+		}
+		delete(lan.staticIDs, cc.Address)
+		for _, dev := range lan.streamsOf(camID) {
+			utils.Logger.Info().Str("cam", dev.ID).Str("url", cc.Address).Str("action", "forget").Msg("reload")
+			lan.forgetCamera(dev)
+			forgotten++
+		}
+	}
+	if diff.mediaMoved && lan.uploads != nil {
+		lan.uploads.SetURL(cfg.UpstreamMedia.Address)
+	}
+	lan.dataLock.Unlock()
+
+	for _, field := range diff.restartOnly {
+		utils.Logger.Warn().Str("field", field).Msg("reload: restart required")
+	}
+	if forgotten > 0 {
+		lan.notifyDevicesChanged()
+	}
+	if diff.nics {
+		if err := lan.applyNics(); err != nil {
+			utils.Logger.Warn().Err(err).Msg("reload")
+		}
+	}
+	if diff.nics || len(diff.camerasAdded)+len(diff.streamsAdded)+len(diff.patternsAdded)+len(diff.replaysAdded) > 0 {
+		lan.triggerRescanAsync(ctx)
+	}
+	return diff
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/jfsmig/cams/go/camera"
+)
+
+func TestReload_Diff(t *testing.T) {
+	old := DefaultConfig()
+	old.AgentID = "agent"
+	old.Cameras = []CameraConfig{
+		{Address: "10.0.0.1", User: "a"},
+		{Address: "10.0.0.2", User: "b"},
+	}
+
+	if diff := diffConfig(old, old); !diff.empty() {
+		t.Fatal("unexpected diff", diff)
+	}
+
+	cfg := old
+	cfg.AgentID = ""
+	cfg.Cameras = []CameraConfig{
+		{Address: "10.0.0.1", User: "a"},
+		{Address: "10.0.0.2", User: "c"},
+		{Address: "10.0.0.3"},
+	}
+	cfg.UpstreamMedia.Address = "10.0.0.100:6000"
+	cfg.Trigger.Enabled = true
+
+	diff := diffConfig(old, cfg)
+	if diff.nics || diff.controlMoved || !diff.mediaMoved {
+		t.Fatal("unexpected diff", diff)
+	}
+	if !reflect.DeepEqual(diff.camerasAdded, cfg.Cameras[1:]) {
+		t.Fatal("unexpected cameras added", diff.camerasAdded)
+	}
+	if !reflect.DeepEqual(diff.camerasRemoved, old.Cameras[1:]) {
+		t.Fatal("unexpected cameras removed", diff.camerasRemoved)
+	}
+	if !reflect.DeepEqual(diff.restartOnly, []string{"trigger"}) {
+		t.Fatal("unexpected restart-only fields", diff.restartOnly)
+	}
+
+	cfg.Interfaces = []string{"eth0"}
+	if diff = diffConfig(old, cfg); !diff.nics {
+		t.Fatal("nics change missed")
+	}
+
+	old.Replays = []ReplayConfig{{ID: "bug", Path: "/tmp/a.tar"}}
+	cfg = old
+	cfg.Replays = []ReplayConfig{{ID: "bug", Path: "/tmp/a.tar", Loop: true}}
+	cfg.Patterns = []PatternConfig{{ID: "demo"}}
+	diff = diffConfig(old, cfg)
+	if diff.empty() || len(diff.replaysAdded) != 1 || len(diff.replaysRemoved) != 1 || len(diff.patternsAdded) != 1 {
+		t.Fatal("unexpected diff", diff)
+	}
+}
+
+func TestReload_Agent(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AgentID = "agent"
+	cfg.Cameras = []CameraConfig{{Address: "10.0.0.1", ID: "cam"}}
+	lan := NewLanAgent(cfg)
+
+	// The streams of the profiles of the camera, seen together
+	for _, name := range []string{"main", "sub"} {
+		src, err := camera.NewPatternSource(camera.ProfileStreamID("cam", camera.StreamProfile{Name: name}), 0, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dev := camera.NewSourceCamera(nil, src)
+		dev.Device = "cam"
+		lan.devices.Add(dev)
+	}
+	if !lan.refreshGeneration("cam", 3) || lan.refreshGeneration("cam/sub", 3) {
+		t.Fatal("unexpected device")
+	}
+	for _, dev := range lan.Cameras() {
+		if dev.GetGeneration() != 3 {
+			t.Fatal("generation not refreshed", dev.ID)
+		}
+	}
+
+	reloaded := DefaultConfig()
+	reloaded.User = "other"
+	reloaded.ScanPeriod = 5
+	diff := lan.Reload(context.Background(), reloaded)
+	if len(diff.camerasRemoved) != 1 {
+		t.Fatal("unexpected diff", diff)
+	}
+	if len(lan.Cameras()) != 0 {
+		t.Fatal("streams not forgotten")
+	}
+
+	running := lan.config()
+	if running.User != cfg.User || running.AgentID != "agent" || running.ScanPeriod != 5 {
+		t.Fatal("unexpected configuration", running)
+	}
+	if len(lan.devicesStatic) != 0 {
+		t.Fatal("static cameras not reloaded")
+	}
+}
+
+func TestReload_MatchInterface(t *testing.T) {
+	patterns := DefaultConfig().DiscoverPatterns
+	for itf, expected := range map[string]bool{"lo": false, "docker0": false, "eth0": true} {
+		if matchInterface(patterns, itf) != expected {
+			t.Fatal("unexpected match", itf)
+		}
+	}
+}
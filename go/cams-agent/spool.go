@@ -0,0 +1,286 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jfsmig/cams/go/api/pb"
+	"github.com/jfsmig/cams/go/camera"
+	"github.com/jfsmig/cams/go/utils"
+	"github.com/juju/errors"
+)
+
+const (
+	// Size beyond which a new segment is started. The oldest segments are dropped
+	// as a whole to keep the spool within its bound.
+	spoolSegmentSize = 4 * 1024 * 1024
+
+	spoolSuffix = ".spool"
+
+	// type, media index, reception time, flags, payload size
+	spoolHeaderSize = 1 + 4 + 8 + 1 + 4
+
+	// Bounds the payload of a record, far beyond any RTP packet or SDP banner
+	spoolMaxPayload = 1024 * 1024
+)
+
+// spoolRecord is a frame of a camera, as buffered on disk
+type spoolRecord struct {
+	frameType pb.DownstreamMediaFrameType
+	info      camera.PacketInfo
+	payload   []byte
+}
+
+type spoolSegment struct {
+	seq  uint64
+	size int64
+}
+
+// spool is a bounded FIFO of records, on disk, made of append-only segments.
+// It is written by the streaming sessions of a camera and drained by its forwarder.
+type spool struct {
+	dir      string
+	maxBytes int64
+
+	lock     sync.Mutex
+	segments []spoolSegment
+	total    int64
+
+	// The segment being written, i.e. the last one, if open
+	writer *bufio.Writer
+	file   *os.File
+}
+
+func openSpool(dir string, maxBytes int64) (*spool, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, errors.Annotate(err, "mkdir")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Annotate(err, "list")
+	}
+
+	s := &spool{dir: dir, maxBytes: maxBytes}
+	for _, entry := range entries {
+		var seq uint64
+		if !strings.HasSuffix(entry.Name(), spoolSuffix) {
+			continue
+		}
+		if _, err := fmt.Sscanf(entry.Name(), "%016x"+spoolSuffix, &seq); err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, errors.Annotate(err, "stat")
+		}
+		s.segments = append(s.segments, spoolSegment{seq: seq, size: info.Size()})
+		s.total += info.Size()
+	}
+	sort.Slice(s.segments, func(i, j int) bool { return s.segments[i].seq < s.segments[j].seq })
+	return s, nil
+}
+
+func (s *spool) path(seq uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%016x", seq)+spoolSuffix)
+}
+
+func (s *spool) Empty() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.total <= 0
+}
+
+func (s *spool) Size() int64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.total
+}
+
+// Append buffers a record at the end of the spool, possibly dropping the oldest
+// segments to remain within the bound.
+func (s *spool) Append(rec spoolRecord) error {
+	if len(rec.payload) > spoolMaxPayload {
+		return errors.New("record too large")
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.file == nil || s.segments[len(s.segments)-1].size >= spoolSegmentSize {
+		if err := s.rotate(); err != nil {
+			return errors.Annotate(err, "rotate")
+		}
+	}
+
+	var hdr [spoolHeaderSize]byte
+	hdr[0] = uint8(rec.frameType)
+	binary.BigEndian.PutUint32(hdr[1:], rec.info.MediaIndex)
+	binary.BigEndian.PutUint64(hdr[5:], uint64(rec.info.ReceivedAt.UnixMicro()))
+	if rec.info.Keyframe {
+		hdr[13] = 1
+	}
+	binary.BigEndian.PutUint32(hdr[14:], uint32(len(rec.payload)))
+	if _, err := s.writer.Write(hdr[:]); err != nil {
+		return errors.Annotate(err, "write")
+	}
+	if _, err := s.writer.Write(rec.payload); err != nil {
+		return errors.Annotate(err, "write")
+	}
+
+	size := int64(spoolHeaderSize + len(rec.payload))
+	s.segments[len(s.segments)-1].size += size
+	s.total += size
+
+	for s.total > s.maxBytes && len(s.segments) > 1 {
+		s.dropOldest()
+	}
+	return nil
+}
+
+// rotate seals the segment being written and starts a new one
+func (s *spool) rotate() error {
+	if err := s.seal(); err != nil {
+		return err
+	}
+	seq := uint64(0)
+	if len(s.segments) > 0 {
+		seq = s.segments[len(s.segments)-1].seq + 1
+	}
+	f, err := os.OpenFile(s.path(seq), os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	s.file = f
+	s.writer = bufio.NewWriter(f)
+	s.segments = append(s.segments, spoolSegment{seq: seq})
+	return nil
+}
+
+func (s *spool) seal() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.writer.Flush()
+	if err2 := s.file.Close(); err == nil {
+		err = err2
+	}
+	s.file, s.writer = nil, nil
+	return err
+}
+
+func (s *spool) dropOldest() {
+	oldest := s.segments[0]
+	s.segments = s.segments[1:]
+	s.total -= oldest.size
+	_ = os.Remove(s.path(oldest.seq))
+}
+
+// Next seals and returns the oldest segment to be forwarded, if any
+func (s *spool) Next() (uint64, bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.total <= 0 {
+		return 0, false, nil
+	}
+	if len(s.segments) == 1 && s.file != nil {
+		if err := s.seal(); err != nil {
+			return 0, false, errors.Annotate(err, "seal")
+		}
+	}
+	return s.segments[0].seq, true, nil
+}
+
+// Release forgets a segment that has been forwarded
+func (s *spool) Release(seq uint64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for i, seg := range s.segments {
+		if seg.seq == seq {
+			if s.file != nil && i == len(s.segments)-1 {
+				return // still being written
+			}
+			s.segments = append(s.segments[:i], s.segments[i+1:]...)
+			s.total -= seg.size
+			_ = os.Remove(s.path(seq))
+			return
+		}
+	}
+}
+
+// Read decodes the records of a segment. A truncated or corrupted record, e.g.
+// after a crash, ends the segment.
+func (s *spool) Read(seq uint64, fn func(rec spoolRecord) error) error {
+	f, err := os.Open(s.path(seq))
+	if os.IsNotExist(err) {
+		return nil // dropped in the meantime
+	}
+	if err != nil {
+		return errors.Annotate(err, "open")
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return errors.Annotate(err, "stat")
+	}
+	remaining := info.Size()
+
+	r := bufio.NewReader(f)
+	for {
+		var hdr [spoolHeaderSize]byte
+		if _, err = io.ReadFull(r, hdr[:]); err != nil {
+			break
+		}
+		remaining -= spoolHeaderSize
+		size := binary.BigEndian.Uint32(hdr[14:])
+		if size > spoolMaxPayload || int64(size) > remaining {
+			utils.Logger.Warn().Uint64("seq", seq).Uint32("size", size).Str("action", "truncate").Msg("spool")
+			return nil
+		}
+		remaining -= int64(size)
+		rec := spoolRecord{
+			frameType: pb.DownstreamMediaFrameType(hdr[0]),
+			info: camera.PacketInfo{
+				MediaIndex: binary.BigEndian.Uint32(hdr[1:]),
+				ReceivedAt: time.UnixMicro(int64(binary.BigEndian.Uint64(hdr[5:]))),
+				Keyframe:   hdr[13] != 0,
+			},
+			payload: make([]byte, size),
+		}
+		if _, err = io.ReadFull(r, rec.payload); err != nil {
+			break
+		}
+		if err = fn(rec); err != nil {
+			return err
+		}
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return nil
+	}
+	return errors.Annotate(err, "read")
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/binary"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jfsmig/cams/go/api/pb"
+	"github.com/jfsmig/cams/go/camera"
+)
+
+func rtpRecord(i int, size int) spoolRecord {
+	payload := make([]byte, size)
+	payload[0] = byte(i)
+	return spoolRecord{
+		frameType: pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_RTP,
+		info:      camera.PacketInfo{MediaIndex: 1, ReceivedAt: time.UnixMicro(int64(i)), Keyframe: i%2 == 0},
+		payload:   payload,
+	}
+}
+
+func readAll(t *testing.T, s *spool) []spoolRecord {
+	var out []spoolRecord
+	for {
+		seq, ok, err := s.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			return out
+		}
+		err = s.Read(seq, func(rec spoolRecord) error {
+			out = append(out, rec)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Release(seq)
+	}
+}
+
+func TestSpool_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	s, err := openSpool(dir, 1024*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if err = s.Append(rtpRecord(i, 100)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	records := readAll(t, s)
+	if len(records) != 10 {
+		t.Fatal("unexpected records", len(records))
+	}
+	for i, rec := range records {
+		if rec.payload[0] != byte(i) || rec.info.MediaIndex != 1 || rec.info.Keyframe != (i%2 == 0) ||
+			rec.info.ReceivedAt.UnixMicro() != int64(i) || len(rec.payload) != 100 {
+			t.Fatal("unexpected record", i, rec.info)
+		}
+	}
+	if !s.Empty() {
+		t.Fatal("spool not empty", s.Size())
+	}
+}
+
+func TestSpool_Bounded(t *testing.T) {
+	dir := t.TempDir()
+	s, err := openSpool(dir, 3*spoolSegmentSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Write 6 segments worth of records, the oldest are dropped
+	for i := 0; i < 6*64; i++ {
+		if err = s.Append(rtpRecord(i, spoolSegmentSize/64)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if size := s.Size(); size > 3*spoolSegmentSize {
+		t.Fatal("spool out of its bound", size)
+	}
+
+	// The spool survives a restart of the agent
+	if err = s.seal(); err != nil {
+		t.Fatal(err)
+	}
+	s, err = openSpool(dir, 3*spoolSegmentSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := readAll(t, s)
+	if len(records) <= 0 || records[len(records)-1].info.ReceivedAt.UnixMicro() != 6*64-1 {
+		t.Fatal("newest records lost")
+	}
+	if records[0].info.ReceivedAt.UnixMicro() == 0 {
+		t.Fatal("oldest records kept")
+	}
+}
+
+func TestSpool_Corrupted(t *testing.T) {
+	s, err := openSpool(t.TempDir(), 1024*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err = s.Append(rtpRecord(i, 100)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = s.Append(rtpRecord(3, spoolMaxPayload+1)); err == nil {
+		t.Fatal("unexpected success")
+	}
+	seq, _, err := s.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The size of the second record is corrupted, the segment ends after the first
+	f, err := os.OpenFile(s.path(seq), os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], 0xFFFFFFF0)
+	if _, err = f.WriteAt(size[:], spoolHeaderSize+100+14); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+
+	records := readAll(t, s)
+	if len(records) != 1 || records[0].payload[0] != 0 {
+		t.Fatal("unexpected records", len(records))
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/jfsmig/cams/go/camera"
 	"github.com/jfsmig/cams/go/utils"
 	"github.com/juju/errors"
+	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
 )
@@ -31,25 +32,69 @@ import (
 type upstreamCommandType uint32
 
 type upstreamCommand struct {
-	cmdType  upstreamCommandType
-	streamID string
+	cmdType   upstreamCommandType
+	streamID  string
+	requestID string
+
+	// The streams expected to play, for a reconciliation
+	playing []string
+
+	// How long the live media is uploaded after a trigger
+	duration time.Duration
+
+	// What to do, for a PTZ command
+	ptz *pb.PTZCommand
 }
 
 const (
 	upstreamAgent_CommandPlay upstreamCommandType = iota
 	upstreamAgent_CommandStop
+	upstreamAgent_CommandReconcile
+	upstreamAgent_CommandTrigger
+	upstreamAgent_CommandPTZ
+	upstreamAgent_CommandSnapshot
+	upstreamAgent_CommandReload
 )
 
 var (
 	ErrNoSuchCamera = errors.New("no such camera")
 )
 
+// How long a PTZ command may wait for the camera
+const ptzCallTimeout = 5 * time.Second
+
+// How long the description of a camera may take, at its first registration
+const metadataCallTimeout = 5 * time.Second
+
+// How long a snapshot may take, the image is downloaded from the camera
+const snapshotCallTimeout = 10 * time.Second
+
 type upstreamAgent struct {
 	cfg AgentConfig
 	lan *Agent
 
 	control       chan upstreamCommand
 	singletonLock sync.Mutex
+
+	// Reloads the configuration of the agent, on the request of the hub
+	reload func() error
+
+	// Protects cfg, session and state
+	cfgLock sync.Mutex
+
+	// Ends the current connection to the hub, e.g. when the hub moves
+	session context.CancelFunc
+
+	// The state of the connection to the hub, for the local API
+	state upstreamStatus
+}
+
+// upstreamStatus tells how the connection to the hub is doing
+type upstreamStatus struct {
+	Address   string    `json:"address"`
+	Connected bool      `json:"connected"`
+	Since     time.Time `json:"since"`
+	LastError string    `json:"last_error,omitempty"`
 }
 
 func NewUpstreamAgent(cfg AgentConfig) *upstreamAgent {
@@ -78,43 +123,90 @@ func (us *upstreamAgent) Run(ctx context.Context, lan *Agent) {
 	}
 }
 
+func (us *upstreamAgent) config() AgentConfig {
+	us.cfgLock.Lock()
+	defer us.cfgLock.Unlock()
+	return us.cfg
+}
+
+// Reload applies a new configuration, the connection to the hub is only
+// restarted when the hub moved.
+func (us *upstreamAgent) Reload(cfg AgentConfig) {
+	us.cfgLock.Lock()
+	defer us.cfgLock.Unlock()
+
+	moved := us.cfg.UpstreamControl.Address != cfg.UpstreamControl.Address
+	us.cfg = keepRestartOnly(us.cfg, cfg)
+	if moved && us.session != nil {
+		utils.Logger.Info().Str("endpoint", cfg.UpstreamControl.Address).Str("action", "move").Msg("up")
+		us.session()
+	}
+}
+
+// Status returns the state of the connection to the hub
+func (us *upstreamAgent) Status() upstreamStatus {
+	us.cfgLock.Lock()
+	defer us.cfgLock.Unlock()
+	out := us.state
+	out.Address = us.cfg.UpstreamControl.Address
+	return out
+}
+
+func (us *upstreamAgent) setConnected(connected bool, err error) {
+	us.cfgLock.Lock()
+	defer us.cfgLock.Unlock()
+	if connected != us.state.Connected {
+		us.state.Since = time.Now()
+	}
+	us.state.Connected = connected
+	if err != nil {
+		us.state.LastError = err.Error()
+	}
+}
+
 func (us *upstreamAgent) getRegisterPeriod() time.Duration {
-	if us.cfg.RegisterPeriod > 0 {
-		return time.Duration(us.cfg.RegisterPeriod) * time.Second
+	if period := us.config().RegisterPeriod; period > 0 {
+		return time.Duration(period) * time.Second
 	}
 	return 30 * time.Second
 }
 
-func (us *upstreamAgent) runMain(ctx context.Context, cnx *grpc.ClientConn) error {
+// runMain executes the commands of the hub, and queues their replies for the
+// current session: a reply is never sent to the hub in a later session.
+func (us *upstreamAgent) runMain(ctx context.Context, replies chan<- *pb.UpstreamControlReply) error {
 	utils.Logger.Trace().Str("action", "start").Msg("up")
 
-	registrationNext := time.After(0)
-	client := pb.NewRegistrarClient(cnx)
-
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 
-		case <-registrationNext:
-			registrationNext = time.After(us.getRegisterPeriod())
-			ctx2 := metadata.NewOutgoingContext(ctx, metadata.New(map[string]string{
-				utils.KeyUser: us.cfg.User,
-			}))
-			for _, cam := range us.lan.Cameras() {
-				inReq := pb.RegisterRequest{
-					Id: &pb.StreamId{
-						User:   us.cfg.User,
-						Stream: cam.ID,
-					},
-				}
-				if _, err := client.Register(ctx2, &inReq); err != nil {
-					return errors.Annotate(err, "register")
-				}
-			}
 		case cmd := <-us.control:
-			if err := us.onCommand(cmd); err != nil {
-				return errors.Annotate(err, "control command")
+			var call func(context.Context, upstreamCommand) *pb.UpstreamControlReply
+			switch cmd.cmdType {
+			case upstreamAgent_CommandPTZ:
+				call = us.onPTZ
+			case upstreamAgent_CommandSnapshot:
+				call = us.onSnapshot
+			case upstreamAgent_CommandReload:
+				call = us.onReload
+			}
+			if call != nil {
+				// The calls to the camera must not delay the other commands
+				go func() {
+					reply := call(ctx, cmd)
+					select {
+					case <-ctx.Done():
+					case replies <- reply:
+					}
+				}()
+				continue
+			}
+			reply := makeReply(cmd.requestID, us.onCommand(cmd))
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case replies <- reply:
 			}
 		}
 	}
@@ -127,25 +219,123 @@ func (us *upstreamAgent) onCommand(cmd upstreamCommand) error {
 		return us.lan.UpdateStreamExpectation(camID, camera.CamCommandPlay)
 	case upstreamAgent_CommandStop: // From the hub
 		return us.lan.UpdateStreamExpectation(camID, camera.CamCommandPause)
+	case upstreamAgent_CommandReconcile: // From the hub, on each connection
+		us.lan.Reconcile(cmd.playing)
+		return nil
+	case upstreamAgent_CommandTrigger: // From the hub
+		return us.lan.Trigger(camID, cmd.duration)
 	default:
 		return errors.New("BUG: unexpected command")
 	}
 }
 
+// onPTZ steers the camera and tells the outcome
+func (us *upstreamAgent) onPTZ(ctx context.Context, cmd upstreamCommand) *pb.UpstreamControlReply {
+	ctx, cancel := context.WithTimeout(ctx, ptzCallTimeout)
+	defer cancel()
+
+	cam, err := us.lan.Camera(cmd.streamID)
+	if err != nil {
+		return makeReply(cmd.requestID, err)
+	}
+
+	var presets []camera.PTZPreset
+	switch cmd.ptz.GetAction() {
+	case pb.PTZAction_PTZ_ACTION_MOVE:
+		err = cam.PTZContinuousMove(ctx, camera.PTZMove{
+			Pan:     float64(cmd.ptz.Pan),
+			Tilt:    float64(cmd.ptz.Tilt),
+			Zoom:    float64(cmd.ptz.Zoom),
+			Timeout: time.Duration(cmd.ptz.Timeout) * time.Millisecond,
+		})
+	case pb.PTZAction_PTZ_ACTION_STOP:
+		err = cam.PTZStop(ctx)
+	case pb.PTZAction_PTZ_ACTION_GOTO_PRESET:
+		err = cam.PTZGotoPreset(ctx, cmd.ptz.Preset)
+	case pb.PTZAction_PTZ_ACTION_LIST_PRESETS:
+		presets, err = cam.PTZPresets(ctx)
+	default:
+		err = errors.New("unexpected PTZ action")
+	}
+	if err != nil {
+		utils.Logger.Warn().Str("cam", cmd.streamID).Str("ptz", cmd.ptz.GetAction().String()).Err(err).Msg("up ctrl")
+	}
+
+	reply := makeReply(cmd.requestID, err)
+	for _, p := range presets {
+		reply.Presets = append(reply.Presets, &pb.PTZPreset{Token: p.Token, Name: p.Name})
+	}
+	return reply
+}
+
+// onSnapshot takes a JPEG image of the camera
+func (us *upstreamAgent) onSnapshot(ctx context.Context, cmd upstreamCommand) *pb.UpstreamControlReply {
+	ctx, cancel := context.WithTimeout(ctx, snapshotCallTimeout)
+	defer cancel()
+
+	cam, err := us.lan.Camera(cmd.streamID)
+	if err != nil {
+		return makeReply(cmd.requestID, err)
+	}
+	img, taken, err := cam.Snapshot(ctx)
+	if err != nil {
+		utils.Logger.Warn().Str("cam", cmd.streamID).Err(err).Msg("snapshot")
+		return makeReply(cmd.requestID, err)
+	}
+	reply := makeReply(cmd.requestID, nil)
+	reply.Snapshot = img
+	reply.Taken = taken.UnixMilli()
+	return reply
+}
+
+// onReload reloads the configuration of the agent. The reply is lost when the
+// reload moves the agent to another hub.
+func (us *upstreamAgent) onReload(ctx context.Context, cmd upstreamCommand) *pb.UpstreamControlReply {
+	if us.reload == nil {
+		return makeReply(cmd.requestID, errors.New("reload not supported"))
+	}
+	err := us.reload()
+	if err != nil {
+		utils.Logger.Warn().Err(err).Msg("reload")
+	}
+	return makeReply(cmd.requestID, err)
+}
+
+// makeReply builds the reply to a command from the error returned by its execution
+func makeReply(requestID string, err error) *pb.UpstreamControlReply {
+	reply := &pb.UpstreamControlReply{
+		RequestID: requestID,
+		Status:    pb.UpstreamReplyStatus_UPSTREAM_REPLY_STATUS_OK,
+	}
+	if errors.Is(err, ErrNoSuchCamera) {
+		reply.Status = pb.UpstreamReplyStatus_UPSTREAM_REPLY_STATUS_NO_SUCH_CAMERA
+	} else if err != nil {
+		reply.Status = pb.UpstreamReplyStatus_UPSTREAM_REPLY_STATUS_CAMERA_ERROR
+		reply.Message = err.Error()
+	}
+	return reply
+}
+
 // runControl polls the control stream and forward them in the command channel
-// is the upstreamAgent
-func (us *upstreamAgent) runControl(ctx context.Context, cnx *grpc.ClientConn) error {
+// is the upstreamAgent. It also sends back the replies to the commands.
+func (us *upstreamAgent) runControl(ctx context.Context, cnx *grpc.ClientConn, replies <-chan *pb.UpstreamControlReply) error {
 	utils.Logger.Trace().Str("action", "start").Msg("up ctrl")
 
 	client := pb.NewControllerClient(cnx)
 
+	cfg := us.config()
 	ctx = metadata.AppendToOutgoingContext(ctx,
-		utils.KeyUser, us.cfg.User)
+		utils.KeyUser, cfg.User,
+		utils.KeyAgent, cfg.AgentID)
+
+	// The failure of any goroutine of the group must also abort the stream
+	g, ctx := errgroup.WithContext(ctx)
 
 	ctrl, err := client.Control(ctx)
 	if err != nil {
 		return errors.Annotate(err, "control open")
 	}
+	us.setConnected(true, nil)
 
 	defer func() {
 		if err := ctrl.CloseSend(); err != nil {
@@ -153,41 +343,201 @@ func (us *upstreamAgent) runControl(ctx context.Context, cnx *grpc.ClientConn) e
 		}
 	}()
 
-	for {
-		request, err := ctrl.Recv()
-		if err != nil {
-			return errors.Annotate(err, "control recv")
-		}
+	g.Go(func() error {
+		for {
+			request, err := ctrl.Recv()
+			if err != nil {
+				return errors.Annotate(err, "control recv")
+			}
 
-		switch request.Command {
-		case pb.DownstreamCommandType_DOWNSTREAM_COMMAND_TYPE_PLAY:
-			us.control <- upstreamCommand{upstreamAgent_CommandPlay, request.StreamID}
-		case pb.DownstreamCommandType_DOWNSTREAM_COMMAND_TYPE_STOP:
-			us.control <- upstreamCommand{upstreamAgent_CommandStop, request.StreamID}
+			cmd := upstreamCommand{streamID: request.StreamID, requestID: request.RequestID}
+			switch request.Command {
+			case pb.DownstreamCommandType_DOWNSTREAM_COMMAND_TYPE_PLAY:
+				cmd.cmdType = upstreamAgent_CommandPlay
+			case pb.DownstreamCommandType_DOWNSTREAM_COMMAND_TYPE_STOP:
+				cmd.cmdType = upstreamAgent_CommandStop
+			case pb.DownstreamCommandType_DOWNSTREAM_COMMAND_TYPE_RECONCILE:
+				cmd.cmdType = upstreamAgent_CommandReconcile
+				cmd.playing = request.Playing
+			case pb.DownstreamCommandType_DOWNSTREAM_COMMAND_TYPE_TRIGGER:
+				cmd.cmdType = upstreamAgent_CommandTrigger
+				cmd.duration = time.Duration(request.Duration) * time.Second
+			case pb.DownstreamCommandType_DOWNSTREAM_COMMAND_TYPE_PTZ:
+				cmd.cmdType = upstreamAgent_CommandPTZ
+				cmd.ptz = request.Ptz
+			case pb.DownstreamCommandType_DOWNSTREAM_COMMAND_TYPE_SNAPSHOT:
+				cmd.cmdType = upstreamAgent_CommandSnapshot
+			case pb.DownstreamCommandType_DOWNSTREAM_COMMAND_TYPE_RELOAD:
+				cmd.cmdType = upstreamAgent_CommandReload
+			default:
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case us.control <- cmd:
+			}
+		}
+	})
+	g.Go(func() error {
+		sendStatus := func(status camera.Status) error {
+			msg := pb.UpstreamControlMessage{Body: &pb.UpstreamControlMessage_Status{Status: statusToPb(status)}}
+			return errors.Annotate(ctrl.Send(&msg), "control send status")
+		}
+		statusNext := time.After(0)
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case reply := <-replies:
+				msg := pb.UpstreamControlMessage{Body: &pb.UpstreamControlMessage_Reply{Reply: reply}}
+				if err := ctrl.Send(&msg); err != nil {
+					return errors.Annotate(err, "control send")
+				}
+			case status := <-us.lan.Statuses():
+				if err := sendStatus(status); err != nil {
+					return err
+				}
+			case event := <-us.lan.Events():
+				msg := pb.UpstreamControlMessage{Body: &pb.UpstreamControlMessage_Event{Event: eventToPb(event)}}
+				if err := ctrl.Send(&msg); err != nil {
+					return errors.Annotate(err, "control send event")
+				}
+			case <-statusNext:
+				// Periodically report the full status, with up-to-date stats
+				statusNext = time.After(us.getRegisterPeriod())
+				for _, cam := range us.lan.Cameras() {
+					if err := sendStatus(cam.Status()); err != nil {
+						return err
+					}
+				}
+			}
 		}
+	})
+	return g.Wait()
+}
+
+func statusToPb(status camera.Status) *pb.CameraStatus {
+	out := &pb.CameraStatus{
+		StreamID:  status.ID,
+		LastError: status.LastError,
+		Stats: &pb.StreamStats{
+			RtpPackets:  status.Stats.RtpPackets,
+			RtpBytes:    status.Stats.RtpBytes,
+			RtcpPackets: status.Stats.RtcpPackets,
+			Restarts:    status.Stats.Restarts,
+		},
+	}
+	if !status.Stats.LastPacket.IsZero() {
+		out.Stats.LastPacket = status.Stats.LastPacket.UnixMilli()
+	}
+	switch status.State {
+	case camera.CamAgentOff:
+		out.State = pb.CameraState_CAMERA_STATE_OFF
+	case camera.CamAgentIdle:
+		out.State = pb.CameraState_CAMERA_STATE_IDLE
+	case camera.CamAgentPlaying:
+		out.State = pb.CameraState_CAMERA_STATE_PLAYING
+	case camera.CamAgentPausing:
+		out.State = pb.CameraState_CAMERA_STATE_PAUSING
+	case camera.CamAgentResuming:
+		out.State = pb.CameraState_CAMERA_STATE_RESUMING
+	}
+	return out
+}
+
+// cameraMetadata describes the camera for its registration. A camera that
+// cannot be described yet is registered anyway, the next registration will
+// retry.
+func (us *upstreamAgent) cameraMetadata(ctx context.Context, cam *camera.Camera) *pb.CameraMetadata {
+	ctx, cancel := context.WithTimeout(ctx, metadataCallTimeout)
+	defer cancel()
+
+	md, err := cam.Metadata(ctx)
+	if errors.Is(err, camera.ErrMetadataBackoff) {
+		return nil
+	} else if err != nil {
+		utils.Logger.Warn().Str("cam", cam.ID).Err(err).Msg("metadata")
+		return nil
+	}
+	return metadataToPb(md)
+}
+
+func metadataToPb(md camera.Metadata) *pb.CameraMetadata {
+	out := &pb.CameraMetadata{
+		Manufacturer: md.Manufacturer,
+		Model:        md.Model,
+		Firmware:     md.Firmware,
+	}
+	for _, p := range md.Profiles {
+		out.Profiles = append(out.Profiles, &pb.CameraProfile{
+			Token:  p.Token,
+			Name:   p.Name,
+			Codec:  p.Codec,
+			Width:  uint32(p.Width),
+			Height: uint32(p.Height),
+			Fps:    float32(p.FPS),
+		})
+	}
+	return out
+}
+
+func eventToPb(event camera.Event) *pb.CameraEvent {
+	out := &pb.CameraEvent{
+		StreamID: event.CamID,
+		Active:   event.Active,
+		Time:     event.Time.UnixMilli(),
+		Topic:    event.Topic,
+		Source:   event.Source,
 	}
+	switch event.Kind {
+	case camera.EventMotion:
+		out.Kind = pb.CameraEventKind_CAMERA_EVENT_KIND_MOTION
+	case camera.EventTamper:
+		out.Kind = pb.CameraEventKind_CAMERA_EVENT_KIND_TAMPER
+	case camera.EventInput:
+		out.Kind = pb.CameraEventKind_CAMERA_EVENT_KIND_INPUT
+	}
+	return out
 }
 
 func (us *upstreamAgent) reconnectAndRerun(ctx context.Context, lan *Agent) {
-	utils.Logger.Trace().Str("action", "restart").Str("endpoint", us.cfg.UpstreamControl.Address).Msg("up")
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	us.cfgLock.Lock()
+	address := us.cfg.UpstreamControl.Address
+	us.session = cancel
+	us.cfgLock.Unlock()
+
+	utils.Logger.Trace().Str("action", "restart").Str("endpoint", address).Msg("up")
 
-	cnx, err := utils.DialInsecure(ctx, us.cfg.UpstreamControl.Address)
+	cnx, err := utils.DialInsecure(ctx, address)
 	if err != nil {
 		utils.Logger.Error().Err(err).Str("action", "dial").Msg("up")
+		us.setConnected(false, err)
 		return
 	}
 	defer cnx.Close()
 
 	us.lan = lan
+	replies := make(chan *pb.UpstreamControlReply, 16)
 	utils.GroupRun(ctx,
 		func(c context.Context) {
-			if err := us.runControl(c, cnx); err != nil {
+			err := us.runControl(c, cnx, replies)
+			if err != nil {
 				utils.Logger.Warn().Err(err).Msg("upstream control error")
 			}
+			us.setConnected(false, err)
 		},
 		func(c context.Context) {
-			if err := us.runMain(c, cnx); err != nil {
+			if err := us.runMain(c, replies); err != nil {
 				utils.Logger.Warn().Err(err).Msg("upstream error")
 			}
+		},
+		func(c context.Context) {
+			if err := us.runRegistration(c, cnx); err != nil {
+				utils.Logger.Warn().Err(err).Msg("upstream registration error")
+			}
 		})
 }
@@ -0,0 +1,65 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jfsmig/cams/go/api/pb"
+)
+
+func TestUpstreamCtrl_Replies(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AgentID = "agent"
+	us := NewUpstreamAgent(cfg)
+	us.lan = NewLanAgent(cfg)
+
+	// A reload that outlives its session
+	release := make(chan struct{})
+	us.reload = func() error { <-release; return nil }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	replies := make(chan *pb.UpstreamControlReply, 16)
+	done := make(chan struct{})
+	go func() { defer close(done); _ = us.runMain(ctx, replies) }()
+
+	us.control <- upstreamCommand{cmdType: upstreamAgent_CommandPlay, streamID: "cam", requestID: "r1"}
+	if reply := <-replies; reply.RequestID != "r1" || reply.Status != pb.UpstreamReplyStatus_UPSTREAM_REPLY_STATUS_NO_SUCH_CAMERA {
+		t.Fatal("unexpected reply", reply)
+	}
+	us.control <- upstreamCommand{cmdType: upstreamAgent_CommandReload, requestID: "stale"}
+	cancel()
+	<-done
+
+	// The next session never sees the replies of the former one
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	next := make(chan *pb.UpstreamControlReply, 16)
+	go func() { _ = us.runMain(ctx, next) }()
+	close(release)
+
+	us.control <- upstreamCommand{cmdType: upstreamAgent_CommandStop, streamID: "cam", requestID: "r2"}
+	if reply := <-next; reply.RequestID != "r2" {
+		t.Fatal("unexpected reply", reply)
+	}
+	select {
+	case reply := <-next:
+		t.Fatal("unexpected reply", reply)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
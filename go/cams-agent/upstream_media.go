@@ -17,67 +17,377 @@ package main
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	"github.com/jfsmig/cams/go/api/pb"
 	"github.com/jfsmig/cams/go/camera"
 	"github.com/jfsmig/cams/go/utils"
 	"github.com/juju/errors"
-	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
 )
 
+// How many media frames may wait for their upload, per stream. Beyond, the
+// media frames of the stream are dropped while the other streams keep flowing.
+const uploadQueueDepth = 256
+
+var (
+	errUploadClosed = errors.New("upload closed")
+	errUploadBusy   = errors.New("upload busy")
+)
+
+// uploadMux shares a single MediaUpload call among all the cameras of the agent.
+// The call is established upon the first streaming session, and reestablished
+// by the first session after a failure.
+type uploadMux struct {
+	ctx     context.Context
+	user    string
+	agentID string
+	url     string
+
+	lock    sync.Mutex
+	session *uploadSession
+}
+
+// uploadSession is one MediaUpload call. It sends the frames of the streams in
+// a round-robin fashion, one frame per stream with pending frames at a time.
+type uploadSession struct {
+	lock   sync.Mutex
+	queues map[string]*uploadQueue
+	order  []string
+	next   int
+
+	// Numbers the frames of the upload, so that the hub detects the drops
+	sequence uint64
+
+	// The sequence number of the last frame written to the call, per queue
+	sent map[string]uint64
+
+	wakeup chan struct{}
+	done   chan struct{}
+	err    error
+
+	cancel context.CancelFunc
+}
+
+type uploadQueue struct {
+	frames []*pb.DownstreamMediaFrame
+
+	// Set when the last frame queued is the CLOSE of the stream
+	closing bool
+}
+
+// grpcUpstream is a streaming session of a camera, on the upload of the agent
 type grpcUpstream struct {
-	cnx          *grpc.ClientConn
-	uploadClient pb.Uploader_MediaUploadClient
+	session  *uploadSession
+	streamID string
+
+	// Set for the sessions replaying footage buffered by the agent
+	recorded bool
 }
 
-func (gu *grpcUpstream) Close() {
-	_ = gu.cnx.Close()
+func newUploadMux(ctx context.Context, userID, agentID, url string) *uploadMux {
+	return &uploadMux{ctx: ctx, user: userID, agentID: agentID, url: url}
 }
 
-func (gu *grpcUpstream) OnSDP(sdp string) error {
-	frame := &pb.DownstreamMediaFrame{
-		Type:    pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_SDP,
-		Payload: []byte(sdp),
+// Opener returns the function opening a streaming session of the given camera
+func (mux *uploadMux) Opener(camID string) camera.UploadOpenFunc {
+	return func(ctx context.Context) (camera.UpstreamMedia, error) {
+		return mux.openStream(camID, false)
 	}
-	return gu.uploadClient.Send(frame)
 }
 
-func (gu *grpcUpstream) OnRTP(pkt []byte) error {
-	frame := &pb.DownstreamMediaFrame{
-		Type:    pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_RTP,
-		Payload: pkt,
+func (mux *uploadMux) openStream(camID string, recorded bool) (*grpcUpstream, error) {
+	session, err := mux.currentSession()
+	if err != nil {
+		return nil, err
 	}
-	return gu.uploadClient.Send(frame)
+	if err = session.open(camID, recorded); err != nil {
+		return nil, err
+	}
+	return &grpcUpstream{session: session, streamID: camID, recorded: recorded}, nil
 }
 
-func (gu *grpcUpstream) OnRTCP(pkt []byte) error {
-	frame := &pb.DownstreamMediaFrame{
-		Type:    pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_RTCP,
-		Payload: pkt,
+// SetURL moves the upload to another hub. The current call is failed so that the
+// streaming sessions of the cameras reconnect to the new address.
+func (mux *uploadMux) SetURL(url string) {
+	mux.lock.Lock()
+	defer mux.lock.Unlock()
+
+	if url == mux.url {
+		return
+	}
+	mux.url = url
+	if mux.session != nil {
+		_ = mux.session.fail(errors.New("media upstream moved"))
+		mux.session = nil
 	}
-	return gu.uploadClient.Send(frame)
 }
 
-func NewGrpcUploadMaker(userID, camID, url string) camera.UploadOpenFunc {
-	return func(ctx context.Context) (camera.UpstreamMedia, error) {
-		var err error
-		up := &grpcUpstream{}
-		up.cnx, err = utils.DialInsecure(ctx, url)
-		if err != nil {
-			return nil, errors.Annotate(err, "dial")
+func (mux *uploadMux) currentSession() (*uploadSession, error) {
+	mux.lock.Lock()
+	defer mux.lock.Unlock()
+
+	if mux.session != nil && mux.session.alive() {
+		return mux.session, nil
+	}
+
+	cnx, err := utils.DialInsecure(mux.ctx, mux.url)
+	if err != nil {
+		return nil, errors.Annotate(err, "dial")
+	}
+
+	ctx, cancel := context.WithCancel(mux.ctx)
+	ctx = metadata.AppendToOutgoingContext(ctx,
+		utils.KeyUser, mux.user,
+		utils.KeyAgent, mux.agentID)
+	client, err := pb.NewUploaderClient(cnx).MediaUpload(ctx)
+	if err != nil {
+		cancel()
+		_ = cnx.Close()
+		return nil, errors.Annotate(err, "call")
+	}
+
+	session := newUploadSession(cancel)
+	go func() {
+		defer cnx.Close()
+		err := session.run(ctx, client)
+		utils.Logger.Info().Str("action", "close").Err(err).Msg("upload")
+	}()
+	mux.session = session
+	return session, nil
+}
+
+func newUploadSession(cancel context.CancelFunc) *uploadSession {
+	return &uploadSession{
+		queues: make(map[string]*uploadQueue),
+		sent:   make(map[string]uint64),
+		wakeup: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+		cancel: cancel,
+	}
+}
+
+func (s *uploadSession) alive() bool {
+	select {
+	case <-s.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// run sends the queued frames until the call fails
+func (s *uploadSession) run(ctx context.Context, client pb.Uploader_MediaUploadClient) error {
+	for {
+		frame := s.pop()
+		if frame == nil {
+			select {
+			case <-ctx.Done():
+				return s.fail(ctx.Err())
+			case <-s.wakeup:
+				continue
+			}
+		}
+		if err := client.Send(frame); err != nil {
+			return s.fail(err)
 		}
+		s.lock.Lock()
+		s.sent[queueKey(frame.StreamID, frame.Recorded)] = frame.Sequence
+		s.lock.Unlock()
+	}
+}
 
-		client := pb.NewUploaderClient(up.cnx)
-		ctx = metadata.AppendToOutgoingContext(ctx,
-			utils.KeyUser, userID,
-			utils.KeyStream, camID)
-		up.uploadClient, err = client.MediaUpload(ctx)
+// waitSent waits until the frame of the queue with the given sequence number
+// has been written to the call, or the call failed. The frames of a queue are
+// sent in order.
+func (s *uploadSession) waitSent(ctx context.Context, key string, seq uint64) error {
+	for {
+		s.lock.Lock()
+		sent, err := s.sent[key], s.err
+		s.lock.Unlock()
+		if sent >= seq {
+			return nil
+		}
 		if err != nil {
-			up.Close()
-			return nil, errors.Annotate(err, "call")
+			return errors.Annotate(err, "upload")
 		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.done:
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func (s *uploadSession) fail(err error) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.err == nil {
+		s.err = err
+		close(s.done)
+		s.cancel()
+	}
+	return s.err
+}
 
-		return up, nil
+// queueKey tells the queue of a frame: the live and the recorded sessions of a
+// stream have their own queue.
+func queueKey(streamID string, recorded bool) string {
+	if recorded {
+		return streamID + "/recorded"
 	}
+	return streamID
+}
+
+func (s *uploadSession) open(streamID string, recorded bool) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.err != nil {
+		return errors.Annotate(s.err, "upload")
+	}
+
+	key := queueKey(streamID, recorded)
+	q, ok := s.queues[key]
+	if !ok {
+		q = &uploadQueue{}
+		s.queues[key] = q
+		s.order = append(s.order, key)
+	}
+	q.closing = false
+	s.sequence++
+	q.frames = append(q.frames, &pb.DownstreamMediaFrame{
+		Type:     pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_OPEN,
+		StreamID: streamID,
+		Sequence: s.sequence,
+		Recorded: recorded,
+	})
+	s.signal()
+	return nil
+}
+
+// push queues a frame of an open stream. The control frames are always queued.
+// When the queue of the stream is full, the live media frames are dropped while
+// the recorded ones are refused with errUploadBusy, to be retried.
+func (s *uploadSession) push(frame *pb.DownstreamMediaFrame, media bool) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.err != nil {
+		return errors.Annotate(s.err, "upload")
+	}
+
+	q, ok := s.queues[queueKey(frame.StreamID, frame.Recorded)]
+	if !ok || q.closing {
+		return errUploadClosed
+	}
+	if media && frame.Recorded && len(q.frames) >= uploadQueueDepth {
+		return errUploadBusy
+	}
+	s.sequence++
+	frame.Sequence = s.sequence
+	if media && len(q.frames) >= uploadQueueDepth {
+		utils.Logger.Trace().Str("stream", frame.StreamID).Str("action", "drop").Msg("upload")
+		return nil
+	}
+	q.frames = append(q.frames, frame)
+	q.closing = frame.Type == pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_CLOSE
+	s.signal()
+	return nil
+}
+
+// pop returns the next frame to be sent, of the next stream with pending frames
+func (s *uploadSession) pop() *pb.DownstreamMediaFrame {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	n := len(s.order)
+	for i := 0; i < n; i++ {
+		idx := (s.next + i) % n
+		key := s.order[idx]
+		q := s.queues[key]
+		if len(q.frames) <= 0 {
+			continue
+		}
+
+		frame := q.frames[0]
+		q.frames[0] = nil
+		q.frames = q.frames[1:]
+
+		if len(q.frames) <= 0 && q.closing {
+			delete(s.queues, key)
+			s.order = append(s.order[:idx], s.order[idx+1:]...)
+			s.next = idx
+		} else {
+			s.next = idx + 1
+		}
+		if len(s.order) > 0 {
+			s.next %= len(s.order)
+		} else {
+			s.next = 0
+		}
+		return frame
+	}
+	return nil
+}
+
+func (s *uploadSession) signal() {
+	select {
+	case s.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+func (gu *grpcUpstream) Close() {
+	_ = gu.session.push(&pb.DownstreamMediaFrame{
+		Type:     pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_CLOSE,
+		StreamID: gu.streamID,
+		Recorded: gu.recorded,
+	}, false)
+}
+
+// CloseSync closes the session of the stream, and waits until all its frames
+// have been written to the call.
+func (gu *grpcUpstream) CloseSync(ctx context.Context) error {
+	frame := &pb.DownstreamMediaFrame{
+		Type:     pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_CLOSE,
+		StreamID: gu.streamID,
+		Recorded: gu.recorded,
+	}
+	if err := gu.session.push(frame, false); err != nil {
+		return err
+	}
+	return gu.session.waitSent(ctx, queueKey(gu.streamID, gu.recorded), frame.Sequence)
+}
+
+func (gu *grpcUpstream) OnSDP(sdp string) error {
+	return gu.session.push(&pb.DownstreamMediaFrame{
+		Type:     pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_SDP,
+		StreamID: gu.streamID,
+		Recorded: gu.recorded,
+		Payload:  []byte(sdp),
+	}, false)
+}
+
+func (gu *grpcUpstream) OnRTP(info camera.PacketInfo, pkt []byte) error {
+	return gu.session.push(&pb.DownstreamMediaFrame{
+		Type:       pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_RTP,
+		StreamID:   gu.streamID,
+		MediaIndex: info.MediaIndex,
+		ReceivedAt: info.ReceivedAt.UnixMicro(),
+		Keyframe:   info.Keyframe,
+		Recorded:   gu.recorded,
+		Payload:    pkt,
+	}, true)
+}
+
+func (gu *grpcUpstream) OnRTCP(info camera.PacketInfo, pkt []byte) error {
+	return gu.session.push(&pb.DownstreamMediaFrame{
+		Type:       pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_RTCP,
+		StreamID:   gu.streamID,
+		MediaIndex: info.MediaIndex,
+		ReceivedAt: info.ReceivedAt.UnixMicro(),
+		Recorded:   gu.recorded,
+		Payload:    pkt,
+	}, true)
 }
@@ -0,0 +1,96 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/jfsmig/cams/go/api/pb"
+	"github.com/jfsmig/cams/go/camera"
+)
+
+func TestUploadSession_RoundRobin(t *testing.T) {
+	s := newUploadSession(func() {})
+	busy := &grpcUpstream{session: s, streamID: "busy"}
+	quiet := &grpcUpstream{session: s, streamID: "quiet"}
+
+	if err := s.open("busy", false); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if err := busy.OnRTP(camera.PacketInfo{}, []byte{byte(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.open("quiet", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := quiet.OnRTP(camera.PacketInfo{}, []byte{0}); err != nil {
+		t.Fatal(err)
+	}
+	quiet.Close()
+
+	// The quiet stream is served within the first turns, despite the backlog of the busy one
+	var streams []string
+	for frame := s.pop(); frame != nil; frame = s.pop() {
+		streams = append(streams, frame.StreamID)
+	}
+	if len(streams) != 14 {
+		t.Fatal("unexpected frames", streams)
+	}
+	for i, expected := range []string{"busy", "quiet", "busy", "quiet", "busy", "quiet"} {
+		if streams[i] != expected {
+			t.Fatal("unexpected order", streams)
+		}
+	}
+
+	// The closed stream is forgotten
+	if err := quiet.OnRTP(camera.PacketInfo{}, []byte{0}); err != errUploadClosed {
+		t.Fatal("unexpected error", err)
+	}
+}
+
+func TestUploadSession_DropMedia(t *testing.T) {
+	s := newUploadSession(func() {})
+	up := &grpcUpstream{session: s, streamID: "s"}
+	if err := s.open("s", false); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2*uploadQueueDepth; i++ {
+		if err := up.OnRTP(camera.PacketInfo{}, []byte{0}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The control frames are never dropped
+	up.Close()
+
+	count := 0
+	var last *pb.DownstreamMediaFrame
+	for frame := s.pop(); frame != nil; frame = s.pop() {
+		count++
+		last = frame
+	}
+	if count != uploadQueueDepth+1 {
+		t.Fatal("unexpected count", count)
+	}
+	if last.Type != pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_CLOSE {
+		t.Fatal("unexpected last frame", last)
+	}
+	// The dropped frames still consumed a sequence number
+	if last.Sequence != 2*uploadQueueDepth+2 {
+		t.Fatal("unexpected sequence", last.Sequence)
+	}
+}
@@ -0,0 +1,251 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jfsmig/cams/go/api/pb"
+	"github.com/jfsmig/cams/go/camera"
+	"github.com/jfsmig/cams/go/utils"
+	"github.com/juju/errors"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// How long a camera rejected by the hub waits before its registration is sent
+// again, the delay doubles with each rejection.
+const (
+	registrationBackoffMin = 10 * time.Second
+	registrationBackoffMax = 5 * time.Minute
+)
+
+// registration tracks what the hub was told about the cameras of the agent,
+// to only send the changes.
+type registration struct {
+	user     string
+	sequence uint64
+	sent     map[string]*pb.CameraMetadata
+	retry    map[string]rejection
+}
+
+// rejection postpones the registration of a camera rejected by the hub
+type rejection struct {
+	at       time.Time
+	backoff  time.Duration
+	sequence uint64 // of the message that registered the camera again
+}
+
+func newRegistration(user string) *registration {
+	return &registration{
+		user:  user,
+		sent:  make(map[string]*pb.CameraMetadata),
+		retry: make(map[string]rejection),
+	}
+}
+
+func (reg *registration) next(kind pb.RegistrationKind) *pb.RegistrationMessage {
+	reg.sequence++
+	return &pb.RegistrationMessage{Sequence: reg.sequence, Kind: kind}
+}
+
+func (reg *registration) request(streamID string, md *pb.CameraMetadata) *pb.RegisterRequest {
+	return &pb.RegisterRequest{Id: &pb.StreamId{User: reg.user, Stream: streamID}, Metadata: md}
+}
+
+// snapshot describes all the cameras, the known ones are forgotten
+func (reg *registration) snapshot(cams map[string]*pb.CameraMetadata) *pb.RegistrationMessage {
+	msg := reg.next(pb.RegistrationKind_REGISTRATION_KIND_SNAPSHOT)
+	reg.sent = make(map[string]*pb.CameraMetadata)
+	for _, streamID := range sortedKeys(cams) {
+		msg.Added = append(msg.Added, reg.request(streamID, cams[streamID]))
+		reg.sent[streamID] = cams[streamID]
+	}
+	return msg
+}
+
+// delta describes the changes since the previous message, nil if none.
+// A camera that cannot be described anymore keeps its former description.
+// A camera rejected by the hub is added again once its backoff is over.
+func (reg *registration) delta(cams map[string]*pb.CameraMetadata) *pb.RegistrationMessage {
+	now := time.Now()
+	msg := &pb.RegistrationMessage{Kind: pb.RegistrationKind_REGISTRATION_KIND_DELTA}
+	var retried []string
+	for _, streamID := range sortedKeys(cams) {
+		md := cams[streamID]
+		known, ok := reg.sent[streamID]
+		if !ok {
+			if r, rejected := reg.retry[streamID]; rejected {
+				if now.Before(r.at) {
+					continue
+				}
+				retried = append(retried, streamID)
+			}
+			msg.Added = append(msg.Added, reg.request(streamID, md))
+			reg.sent[streamID] = md
+		} else if md != nil && !proto.Equal(md, known) {
+			msg.Updated = append(msg.Updated, reg.request(streamID, md))
+			reg.sent[streamID] = md
+		}
+	}
+	for _, streamID := range sortedKeys(reg.sent) {
+		if _, ok := cams[streamID]; !ok {
+			msg.Removed = append(msg.Removed, streamID)
+			delete(reg.sent, streamID)
+		}
+	}
+	for streamID := range reg.retry {
+		if _, ok := cams[streamID]; !ok {
+			delete(reg.retry, streamID)
+		}
+	}
+	if len(msg.Added)+len(msg.Updated)+len(msg.Removed) <= 0 {
+		return nil
+	}
+	reg.sequence++
+	msg.Sequence = reg.sequence
+	for _, streamID := range retried {
+		r := reg.retry[streamID]
+		r.sequence = msg.Sequence
+		reg.retry[streamID] = r
+	}
+	return msg
+}
+
+// ack forgets the cameras rejected by the hub, so that a later delta sends
+// them again, and resets the backoff of those accepted since.
+func (reg *registration) ack(ack *pb.RegistrationAck) {
+	rejected := make(map[string]bool)
+	for _, r := range ack.Rejected {
+		rejected[r.StreamID] = true
+		delete(reg.sent, r.StreamID)
+		retry := reg.retry[r.StreamID]
+		retry.backoff = min(max(2*retry.backoff, registrationBackoffMin), registrationBackoffMax)
+		retry.at = time.Now().Add(retry.backoff)
+		retry.sequence = 0
+		reg.retry[r.StreamID] = retry
+	}
+	for streamID, r := range reg.retry {
+		if !rejected[streamID] && r.sequence > 0 && r.sequence <= ack.Sequence {
+			delete(reg.retry, streamID)
+		}
+	}
+}
+
+func sortedKeys(m map[string]*pb.CameraMetadata) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// runRegistration keeps the cameras registered on the hub: a snapshot first,
+// then the changes as they are signaled, or else a heartbeat at each period.
+// The cameras rejected by the hub are registered again after a backoff.
+func (us *upstreamAgent) runRegistration(ctx context.Context, cnx *grpc.ClientConn) error {
+	utils.Logger.Trace().Str("action", "start").Msg("up reg")
+
+	cfg := us.config()
+	ctx = metadata.AppendToOutgoingContext(ctx,
+		utils.KeyUser, cfg.User,
+		utils.KeyAgent, cfg.AgentID)
+
+	// The failure of any goroutine of the group must also abort the stream
+	g, ctx := errgroup.WithContext(ctx)
+	acks := make(chan *pb.RegistrationAck)
+
+	sync, err := pb.NewRegistrarClient(cnx).Sync(ctx)
+	if err != nil {
+		return errors.Annotate(err, "registration open")
+	}
+
+	g.Go(func() error {
+		for {
+			ack, err := sync.Recv()
+			if err != nil {
+				return errors.Annotate(err, "registration recv")
+			}
+			for _, r := range ack.Rejected {
+				utils.Logger.Warn().Str("cam", r.StreamID).Str("reason", r.Reason).Uint64("seq", ack.Sequence).Msg("up reg")
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case acks <- ack:
+			}
+		}
+	})
+	g.Go(func() error {
+		reg := newRegistration(cfg.User)
+		if err := sync.Send(reg.snapshot(us.describeCameras(ctx))); err != nil {
+			return errors.Annotate(err, "registration snapshot")
+		}
+
+		heartbeat := time.NewTicker(us.getRegisterPeriod())
+		defer heartbeat.Stop()
+		for {
+			var msg *pb.RegistrationMessage
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case ack := <-acks:
+				reg.ack(ack)
+			case <-us.lan.DevicesChanged():
+				msg = reg.delta(us.describeCameras(ctx))
+			case <-heartbeat.C:
+				// The description of a camera may have improved meanwhile
+				if msg = reg.delta(us.describeCameras(ctx)); msg == nil {
+					msg = reg.next(pb.RegistrationKind_REGISTRATION_KIND_HEARTBEAT)
+				}
+			}
+			if msg == nil {
+				continue
+			}
+			if err := sync.Send(msg); err != nil {
+				return errors.Annotate(err, "registration send")
+			}
+		}
+	})
+	return g.Wait()
+}
+
+// describeCameras queries the cameras all at once, so that the registration
+// waits for one timeout at most. The cameras that failed recently are not
+// queried again before their backoff, see camera.Metadata.
+func (us *upstreamAgent) describeCameras(ctx context.Context) map[string]*pb.CameraMetadata {
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	out := make(map[string]*pb.CameraMetadata)
+	for _, cam := range us.lan.Cameras() {
+		wg.Add(1)
+		go func(cam *camera.Camera) {
+			defer wg.Done()
+			md := us.cameraMetadata(ctx, cam)
+			lock.Lock()
+			defer lock.Unlock()
+			out[cam.ID] = md
+		}(cam)
+	}
+	wg.Wait()
+	return out
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/jfsmig/cams/go/api/pb"
+)
+
+func TestRegistration_Deltas(t *testing.T) {
+	reg := newRegistration("u")
+
+	msg := reg.snapshot(map[string]*pb.CameraMetadata{"a": nil, "b": {Model: "m"}})
+	if msg.Kind != pb.RegistrationKind_REGISTRATION_KIND_SNAPSHOT || len(msg.Added) != 2 || msg.Sequence != 1 {
+		t.Fatal("unexpected snapshot", msg)
+	}
+	if msg.Added[0].Id.User != "u" || msg.Added[0].Id.Stream != "a" {
+		t.Fatal("unexpected registration", msg.Added[0])
+	}
+
+	// Nothing changed, and an undescribed camera keeps its description
+	if msg = reg.delta(map[string]*pb.CameraMetadata{"a": nil, "b": nil}); msg != nil {
+		t.Fatal("unexpected delta", msg)
+	}
+
+	msg = reg.delta(map[string]*pb.CameraMetadata{"a": {Model: "n"}, "c": nil})
+	if msg == nil || msg.Kind != pb.RegistrationKind_REGISTRATION_KIND_DELTA || msg.Sequence != 2 {
+		t.Fatal("unexpected delta", msg)
+	}
+	if len(msg.Added) != 1 || msg.Added[0].Id.Stream != "c" {
+		t.Fatal("unexpected additions", msg.Added)
+	}
+	if len(msg.Updated) != 1 || msg.Updated[0].Metadata.Model != "n" {
+		t.Fatal("unexpected updates", msg.Updated)
+	}
+	if len(msg.Removed) != 1 || msg.Removed[0] != "b" {
+		t.Fatal("unexpected removals", msg.Removed)
+	}
+
+	if msg = reg.next(pb.RegistrationKind_REGISTRATION_KIND_HEARTBEAT); msg.Sequence != 3 {
+		t.Fatal("unexpected heartbeat", msg)
+	}
+}
+
+func TestRegistration_Rejected(t *testing.T) {
+	reg := newRegistration("u")
+	cams := map[string]*pb.CameraMetadata{"a": nil, "b": nil}
+
+	reg.snapshot(cams)
+	reg.ack(&pb.RegistrationAck{Sequence: 1, Rejected: []*pb.RegistrationRejection{{StreamID: "b", Reason: "x"}}})
+	if r := reg.retry["b"]; r.backoff != registrationBackoffMin {
+		t.Fatal("unexpected backoff", r.backoff)
+	}
+
+	// Not sent again before the backoff
+	if msg := reg.delta(cams); msg != nil {
+		t.Fatal("unexpected delta", msg)
+	}
+
+	reg.retry["b"] = rejection{backoff: registrationBackoffMin}
+	msg := reg.delta(cams)
+	if msg == nil || len(msg.Added) != 1 || msg.Added[0].Id.Stream != "b" {
+		t.Fatal("unexpected delta", msg)
+	}
+
+	// Rejected again, the backoff grows
+	reg.ack(&pb.RegistrationAck{Sequence: msg.Sequence, Rejected: []*pb.RegistrationRejection{{StreamID: "b", Reason: "x"}}})
+	if r := reg.retry["b"]; r.backoff != 2*registrationBackoffMin {
+		t.Fatal("unexpected backoff", r.backoff)
+	}
+
+	// Accepted at last, the camera is known and its backoff forgotten
+	reg.retry["b"] = rejection{backoff: 2 * registrationBackoffMin}
+	msg = reg.delta(cams)
+	if msg == nil || len(msg.Added) != 1 || msg.Added[0].Id.Stream != "b" {
+		t.Fatal("unexpected delta", msg)
+	}
+	reg.ack(&pb.RegistrationAck{Sequence: msg.Sequence})
+	if _, ok := reg.retry["b"]; ok {
+		t.Fatal("unexpected backoff")
+	}
+	if msg = reg.delta(cams); msg != nil {
+		t.Fatal("unexpected delta", msg)
+	}
+}
@@ -0,0 +1,258 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/jfsmig/cams/go/api/pb"
+	"github.com/jfsmig/cams/go/camera"
+	"github.com/jfsmig/cams/go/utils"
+	"github.com/juju/errors"
+)
+
+const (
+	// How often a camera recording to its spool tries to go live again
+	spoolRetryPeriod = 5 * time.Second
+
+	// How often the forwarder checks for buffered media
+	spoolPollPeriod = time.Second
+)
+
+// cameraSpool buffers on disk the media of a camera while the hub is
+// unreachable, and forwards them as recorded footage once it is back.
+type cameraSpool struct {
+	camID string
+	mux   *uploadMux
+	spool *spool
+
+	// Bytes per second
+	catchUpRate int64
+}
+
+// spooledUpstream is a streaming session of a camera that never fails because
+// of the uplink: it goes live when possible and falls back to the spool.
+type spooledUpstream struct {
+	cs   *cameraSpool
+	live camera.UpstreamMedia
+
+	// The banner of the session, replayed when going live again or
+	// when switching to the spool.
+	sdp     string
+	lastTry time.Time
+}
+
+func newCameraSpool(cfg SpoolConfig, mux *uploadMux, camID string) (*cameraSpool, error) {
+	s, err := openSpool(filepath.Join(cfg.Dir, camID), cfg.MaxBytes)
+	if err != nil {
+		return nil, errors.Annotate(err, "spool")
+	}
+	return &cameraSpool{camID: camID, mux: mux, spool: s, catchUpRate: cfg.CatchUpRate}, nil
+}
+
+// Opener returns the function opening a streaming session of the camera
+func (cs *cameraSpool) Opener() camera.UploadOpenFunc {
+	return func(ctx context.Context) (camera.UpstreamMedia, error) {
+		up := &spooledUpstream{cs: cs, lastTry: time.Now()}
+		if live, err := cs.mux.openStream(cs.camID, false); err != nil {
+			utils.Logger.Info().Str("cam", cs.camID).Err(err).Str("action", "spool").Msg("upload")
+		} else {
+			up.live = live
+		}
+		return up, nil
+	}
+}
+
+func (up *spooledUpstream) Close() {
+	if up.live != nil {
+		up.live.Close()
+	}
+}
+
+func (up *spooledUpstream) OnSDP(sdp string) error {
+	up.sdp = sdp
+	if up.live != nil {
+		if err := up.live.OnSDP(sdp); err == nil {
+			return nil
+		}
+		up.dropLive()
+		return nil
+	}
+	return up.cs.spool.Append(spoolRecord{
+		frameType: pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_SDP,
+		payload:   []byte(sdp),
+	})
+}
+
+func (up *spooledUpstream) OnRTP(info camera.PacketInfo, pkt []byte) error {
+	return up.onPacket(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_RTP, info, pkt)
+}
+
+func (up *spooledUpstream) OnRTCP(info camera.PacketInfo, pkt []byte) error {
+	return up.onPacket(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_RTCP, info, pkt)
+}
+
+func (up *spooledUpstream) onPacket(frameType pb.DownstreamMediaFrameType, info camera.PacketInfo, pkt []byte) error {
+	if up.live == nil {
+		up.retryLive()
+	}
+	if up.live != nil {
+		var err error
+		if frameType == pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_RTP {
+			err = up.live.OnRTP(info, pkt)
+		} else {
+			err = up.live.OnRTCP(info, pkt)
+		}
+		if err == nil {
+			return nil
+		}
+		up.dropLive()
+	}
+	return up.cs.spool.Append(spoolRecord{frameType: frameType, info: info, payload: pkt})
+}
+
+// dropLive switches the session to the spool, that starts with the banner of the session
+func (up *spooledUpstream) dropLive() {
+	utils.Logger.Info().Str("cam", up.cs.camID).Str("action", "spool").Msg("upload")
+	up.live.Close()
+	up.live = nil
+	up.lastTry = time.Now()
+	if len(up.sdp) > 0 {
+		_ = up.cs.spool.Append(spoolRecord{
+			frameType: pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_SDP,
+			payload:   []byte(up.sdp),
+		})
+	}
+}
+
+func (up *spooledUpstream) retryLive() {
+	if time.Since(up.lastTry) < spoolRetryPeriod {
+		return
+	}
+	up.lastTry = time.Now()
+
+	live, err := up.cs.mux.openStream(up.cs.camID, false)
+	if err != nil {
+		return
+	}
+	if len(up.sdp) > 0 {
+		if err = live.OnSDP(up.sdp); err != nil {
+			live.Close()
+			return
+		}
+	}
+	utils.Logger.Info().Str("cam", up.cs.camID).Str("action", "live").Msg("upload")
+	up.live = live
+}
+
+// Run forwards the buffered media as recorded footage, at the catch-up rate
+func (cs *cameraSpool) Run(ctx context.Context) {
+	for ctx.Err() == nil {
+		seq, ok, err := cs.spool.Next()
+		if err != nil {
+			utils.Logger.Warn().Str("cam", cs.camID).Err(err).Msg("spool")
+		}
+		if !ok || err != nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(spoolPollPeriod):
+			}
+			continue
+		}
+
+		if err = cs.forward(ctx, seq); err != nil {
+			utils.Logger.Info().Str("cam", cs.camID).Err(err).Str("action", "forward").Msg("spool")
+			select {
+			case <-ctx.Done():
+			case <-time.After(spoolRetryPeriod):
+			}
+			continue
+		}
+		cs.spool.Release(seq)
+	}
+}
+
+// forward uploads a whole segment. It only succeeds once the segment has been
+// written to the upload, a segment partially uploaded is uploaded again from its
+// beginning.
+func (cs *cameraSpool) forward(ctx context.Context, seq uint64) error {
+	up, err := cs.mux.openStream(cs.camID, true)
+	if err != nil {
+		return err
+	}
+
+	pacer := newPacer(cs.catchUpRate)
+	err = cs.spool.Read(seq, func(rec spoolRecord) error {
+		if err := pacer.wait(ctx, len(rec.payload)); err != nil {
+			return err
+		}
+		for {
+			var err error
+			switch rec.frameType {
+			case pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_SDP:
+				err = up.OnSDP(string(rec.payload))
+			case pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_RTP:
+				err = up.OnRTP(rec.info, rec.payload)
+			case pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_RTCP:
+				err = up.OnRTCP(rec.info, rec.payload)
+			}
+			if err != errUploadBusy {
+				return err
+			}
+			if err = pacer.sleep(ctx, 10*time.Millisecond); err != nil {
+				return err
+			}
+		}
+	})
+	if err != nil {
+		up.Close()
+		return err
+	}
+	return up.CloseSync(ctx)
+}
+
+// pacer spaces the uploads to respect a rate in bytes per second
+type pacer struct {
+	rate int64
+	debt time.Duration
+}
+
+func newPacer(rate int64) *pacer { return &pacer{rate: rate} }
+
+func (p *pacer) wait(ctx context.Context, size int) error {
+	if p.rate <= 0 {
+		return nil
+	}
+	p.debt += time.Duration(int64(size) * int64(time.Second) / p.rate)
+	// Sleep by chunks, the timers are too coarse for a single packet
+	if p.debt < 10*time.Millisecond {
+		return nil
+	}
+	d := p.debt
+	p.debt = 0
+	return p.sleep(ctx, d)
+}
+
+func (p *pacer) sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jfsmig/cams/go/api/pb"
+	"github.com/juju/errors"
+	"google.golang.org/grpc"
+)
+
+// sentFrames mimics the MediaUpload call, and records the frames written to it
+type sentFrames struct {
+	grpc.ClientStream
+	lock   sync.Mutex
+	frames []*pb.DownstreamMediaFrame
+}
+
+func (c *sentFrames) Send(frame *pb.DownstreamMediaFrame) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.frames = append(c.frames, frame)
+	return nil
+}
+
+func (c *sentFrames) CloseAndRecv() (*pb.None, error) { return &pb.None{}, nil }
+
+func (c *sentFrames) count() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.frames)
+}
+
+func TestCameraSpool_ReleaseSent(t *testing.T) {
+	s, err := openSpool(t.TempDir(), 1024*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if err = s.Append(rtpRecord(i, 100)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mux := newUploadMux(context.Background(), "u", "agent", "")
+	cs := &cameraSpool{camID: "cam", mux: mux, spool: s}
+
+	// The upload fails while the segment is queued, before it is sent
+	session := newUploadSession(func() {})
+	mux.session = session
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() { defer close(done); cs.Run(ctx) }()
+	for queued := false; !queued; time.Sleep(10 * time.Millisecond) {
+		session.lock.Lock()
+		q, ok := session.queues[queueKey("cam", true)]
+		queued = ok && q.closing
+		session.lock.Unlock()
+	}
+	_ = session.fail(errors.New("connection reset"))
+	cancel()
+	<-done
+	if s.Empty() {
+		t.Fatal("segment released before its upload")
+	}
+
+	// The segment is uploaded again, then released
+	session = newUploadSession(func() {})
+	mux.session = session
+	client := &sentFrames{}
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = session.run(ctx, client) }()
+	go cs.Run(ctx)
+	deadline := time.Now().Add(2 * time.Second)
+	for !s.Empty() {
+		if time.Now().After(deadline) {
+			t.Fatal("segment not released")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// OPEN, the records, CLOSE
+	if n := client.count(); n != 12 {
+		t.Fatal("unexpected frames", n)
+	}
+}
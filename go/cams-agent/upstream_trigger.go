@@ -0,0 +1,272 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/jfsmig/cams/go/api/pb"
+	"github.com/jfsmig/cams/go/camera"
+	"github.com/jfsmig/cams/go/utils"
+)
+
+// How often a triggered camera retries to go live after a failure
+const triggerRetryPeriod = time.Second
+
+// cameraTrigger keeps the camera streaming locally into a rolling buffer, and
+// only uploads when triggered: the buffer first, then the live media until the
+// trigger expires. A camera played by the hub is pinned live.
+type cameraTrigger struct {
+	camID string
+	inner camera.UploadOpenFunc
+
+	preEvent  time.Duration
+	postEvent time.Duration
+	maxBytes  int
+
+	lock     sync.Mutex
+	deadline time.Time
+	pinned   bool
+
+	now func() time.Time
+}
+
+// triggeredUpstream is a streaming session of a triggered camera
+type triggeredUpstream struct {
+	ct  *cameraTrigger
+	ctx context.Context
+
+	sdp string
+
+	// The rolling buffer, that always starts with a keyframe. The keyframes
+	// locate the first packet of each keyframe access unit, e.g. SPS+PPS+IDR.
+	buffer    []bufferedFrame
+	keyframes []int
+	bytes     int
+
+	// The RTP timestamp of the latest keyframe access unit
+	keyUnit   uint32
+	inKeyUnit bool
+
+	// The upload, while triggered
+	inner   camera.UpstreamMedia
+	lastTry time.Time
+}
+
+type bufferedFrame struct {
+	frameType pb.DownstreamMediaFrameType
+	info      camera.PacketInfo
+	payload   []byte
+}
+
+func newCameraTrigger(cfg TriggerConfig, inner camera.UploadOpenFunc, camID string) *cameraTrigger {
+	return &cameraTrigger{
+		camID:     camID,
+		inner:     inner,
+		preEvent:  cfg.GetPreEvent(),
+		postEvent: cfg.GetPostEvent(),
+		maxBytes:  cfg.MaxBytes,
+		now:       time.Now,
+	}
+}
+
+// Fire uploads the buffer then the live media for the given duration, or the
+// configured default if zero. Firing an active trigger extends it.
+func (ct *cameraTrigger) Fire(d time.Duration) {
+	if d <= 0 {
+		d = ct.postEvent
+	}
+	ct.lock.Lock()
+	defer ct.lock.Unlock()
+	if until := ct.now().Add(d); until.After(ct.deadline) {
+		ct.deadline = until
+	}
+}
+
+// Pin keeps the camera live whatever the triggers
+func (ct *cameraTrigger) Pin(pinned bool) {
+	ct.lock.Lock()
+	defer ct.lock.Unlock()
+	ct.pinned = pinned
+}
+
+func (ct *cameraTrigger) active() bool {
+	ct.lock.Lock()
+	defer ct.lock.Unlock()
+	return ct.pinned || ct.now().Before(ct.deadline)
+}
+
+// Opener returns the function opening a streaming session of the camera
+func (ct *cameraTrigger) Opener() camera.UploadOpenFunc {
+	return func(ctx context.Context) (camera.UpstreamMedia, error) {
+		return &triggeredUpstream{ct: ct, ctx: ctx}, nil
+	}
+}
+
+func (up *triggeredUpstream) Close() {
+	if up.inner != nil {
+		up.inner.Close()
+		up.inner = nil
+	}
+}
+
+func (up *triggeredUpstream) OnSDP(sdp string) error {
+	up.sdp = sdp
+	if up.inner != nil {
+		if err := up.inner.OnSDP(sdp); err != nil {
+			up.dropLive(err)
+		}
+	}
+	return nil
+}
+
+func (up *triggeredUpstream) OnRTP(info camera.PacketInfo, pkt []byte) error {
+	up.onFrame(bufferedFrame{pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_RTP, info, pkt})
+	return nil
+}
+
+func (up *triggeredUpstream) OnRTCP(info camera.PacketInfo, pkt []byte) error {
+	up.onFrame(bufferedFrame{pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_RTCP, info, pkt})
+	return nil
+}
+
+func (up *triggeredUpstream) onFrame(frame bufferedFrame) {
+	if up.ct.active() {
+		if up.inner == nil {
+			up.goLive()
+		}
+		if up.inner != nil {
+			err := up.send(frame)
+			if err == nil {
+				return
+			}
+			up.dropLive(err)
+		}
+	} else if up.inner != nil {
+		utils.Logger.Info().Str("cam", up.ct.camID).Str("action", "buffer").Msg("trigger")
+		up.inner.Close()
+		up.inner = nil
+	}
+	up.bufferFrame(frame)
+}
+
+// goLive opens the upload and flushes the buffer into it
+func (up *triggeredUpstream) goLive() {
+	now := up.ct.now()
+	if now.Sub(up.lastTry) < triggerRetryPeriod {
+		return
+	}
+	up.lastTry = now
+
+	inner, err := up.ct.inner(up.ctx)
+	if err != nil {
+		utils.Logger.Warn().Str("cam", up.ct.camID).Err(err).Str("action", "live").Msg("trigger")
+		return
+	}
+	utils.Logger.Info().Str("cam", up.ct.camID).Int("buffered", len(up.buffer)).Str("action", "live").Msg("trigger")
+
+	up.inner = inner
+	if len(up.sdp) > 0 {
+		if err = inner.OnSDP(up.sdp); err != nil {
+			up.dropLive(err)
+			return
+		}
+	}
+	for _, frame := range up.buffer {
+		if err = up.send(frame); err != nil {
+			up.dropLive(err)
+			return
+		}
+	}
+	up.resetBuffer()
+}
+
+func (up *triggeredUpstream) dropLive(err error) {
+	utils.Logger.Warn().Str("cam", up.ct.camID).Err(err).Str("action", "drop").Msg("trigger")
+	up.inner.Close()
+	up.inner = nil
+}
+
+func (up *triggeredUpstream) send(frame bufferedFrame) error {
+	if frame.frameType == pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_RTP {
+		return up.inner.OnRTP(frame.info, frame.payload)
+	}
+	return up.inner.OnRTCP(frame.info, frame.payload)
+}
+
+func (up *triggeredUpstream) resetBuffer() {
+	up.buffer = nil
+	up.keyframes = nil
+	up.bytes = 0
+}
+
+// bufferFrame appends a frame to the rolling buffer, then trims the buffer to the
+// shortest window that starts with a keyframe and covers the pre-event duration.
+// The buffer is only cut at the start of a keyframe access unit.
+func (up *triggeredUpstream) bufferFrame(frame bufferedFrame) {
+	isKey := frame.frameType == pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_RTP && up.keyUnitStart(frame)
+	if len(up.buffer) <= 0 && !isKey {
+		return // useless without the keyframe it depends on
+	}
+	if isKey {
+		up.keyframes = append(up.keyframes, len(up.buffer))
+	}
+	up.buffer = append(up.buffer, frame)
+	up.bytes += len(frame.payload)
+
+	horizon := frame.info.ReceivedAt.Add(-up.ct.preEvent)
+	for len(up.keyframes) > 1 && !up.buffer[up.keyframes[1]].info.ReceivedAt.After(horizon) {
+		up.dropHead()
+	}
+	for up.ct.maxBytes > 0 && up.bytes > up.ct.maxBytes {
+		if len(up.keyframes) <= 1 {
+			up.resetBuffer()
+			return
+		}
+		up.dropHead()
+	}
+}
+
+// keyUnitStart tells if the RTP packet starts a keyframe access unit: the
+// packets of the unit share their RTP timestamp, like in keyframeUnit on the hub.
+func (up *triggeredUpstream) keyUnitStart(frame bufferedFrame) bool {
+	if !frame.info.Keyframe {
+		return false
+	}
+	if len(frame.payload) < 8 {
+		return true // no timestamp, the packet is the unit
+	}
+	timestamp := binary.BigEndian.Uint32(frame.payload[4:8])
+	start := !up.inKeyUnit || timestamp != up.keyUnit
+	up.keyUnit, up.inKeyUnit = timestamp, true
+	return start
+}
+
+// dropHead drops the oldest group of pictures of the buffer
+func (up *triggeredUpstream) dropHead() {
+	cut := up.keyframes[1]
+	for _, frame := range up.buffer[:cut] {
+		up.bytes -= len(frame.payload)
+	}
+	up.buffer = append(up.buffer[:0:0], up.buffer[cut:]...)
+	up.keyframes = up.keyframes[1:]
+	for i := range up.keyframes {
+		up.keyframes[i] -= cut
+	}
+}
@@ -0,0 +1,246 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jfsmig/cams/go/camera"
+	"github.com/jfsmig/cams/go/utils"
+	"github.com/pion/rtp"
+)
+
+type recordingUpstream struct {
+	sdp    string
+	frames []byte
+	raw    [][]byte
+	closed bool
+}
+
+func (up *recordingUpstream) Close()                 { up.closed = true }
+func (up *recordingUpstream) OnSDP(sdp string) error { up.sdp = sdp; return nil }
+func (up *recordingUpstream) OnRTCP(camera.PacketInfo, []byte) error {
+	return nil
+}
+func (up *recordingUpstream) OnRTP(info camera.PacketInfo, pkt []byte) error {
+	up.frames = append(up.frames, pkt[0])
+	up.raw = append(up.raw, pkt)
+	return nil
+}
+
+func TestTrigger_PreEventBuffer(t *testing.T) {
+	var opened []*recordingUpstream
+	inner := func(ctx context.Context) (camera.UpstreamMedia, error) {
+		up := &recordingUpstream{}
+		opened = append(opened, up)
+		return up, nil
+	}
+
+	start := time.Unix(1700000000, 0)
+	now := start
+	ct := newCameraTrigger(TriggerConfig{Enabled: true, PreEvent: 3, PostEvent: 5}, inner, "cam")
+	ct.now = func() time.Time { return now }
+
+	up, _ := ct.Opener()(context.Background())
+	_ = up.OnSDP("sdp")
+
+	// One frame per second, with a keyframe every other second
+	push := func(i int) {
+		now = start.Add(time.Duration(i) * time.Second)
+		info := camera.PacketInfo{ReceivedAt: now, Keyframe: i%2 == 0}
+		if err := up.OnRTP(info, []byte{byte(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	push(1) // dropped, no keyframe before
+	for i := 2; i < 10; i++ {
+		push(i)
+	}
+	if len(opened) != 0 {
+		t.Fatal("upload opened before the trigger")
+	}
+
+	// The buffer starts with the latest keyframe that covers the pre-event window
+	ct.Fire(0)
+	push(10)
+	if len(opened) != 1 {
+		t.Fatal("upload not opened on trigger")
+	}
+	live := opened[0]
+	if live.sdp != "sdp" {
+		t.Fatal("SDP not sent", live.sdp)
+	}
+	if string(live.frames) != string([]byte{6, 7, 8, 9, 10}) {
+		t.Fatal("unexpected frames", live.frames)
+	}
+
+	// Back to buffering once the post-event duration elapsed
+	push(16)
+	if !live.closed {
+		t.Fatal("upload still open after the trigger")
+	}
+	if len(live.frames) != 5 {
+		t.Fatal("frame uploaded after the trigger", live.frames)
+	}
+
+	// A pinned camera stays live
+	ct.Pin(true)
+	push(17)
+	if len(opened) != 2 || string(opened[1].frames) != string([]byte{16, 17}) {
+		t.Fatal("pinned camera not live")
+	}
+}
+
+func TestTrigger_Rules(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Trigger.Enabled = true
+	cfg.Trigger.Rules = []TriggerRule{
+		{Camera: "door", Event: "motion", Duration: 20},
+		{Event: "tamper"},
+	}
+	lan := NewLanAgent(cfg)
+	triggers := map[string]*cameraTrigger{}
+	for _, camID := range []string{"door", "yard"} {
+		triggers[camID] = newCameraTrigger(cfg.Trigger, nil, camID)
+		lan.triggers[camID] = triggers[camID]
+	}
+
+	// Only the active events matching a rule fire
+	lan.onCameraEvent(camera.Event{CamID: "yard", Kind: camera.EventMotion, Active: true})
+	lan.onCameraEvent(camera.Event{CamID: "door", Kind: camera.EventMotion, Active: false})
+	if triggers["door"].active() || triggers["yard"].active() {
+		t.Fatal("unexpected trigger")
+	}
+
+	lan.onCameraEvent(camera.Event{CamID: "door", Kind: camera.EventMotion, Active: true})
+	if !triggers["door"].active() || triggers["yard"].active() {
+		t.Fatal("rule not applied")
+	}
+	if d := time.Until(triggers["door"].deadline); d <= 19*time.Second || d > 20*time.Second {
+		t.Fatal("unexpected duration", d)
+	}
+
+	lan.onCameraEvent(camera.Event{CamID: "yard", Kind: camera.EventTamper, Active: true})
+	if !triggers["yard"].active() {
+		t.Fatal("rule not applied")
+	}
+}
+
+func TestTrigger_KeyframeUnit(t *testing.T) {
+	var live *recordingUpstream
+	inner := func(ctx context.Context) (camera.UpstreamMedia, error) {
+		live = &recordingUpstream{}
+		return live, nil
+	}
+
+	start := time.Unix(1700000000, 0)
+	now := start
+	ct := newCameraTrigger(TriggerConfig{Enabled: true, PreEvent: 3, PostEvent: 5}, inner, "cam")
+	ct.now = func() time.Time { return now }
+	up, _ := ct.Opener()(context.Background())
+
+	// The packets of an access unit share their RTP timestamp
+	push := func(at time.Duration, id byte, keyframe bool) {
+		now = start.Add(at)
+		pkt := rtp.Packet{Header: rtp.Header{Version: 2, Timestamp: uint32(at / time.Millisecond)}, Payload: []byte{id}}
+		raw, err := pkt.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = up.OnRTP(camera.PacketInfo{ReceivedAt: now, Keyframe: keyframe}, raw); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// SPS, PPS and IDR, then the predicted frames
+	for id := byte(1); id <= 3; id++ {
+		push(0, id, true)
+	}
+	for i := 1; i <= 4; i++ {
+		push(time.Duration(i)*time.Second, byte(3+i), false)
+	}
+
+	// The buffer still starts with the whole keyframe unit
+	ct.Fire(0)
+	push(5*time.Second, 8, false)
+	if live == nil || len(live.frames) != 8 {
+		t.Fatal("unexpected upload", live)
+	}
+	var first rtp.Packet
+	if err := first.Unmarshal(live.raw[0]); err != nil || first.Payload[0] != 1 {
+		t.Fatal("buffer not starting with the SPS", first.Payload, err)
+	}
+}
+
+// packetsUpstream records the RTP packets uploaded by a camera goroutine
+type packetsUpstream struct {
+	lock  sync.Mutex
+	infos []camera.PacketInfo
+	pkts  [][]byte
+}
+
+func (up *packetsUpstream) Close()                                 {}
+func (up *packetsUpstream) OnSDP(string) error                     { return nil }
+func (up *packetsUpstream) OnRTCP(camera.PacketInfo, []byte) error { return nil }
+func (up *packetsUpstream) OnRTP(info camera.PacketInfo, pkt []byte) error {
+	up.lock.Lock()
+	defer up.lock.Unlock()
+	up.infos = append(up.infos, info)
+	up.pkts = append(up.pkts, append([]byte{}, pkt...))
+	return nil
+}
+
+func TestTrigger_MJPEG(t *testing.T) {
+	live := &packetsUpstream{}
+	inner := func(ctx context.Context) (camera.UpstreamMedia, error) { return live, nil }
+	ct := newCameraTrigger(TriggerConfig{Enabled: true, PreEvent: 3, PostEvent: 5}, inner, "cam")
+
+	src, err := camera.NewPatternSource("cam", 160, 120, 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cam := camera.NewSourceCamera(ct.Opener(), src)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() { defer close(done); cam.Run(ctx) }()
+	if err = cam.PlayStream(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The M-JPEG images buffered before the trigger are uploaded
+	time.Sleep(300 * time.Millisecond)
+	fired := time.Now()
+	ct.Fire(time.Second)
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+	<-done
+
+	live.lock.Lock()
+	defer live.lock.Unlock()
+	if len(live.infos) <= 0 || !live.infos[0].Keyframe || !live.infos[0].ReceivedAt.Before(fired) {
+		t.Fatal("pre-event footage missing", len(live.infos))
+	}
+	var first rtp.Packet
+	if err = first.Unmarshal(live.pkts[0]); err != nil {
+		t.Fatal(err)
+	}
+	if !utils.IsFrameStartMJPEG(first.Payload) {
+		t.Fatal("buffer not starting with an image")
+	}
+}
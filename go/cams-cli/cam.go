@@ -122,11 +122,11 @@ func (lu *localUpstream) OnSDP(sdp string) error {
 	return lu.writeFile("sdp", []byte(sdp))
 }
 
-func (lu *localUpstream) OnRTP(pkt []byte) error {
+func (lu *localUpstream) OnRTP(info camera.PacketInfo, pkt []byte) error {
 	return lu.writeFile("rtp", pkt)
 }
 
-func (lu *localUpstream) OnRTCP(pkt []byte) error {
+func (lu *localUpstream) OnRTCP(info camera.PacketInfo, pkt []byte) error {
 	return lu.writeFile("rtcp", pkt)
 }
 
@@ -135,15 +135,17 @@ func (lu *localUpstream) writeFile(tag string, payload []byte) error {
 	path := fmt.Sprintf("%06d", idx) + "." + tag
 	sz := int64(len(payload))
 	utils.Logger.Info().Str("path", path).Int64("size", sz).Msg("entry")
+	// The replay paces the packets after their time, PAX keeps its sub-seconds
+	now := time.Now()
 	hdr := tar.Header{
 		Name:       path,
 		Size:       sz,
-		AccessTime: time.Now(),
-		ModTime:    time.Now(),
-		ChangeTime: time.Now(),
+		AccessTime: now,
+		ModTime:    now,
+		ChangeTime: now,
 		Mode:       0644,
 		Typeflag:   tar.TypeReg,
-		Format:     tar.FormatGNU,
+		Format:     tar.FormatPAX,
 	}
 	if err := lu.archive.WriteHeader(&hdr); err != nil {
 		return errors.Annotate(err, "tar header")
@@ -0,0 +1,76 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"sync"
+
+	"github.com/jfsmig/go-bags"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// agentRegistry gathers the established control sessions, at most one per agent.
+// It is shared by the Controller and the Viewer services and is safe for a
+// concurrent use.
+type agentRegistry struct {
+	agents bags.SortedObj[AgentID, *AgentTwin]
+	lock   sync.Mutex
+}
+
+func newAgentRegistry() *agentRegistry {
+	return &agentRegistry{}
+}
+
+func (r *agentRegistry) Get(id AgentID) (*AgentTwin, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.agents.Get(id)
+}
+
+// Attach registers the session of an agent. A previous session of the same agent
+// is evicted and returned, so that the caller can make it exit.
+// A session of an agent already bound to another user is refused.
+func (r *agentRegistry) Attach(agent *AgentTwin) (*AgentTwin, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	previous, ok := r.agents.Get(agent.agentID)
+	if !ok {
+		r.agents.Add(agent)
+		return nil, nil
+	}
+	if previous.user != agent.user {
+		return nil, status.Error(codes.PermissionDenied, "agent running for another user")
+	}
+	r.agents.Remove(agent.agentID)
+	r.agents.Add(agent)
+	return previous, nil
+}
+
+// Detach unregisters the session of an agent, unless it has already been
+// evicted by a newer session.
+func (r *agentRegistry) Detach(agent *AgentTwin) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	current, ok := r.agents.Get(agent.agentID)
+	if !ok || current.sessionID != agent.sessionID {
+		return false
+	}
+	r.agents.Remove(agent.agentID)
+	return true
+}
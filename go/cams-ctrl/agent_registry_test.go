@@ -0,0 +1,69 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAgentRegistry_Takeover(t *testing.T) {
+	r := newAgentRegistry()
+	old := NewAgentTwin("a", "u", nil)
+	if previous, err := r.Attach(old); err != nil || previous != nil {
+		t.Fatal("unexpected attach", previous, err)
+	}
+
+	// A newer session of the same agent evicts the older one
+	young := NewAgentTwin("a", "u", nil)
+	if previous, err := r.Attach(young); err != nil || previous != old {
+		t.Fatal("unexpected takeover", previous, err)
+	}
+
+	// The late exit of the evicted session leaves the newer one in place
+	if r.Detach(old) {
+		t.Fatal("evicted session detached the newer one")
+	}
+	if current, ok := r.Get("a"); !ok || current != young {
+		t.Fatal("newer session not registered")
+	}
+
+	// Another user cannot take the agent over
+	if _, err := r.Attach(NewAgentTwin("a", "other", nil)); status.Code(err) != codes.PermissionDenied {
+		t.Fatal("unexpected error", err)
+	}
+
+	if !r.Detach(young) {
+		t.Fatal("session not detached")
+	}
+}
+
+func TestAgentTwin_CallAfterClose(t *testing.T) {
+	agent := NewAgentTwin("a", "u", nil)
+	agent.Exit()
+	agent.Exit()
+	agent.close()
+
+	// The requests channel is never closed, sending cannot panic
+	for i := 0; i < 3; i++ {
+		if err := agent.Play(context.Background(), "s"); status.Code(err) != codes.Unavailable {
+			t.Fatal("unexpected error", err)
+		}
+	}
+}
@@ -16,52 +16,279 @@
 package main
 
 import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
 	"github.com/jfsmig/cams/go/api/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// How long a command waits for the reply of the agent, when the caller
+// didn't set any deadline.
+const controlReplyTimeout = 5 * time.Second
+
 type CtrlCommandType uint32
 
 type CtrlCommand struct {
-	cmdType  CtrlCommandType
-	streamID string
+	cmdType   CtrlCommandType
+	streamID  string
+	requestID string
+
+	// The streams expected to play, for a reconciliation
+	playing []string
+
+	// How long the live media is uploaded after a trigger, in seconds
+	duration uint32
+
+	// What to do, for a PTZ command
+	ptz *pb.PTZCommand
 }
 
 const (
 	CtrlCommandType_Play CtrlCommandType = iota
 	CtrlCommandType_Stop
-	CtrlCommandType_Exit
+	CtrlCommandType_Reconcile
+	CtrlCommandType_Trigger
+	CtrlCommandType_PTZ
+	CtrlCommandType_Snapshot
+	CtrlCommandType_Reload
 )
 
 type AgentTwin struct {
 	agentID    AgentID
+	user       string
 	downstream pb.Controller_ControlServer
 
+	// Identifies the control session, among the successive sessions of the agent
+	sessionID string
+
 	// Control commands sent to the agents twin by the system
 	requests chan CtrlCommand
+
+	// Closed to ask the session to exit, e.g. when a newer session takes over
+	exit     chan struct{}
+	exitOnce sync.Once
+
+	// Closed when the session ended and stopped consuming the requests
+	done     chan struct{}
+	doneOnce sync.Once
+
+	// Commands sent to the agent and still waiting for a reply
+	pending     map[string]chan *pb.UpstreamControlReply
+	pendingLock sync.Mutex
+
+	// Last status reported by the agent for each of its cameras
+	cameras     map[string]cameraStatusRecord
+	camerasLock sync.Mutex
+}
+
+type cameraStatusRecord struct {
+	status  *pb.CameraStatus
+	updated time.Time
 }
 
-func NewAgentTwin(id AgentID, stream pb.Controller_ControlServer) *AgentTwin {
+func NewAgentTwin(id AgentID, user string, stream pb.Controller_ControlServer) *AgentTwin {
 	agent := AgentTwin{}
 	agent.agentID = id
+	agent.user = user
 	agent.downstream = stream
+	agent.sessionID = uuid.NewString()
 	agent.requests = make(chan CtrlCommand, 1)
+	agent.exit = make(chan struct{})
+	agent.done = make(chan struct{})
+	agent.pending = make(map[string]chan *pb.UpstreamControlReply)
+	agent.cameras = make(map[string]cameraStatusRecord)
 	return &agent
 }
 
-func (agent *AgentTwin) Play(streamID string) error {
-	agent.requests <- CtrlCommand{CtrlCommandType_Play, streamID}
-	return nil
+func (agent *AgentTwin) Play(ctx context.Context, streamID string) error {
+	_, err := agent.call(ctx, CtrlCommand{cmdType: CtrlCommandType_Play, streamID: streamID})
+	return err
+}
+
+func (agent *AgentTwin) Stop(ctx context.Context, streamID string) error {
+	_, err := agent.call(ctx, CtrlCommand{cmdType: CtrlCommandType_Stop, streamID: streamID})
+	return err
 }
 
-func (agent *AgentTwin) Stop(streamID string) error {
-	agent.requests <- CtrlCommand{CtrlCommandType_Stop, streamID}
-	return nil
+// Trigger asks the agent to upload the pre-event buffer of the stream, then
+// its live media for the given duration.
+func (agent *AgentTwin) Trigger(ctx context.Context, streamID string, duration uint32) error {
+	_, err := agent.call(ctx, CtrlCommand{cmdType: CtrlCommandType_Trigger, streamID: streamID, duration: duration})
+	return err
 }
 
+// PTZ asks the agent to steer the camera of the stream. The presets are only
+// returned when listed.
+func (agent *AgentTwin) PTZ(ctx context.Context, streamID string, cmd *pb.PTZCommand) ([]*pb.PTZPreset, error) {
+	reply, err := agent.call(ctx, CtrlCommand{cmdType: CtrlCommandType_PTZ, streamID: streamID, ptz: cmd})
+	if err != nil {
+		return nil, err
+	}
+	return reply.Presets, nil
+}
+
+// Snapshot asks the agent for a JPEG image of the camera of the stream
+func (agent *AgentTwin) Snapshot(ctx context.Context, streamID string) (*pb.StreamSnapshot, error) {
+	reply, err := agent.call(ctx, CtrlCommand{cmdType: CtrlCommandType_Snapshot, streamID: streamID})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.StreamSnapshot{Jpeg: reply.Snapshot, Taken: reply.Taken}, nil
+}
+
+// Reload asks the agent to reload its configuration
+func (agent *AgentTwin) Reload(ctx context.Context) error {
+	_, err := agent.call(ctx, CtrlCommand{cmdType: CtrlCommandType_Reload})
+	return err
+}
+
+// Exit asks the session to end, without waiting for it
 func (agent *AgentTwin) Exit() {
-	agent.requests <- CtrlCommand{CtrlCommandType_Exit, ""}
+	agent.exitOnce.Do(func() { close(agent.exit) })
+}
+
+// close marks the session as ended: the pending and future calls fail
+func (agent *AgentTwin) close() {
+	agent.doneOnce.Do(func() { close(agent.done) })
+	agent.failPending()
 }
 
 func (agent *AgentTwin) PK() AgentID {
 	return agent.agentID
 }
+
+// call queues a command for the agent and waits for its reply
+func (agent *AgentTwin) call(ctx context.Context, cmd CtrlCommand) (*pb.UpstreamControlReply, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, controlReplyTimeout)
+		defer cancel()
+	}
+
+	cmd.requestID = uuid.NewString()
+	reply := make(chan *pb.UpstreamControlReply, 1)
+
+	agent.pendingLock.Lock()
+	agent.pending[cmd.requestID] = reply
+	agent.pendingLock.Unlock()
+	defer agent.forget(cmd.requestID)
+
+	select {
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	case <-agent.done:
+		return nil, status.Error(codes.Unavailable, "agent disconnected")
+	case agent.requests <- cmd:
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	case <-agent.done:
+		return nil, status.Error(codes.Unavailable, "agent disconnected")
+	case r, ok := <-reply:
+		if !ok {
+			return nil, status.Error(codes.Unavailable, "agent disconnected")
+		}
+		return r, replyToError(r)
+	}
+}
+
+func (agent *AgentTwin) forget(requestID string) {
+	agent.pendingLock.Lock()
+	defer agent.pendingLock.Unlock()
+	delete(agent.pending, requestID)
+}
+
+// onReply wakes the caller waiting for the given reply, if any
+func (agent *AgentTwin) onReply(reply *pb.UpstreamControlReply) {
+	agent.pendingLock.Lock()
+	defer agent.pendingLock.Unlock()
+	if ch, ok := agent.pending[reply.RequestID]; ok {
+		ch <- reply
+		delete(agent.pending, reply.RequestID)
+	}
+}
+
+// failPending wakes all the callers still waiting for a reply
+func (agent *AgentTwin) failPending() {
+	agent.pendingLock.Lock()
+	defer agent.pendingLock.Unlock()
+	for id, ch := range agent.pending {
+		close(ch)
+		delete(agent.pending, id)
+	}
+}
+
+func (agent *AgentTwin) onStatus(camStatus *pb.CameraStatus) {
+	agent.camerasLock.Lock()
+	defer agent.camerasLock.Unlock()
+	agent.cameras[camStatus.StreamID] = cameraStatusRecord{status: camStatus, updated: time.Now()}
+}
+
+// CameraStatus returns the last status reported for the given camera
+func (agent *AgentTwin) CameraStatus(streamID string) (cameraStatusRecord, bool) {
+	agent.camerasLock.Lock()
+	defer agent.camerasLock.Unlock()
+	record, ok := agent.cameras[streamID]
+	return record, ok
+}
+
+func (agent *AgentTwin) forwardCommand(cmd CtrlCommand) error {
+	req := pb.DownstreamControlRequest{StreamID: cmd.streamID, RequestID: cmd.requestID}
+	switch cmd.cmdType {
+	case CtrlCommandType_Play:
+		req.Command = pb.DownstreamCommandType_DOWNSTREAM_COMMAND_TYPE_PLAY
+	case CtrlCommandType_Stop:
+		req.Command = pb.DownstreamCommandType_DOWNSTREAM_COMMAND_TYPE_STOP
+	case CtrlCommandType_Reconcile:
+		req.Command = pb.DownstreamCommandType_DOWNSTREAM_COMMAND_TYPE_RECONCILE
+		req.Playing = cmd.playing
+	case CtrlCommandType_Trigger:
+		req.Command = pb.DownstreamCommandType_DOWNSTREAM_COMMAND_TYPE_TRIGGER
+		req.Duration = cmd.duration
+	case CtrlCommandType_PTZ:
+		req.Command = pb.DownstreamCommandType_DOWNSTREAM_COMMAND_TYPE_PTZ
+		req.Ptz = cmd.ptz
+	case CtrlCommandType_Snapshot:
+		req.Command = pb.DownstreamCommandType_DOWNSTREAM_COMMAND_TYPE_SNAPSHOT
+	case CtrlCommandType_Reload:
+		req.Command = pb.DownstreamCommandType_DOWNSTREAM_COMMAND_TYPE_RELOAD
+	}
+	return agent.downstream.Send(&req)
+}
+
+// runUpstream consumes the messages sent by the agent until the stream fails
+func (agent *AgentTwin) runUpstream(onEvent func(*AgentTwin, *pb.CameraEvent)) error {
+	for {
+		msg, err := agent.downstream.Recv()
+		if err != nil {
+			return err
+		}
+		switch body := msg.Body.(type) {
+		case *pb.UpstreamControlMessage_Reply:
+			agent.onReply(body.Reply)
+		case *pb.UpstreamControlMessage_Status:
+			agent.onStatus(body.Status)
+		case *pb.UpstreamControlMessage_Event:
+			onEvent(agent, body.Event)
+		}
+	}
+}
+
+func replyToError(reply *pb.UpstreamControlReply) error {
+	switch reply.Status {
+	case pb.UpstreamReplyStatus_UPSTREAM_REPLY_STATUS_OK:
+		return nil
+	case pb.UpstreamReplyStatus_UPSTREAM_REPLY_STATUS_NO_SUCH_CAMERA:
+		return status.Error(codes.NotFound, "no such camera")
+	case pb.UpstreamReplyStatus_UPSTREAM_REPLY_STATUS_CAMERA_ERROR:
+		return status.Errorf(codes.Unavailable, "camera error: %s", reply.Message)
+	default:
+		return status.Errorf(codes.Internal, "unexpected reply from the agent: %s", reply.Status)
+	}
+}
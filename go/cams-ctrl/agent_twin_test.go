@@ -0,0 +1,102 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jfsmig/cams/go/api/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// replyTo mimics the agent, answering the next command it receives
+func replyTo(agent *AgentTwin, replyStatus pb.UpstreamReplyStatus) {
+	cmd := <-agent.requests
+	agent.onReply(&pb.UpstreamControlReply{RequestID: cmd.requestID, Status: replyStatus})
+}
+
+func countPending(agent *AgentTwin) int {
+	agent.pendingLock.Lock()
+	defer agent.pendingLock.Unlock()
+	return len(agent.pending)
+}
+
+func TestAgentTwin_Correlation(t *testing.T) {
+	agent := NewAgentTwin("a", "u", nil)
+	go func() {
+		cmd := <-agent.requests
+		// A reply to an unknown request is ignored
+		agent.onReply(&pb.UpstreamControlReply{RequestID: "unknown", Status: pb.UpstreamReplyStatus_UPSTREAM_REPLY_STATUS_CAMERA_ERROR})
+		agent.onReply(&pb.UpstreamControlReply{RequestID: cmd.requestID, Status: pb.UpstreamReplyStatus_UPSTREAM_REPLY_STATUS_OK})
+		// ... and so is a duplicate reply
+		agent.onReply(&pb.UpstreamControlReply{RequestID: cmd.requestID, Status: pb.UpstreamReplyStatus_UPSTREAM_REPLY_STATUS_OK})
+	}()
+	if err := agent.Play(context.Background(), "s"); err != nil {
+		t.Fatal(err)
+	}
+
+	go replyTo(agent, pb.UpstreamReplyStatus_UPSTREAM_REPLY_STATUS_NO_SUCH_CAMERA)
+	if err := agent.Stop(context.Background(), "s"); status.Code(err) != codes.NotFound {
+		t.Fatal("unexpected error", err)
+	}
+	if pending := countPending(agent); pending != 0 {
+		t.Fatal("pending calls left", pending)
+	}
+}
+
+func TestAgentTwin_Timeout(t *testing.T) {
+	agent := NewAgentTwin("a", "u", nil)
+
+	// The command is consumed, the agent never replies
+	go func() { <-agent.requests }()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := agent.Play(ctx, "s"); status.Code(err) != codes.DeadlineExceeded {
+		t.Fatal("unexpected error", err)
+	}
+
+	// The call is forgotten, a late reply would be dropped
+	if pending := countPending(agent); pending != 0 {
+		t.Fatal("pending calls left", pending)
+	}
+}
+
+func TestAgentTwin_Reconnect(t *testing.T) {
+	old := NewAgentTwin("a", "u", nil)
+
+	// A call waits for the reply when the session ends
+	called := make(chan error, 1)
+	go func() { called <- old.Play(context.Background(), "s") }()
+	requestID := (<-old.requests).requestID
+	old.close()
+	if err := <-called; status.Code(err) != codes.Unavailable {
+		t.Fatal("unexpected error", err)
+	}
+
+	// The reply that arrives late in the newer session doesn't answer its calls
+	young := NewAgentTwin("a", "u", nil)
+	go func() {
+		cmd := <-young.requests
+		young.onReply(&pb.UpstreamControlReply{RequestID: requestID, Status: pb.UpstreamReplyStatus_UPSTREAM_REPLY_STATUS_CAMERA_ERROR})
+		young.onReply(&pb.UpstreamControlReply{RequestID: cmd.requestID, Status: pb.UpstreamReplyStatus_UPSTREAM_REPLY_STATUS_OK})
+	}()
+	if err := young.Play(context.Background(), "s"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -16,56 +16,100 @@
 package main
 
 import (
+	"github.com/google/uuid"
 	"github.com/jfsmig/cams/go/api/pb"
 	"github.com/jfsmig/cams/go/utils"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
 func (hub *grpcHub) Control(stream pb.Controller_ControlServer) error {
-	md, ok := metadata.FromIncomingContext(stream.Context())
-	if !ok {
-		err := status.Error(codes.InvalidArgument, "missing metadata")
+	user, agentID, err := agentIdentity(stream.Context())
+	if err != nil {
 		utils.Logger.Warn().Str("action", "check").Err(err).Msg("hub ctrl")
 		return err
 	}
-	user := md.Get(utils.KeyUser)[0]
 
-	if hub.agents.Has(AgentID(user)) {
-		err := status.Error(codes.AlreadyExists, "user agents already running")
-		utils.Logger.Warn().Str("user", user).Str("action", "check").Err(err).Msg("hub ctrl")
+	agent := NewAgentTwin(agentID, user, stream)
+	previous, err := hub.agents.Attach(agent)
+	if err != nil {
+		utils.Logger.Warn().Str("user", user).Str("agent", string(agentID)).Str("action", "check").Err(err).Msg("hub ctrl")
 		return err
 	}
+	if previous != nil {
+		// The newer session wins, e.g. the agent reconnected while its
+		// former connection is half-dead.
+		utils.Logger.Info().Str("user", user).Str("agent", string(agentID)).
+			Str("session", previous.sessionID).Str("action", "evict").Msg("hub ctrl")
+		previous.Exit()
+	}
+
+	utils.Logger.Trace().Str("user", user).Str("agent", string(agentID)).Str("session", agent.sessionID).Str("action", "start").Msg("hub ctrl")
 
-	utils.Logger.Trace().Str("user", user).Str("action", "start").Msg("hub ctrl")
+	// Replay the desired state of all the streams of the agent
+	if err := hub.replayDesired(agent, user); err != nil {
+		utils.Logger.Warn().Str("user", user).Str("action", "replay").Err(err).Msg("hub ctrl")
+	}
 
-	agent := NewAgentTwin(AgentID(user), stream)
-	hub.agents.Add(agent)
+	// consume the replies of the agent
+	upstreamDone := make(chan error, 1)
+	go func() { upstreamDone <- agent.runUpstream(hub.onEvent) }()
 
 	// wait for commands from outside, to propagate to the agents
+	var exitErr error
 	for running := true; running; {
 		select {
 		case <-stream.Context().Done():
 			utils.Logger.Info().Str("user", user).Str("action", "shut").Msg("hub ctrl")
 			running = false
+		case err := <-upstreamDone:
+			utils.Logger.Info().Str("user", user).Str("action", "shut").Err(err).Msg("hub ctrl")
+			running = false
+		case <-agent.exit: // evicted by a newer session
+			exitErr = status.Error(codes.Aborted, "session taken over")
+			running = false
 		case cmd := <-agent.requests:
 			switch cmd.cmdType {
-			case CtrlCommandType_Play: // Play a stream
-				stream.Send(&pb.DownstreamControlRequest{Command: pb.DownstreamCommandType_DOWNSTREAM_COMMAND_TYPE_PLAY, StreamID: cmd.streamID})
-			case CtrlCommandType_Stop: // Stop a stream
-				stream.Send(&pb.DownstreamControlRequest{Command: pb.DownstreamCommandType_DOWNSTREAM_COMMAND_TYPE_STOP, StreamID: cmd.streamID})
-			case CtrlCommandType_Exit: // abort the
-				running = false
+			case CtrlCommandType_Play, CtrlCommandType_Stop, CtrlCommandType_Trigger, CtrlCommandType_PTZ, CtrlCommandType_Snapshot, CtrlCommandType_Reload:
+				if err := agent.forwardCommand(cmd); err != nil {
+					utils.Logger.Warn().Str("user", user).Str("action", "send").Err(err).Msg("hub ctrl")
+					running = false
+				}
 			}
 		}
 	}
 
-	// Close the command channel
-	close(agent.requests)
+	// Unregister the AgentTwin, unless a newer session already replaced it
+	hub.agents.Detach(agent)
+
+	// Unblock the callers still waiting for a reply, or trying to send a command
+	agent.close()
 
-	// Unregister the AgentTwin
-	hub.agents.Remove(AgentID(user))
+	return exitErr
+}
+
+// replayDesired sends to the agent the complete set of streams that are expected to play.
+// The reply is not awaited: the agent reports its progress with the status of its cameras.
+func (hub *grpcHub) replayDesired(agent *AgentTwin, user string) error {
+	playing := make([]string, 0)
+	for _, state := range hub.desired.ListByUser(user) {
+		if state.Playing && hub.streamAgent(state) == agent.agentID {
+			playing = append(playing, state.StreamID)
+		}
+	}
+	utils.Logger.Info().Str("user", user).Strs("playing", playing).Str("action", "replay").Msg("hub ctrl")
+	return agent.forwardCommand(CtrlCommand{
+		cmdType:   CtrlCommandType_Reconcile,
+		requestID: uuid.NewString(),
+		playing:   playing,
+	})
+}
 
-	return nil
+// streamAgent tells which agent owns the stream: the agent that registered it
+// last or, before any registration, the agent known when it was desired.
+func (hub *grpcHub) streamAgent(state DesiredState) AgentID {
+	if record, ok := hub.registrar.Get(state.StreamID); ok {
+		return record.Agent
+	}
+	return state.Agent
 }
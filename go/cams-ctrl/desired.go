@@ -0,0 +1,214 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/jfsmig/cams/go/utils"
+	"github.com/jfsmig/go-bags"
+	"github.com/juju/errors"
+)
+
+// DesiredState is what the hub expects from a stream, whatever the
+// connection status of its agent.
+type DesiredState struct {
+	StreamID string `json:"stream"`
+	User     string `json:"user"`
+	Playing  bool   `json:"playing"`
+
+	// The viewer sessions of the stream, with the deadline of their renewal
+	Viewers map[string]time.Time `json:"viewers,omitempty"`
+
+	// When the last viewer left a stream still playing
+	IdleSince time.Time `json:"idle_since,omitempty"`
+
+	// The agent charged with the viewers, i.e. the agent of the stream
+	// when its first viewer arrived.
+	Agent AgentID `json:"agent,omitempty"`
+}
+
+type DesiredStore interface {
+	// Update atomically alters the desired state of a stream. The state before
+	// the alteration is returned. An error returned by alter aborts the update.
+	Update(streamID, user string, alter func(state *DesiredState) error) (DesiredState, error)
+
+	Get(streamID string) (DesiredState, bool)
+
+	List() []DesiredState
+
+	ListByUser(user string) []DesiredState
+}
+
+func (ds *DesiredState) PK() string { return ds.StreamID }
+
+func (ds *DesiredState) HasViewer(viewer string) bool {
+	_, ok := ds.Viewers[viewer]
+	return ok
+}
+
+// SetViewer adds or renews a viewer session, and tells if the viewer is new
+func (ds *DesiredState) SetViewer(viewer string, deadline time.Time) bool {
+	if ds.Viewers == nil {
+		ds.Viewers = make(map[string]time.Time)
+	}
+	_, ok := ds.Viewers[viewer]
+	ds.Viewers[viewer] = deadline
+	return !ok
+}
+
+func (ds *DesiredState) RemoveViewer(viewer string) bool {
+	if _, ok := ds.Viewers[viewer]; !ok {
+		return false
+	}
+	delete(ds.Viewers, viewer)
+	return true
+}
+
+func (ds DesiredState) clone() DesiredState {
+	viewers := make(map[string]time.Time, len(ds.Viewers))
+	for k, v := range ds.Viewers {
+		viewers[k] = v
+	}
+	ds.Viewers = viewers
+	return ds
+}
+
+type desiredStoreInMem struct {
+	states bags.SortedObj[string, *DesiredState]
+	lock   sync.Mutex
+
+	// Where the states are persisted, if not empty
+	path string
+}
+
+func NewDesiredStoreInMem() DesiredStore {
+	return &desiredStoreInMem{}
+}
+
+// NewDesiredStoreFile loads the states persisted at the given path, and
+// then persists there each update.
+func NewDesiredStoreFile(path string) (DesiredStore, error) {
+	store := &desiredStoreInMem{path: path}
+
+	encoded, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, errors.Annotate(err, "read")
+	}
+
+	var states []*DesiredState
+	if err = json.Unmarshal(encoded, &states); err != nil {
+		return nil, errors.Annotate(err, "decode")
+	}
+	store.states.Append(states...)
+	return store, nil
+}
+
+func (s *desiredStoreInMem) Update(streamID, user string, alter func(state *DesiredState) error) (DesiredState, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	before := DesiredState{StreamID: streamID, User: user}
+	if current, ok := s.states.Get(streamID); ok {
+		before = current.clone()
+	}
+
+	after := before.clone()
+	if err := alter(&after); err != nil {
+		return before, err
+	}
+
+	// A stopped stream without viewers is the default, no need to keep it
+	s.states.Remove(streamID)
+	if after.Playing || len(after.Viewers) > 0 {
+		s.states.Add(&after)
+	}
+
+	// The memory is authoritative while the hub runs, a failure to persist
+	// only weakens the recovery after a restart.
+	if err := s.persist(); err != nil {
+		utils.Logger.Warn().Str("path", s.path).Err(err).Msg("desired state persist")
+	}
+	return before, nil
+}
+
+func (s *desiredStoreInMem) Get(streamID string) (DesiredState, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if current, ok := s.states.Get(streamID); ok {
+		return current.clone(), true
+	}
+	return DesiredState{}, false
+}
+
+func (s *desiredStoreInMem) List() []DesiredState {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	out := make([]DesiredState, 0, len(s.states))
+	for _, state := range s.states {
+		out = append(out, state.clone())
+	}
+	return out
+}
+
+func (s *desiredStoreInMem) ListByUser(user string) []DesiredState {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	out := make([]DesiredState, 0)
+	for _, state := range s.states {
+		if state.User == user {
+			out = append(out, state.clone())
+		}
+	}
+	return out
+}
+
+// persist atomically replaces the file with the current states
+func (s *desiredStoreInMem) persist() error {
+	if len(s.path) <= 0 {
+		return nil
+	}
+
+	encoded, err := json.Marshal(s.states)
+	if err != nil {
+		return errors.Annotate(err, "encode")
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return errors.Annotate(err, "create")
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(encoded); err != nil {
+		tmp.Close()
+		return errors.Annotate(err, "write")
+	}
+	if err = tmp.Close(); err != nil {
+		return errors.Annotate(err, "close")
+	}
+	return errors.Annotate(os.Rename(tmp.Name(), s.path), "rename")
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDesired_FileRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	store, err := NewDesiredStoreFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = store.Update("s0", "u", func(state *DesiredState) error {
+		state.Playing = true
+		state.SetViewer("v0", time.Now().Add(time.Minute))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Neither playing nor viewed: not retained
+	_, _ = store.Update("s1", "u", func(state *DesiredState) error { return nil })
+
+	reloaded, err := NewDesiredStoreFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	states := reloaded.ListByUser("u")
+	if len(states) != 1 {
+		t.Fatal("unexpected states", states)
+	}
+	if !states[0].Playing || !states[0].HasViewer("v0") {
+		t.Fatal("unexpected state", states[0])
+	}
+}
+
+var errAborted = errors.New("aborted")
+
+func TestDesired_UpdateAborted(t *testing.T) {
+	store := NewDesiredStoreInMem()
+	_, err := store.Update("s0", "u", func(state *DesiredState) error {
+		state.Playing = true
+		return errAborted
+	})
+	if err != errAborted {
+		t.Fatal("unexpected error", err)
+	}
+	if _, ok := store.Get("s0"); ok {
+		t.Fatal("aborted update applied")
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jfsmig/cams/go/api/pb"
+	"github.com/jfsmig/cams/go/utils"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// How many events are kept for each stream, the oldest are dropped first
+	eventsPerStream = 1024
+
+	// How many events are returned by a listing without explicit limit
+	eventsDefaultLimit = 100
+)
+
+// eventStore keeps the latest events of each stream, in the order of their reception
+type eventStore struct {
+	streams map[string][]*pb.CameraEvent
+	lock    sync.Mutex
+}
+
+func newEventStore() *eventStore {
+	return &eventStore{streams: make(map[string][]*pb.CameraEvent)}
+}
+
+// Add stamps the event with its reception time and stores it
+func (s *eventStore) Add(event *pb.CameraEvent, now time.Time) {
+	event.Received = now.UnixMilli()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	events := append(s.streams[event.StreamID], event)
+	if len(events) > eventsPerStream {
+		events = append(events[:0:0], events[len(events)-eventsPerStream:]...)
+	}
+	s.streams[event.StreamID] = events
+}
+
+// List returns the oldest events of the stream received after since
+func (s *eventStore) List(streamID string, since int64, limit int) []*pb.CameraEvent {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	out := make([]*pb.CameraEvent, 0)
+	for _, event := range s.streams[streamID] {
+		if event.Received > since {
+			out = append(out, proto.Clone(event).(*pb.CameraEvent))
+			if len(out) >= limit {
+				break
+			}
+		}
+	}
+	return out
+}
+
+// onEvent stores an event reported by the agent, if the stream belongs to its user
+func (hub *grpcHub) onEvent(agent *AgentTwin, event *pb.CameraEvent) {
+	record, ok := hub.registrar.Get(event.StreamID)
+	if !ok || record.User != agent.user {
+		utils.Logger.Warn().Str("agent", string(agent.agentID)).Str("stream", event.StreamID).Str("action", "drop").Msg("event")
+		return
+	}
+	utils.Logger.Debug().Str("stream", event.StreamID).Str("kind", event.Kind.String()).Bool("active", event.Active).Msg("event")
+	hub.events.Add(event, time.Now())
+}
+
+// Events lists the events of the stream received after the given time, oldest first
+func (hub *grpcHub) Events(ctx context.Context, req *pb.EventsRequest) (*pb.EventsReply, error) {
+	if _, err := hub.lookupStream(req.Id); err != nil {
+		return nil, err
+	}
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = eventsDefaultLimit
+	} else if limit > eventsPerStream {
+		limit = eventsPerStream
+	}
+	return &pb.EventsReply{Events: hub.events.List(req.Id.Stream, req.Since, limit)}, nil
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jfsmig/cams/go/api/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestEvents_Store(t *testing.T) {
+	hub := newTestHub()
+	if err := hub.registrar.Register(StreamRegistration{StreamID: "s", User: "u", Agent: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	agent := NewAgentTwin("a", "u", nil)
+	intruder := NewAgentTwin("x", "other", nil)
+
+	hub.onEvent(agent, &pb.CameraEvent{StreamID: "s", Kind: pb.CameraEventKind_CAMERA_EVENT_KIND_MOTION, Active: true})
+	// The stream belongs to another user
+	hub.onEvent(intruder, &pb.CameraEvent{StreamID: "s", Kind: pb.CameraEventKind_CAMERA_EVENT_KIND_TAMPER})
+	hub.events.Add(&pb.CameraEvent{StreamID: "s", Kind: pb.CameraEventKind_CAMERA_EVENT_KIND_INPUT}, time.Now().Add(time.Hour))
+
+	reply, err := hub.Events(context.Background(), &pb.EventsRequest{Id: &pb.StreamId{User: "u", Stream: "s"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reply.Events) != 2 || reply.Events[0].Kind != pb.CameraEventKind_CAMERA_EVENT_KIND_MOTION || reply.Events[0].Received <= 0 {
+		t.Fatal("unexpected events", reply.Events)
+	}
+
+	// Only the events received since the given time
+	reply, err = hub.Events(context.Background(), &pb.EventsRequest{Id: &pb.StreamId{User: "u", Stream: "s"}, Since: reply.Events[0].Received})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reply.Events) != 1 || reply.Events[0].Kind != pb.CameraEventKind_CAMERA_EVENT_KIND_INPUT {
+		t.Fatal("unexpected events", reply.Events)
+	}
+
+	_, err = hub.Events(context.Background(), &pb.EventsRequest{Id: &pb.StreamId{User: "other", Stream: "s"}})
+	if status.Code(err) != codes.NotFound {
+		t.Fatal("unexpected error", err)
+	}
+}
+
+func TestEvents_Bounded(t *testing.T) {
+	store := newEventStore()
+	now := time.Now()
+	for i := 0; i < eventsPerStream+10; i++ {
+		store.Add(&pb.CameraEvent{StreamID: "s", Topic: "t"}, now.Add(time.Duration(i)*time.Millisecond))
+	}
+	events := store.List("s", 0, eventsPerStream*2)
+	if len(events) != eventsPerStream || events[0].Received != now.Add(10*time.Millisecond).UnixMilli() {
+		t.Fatal("unexpected events", len(events))
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"math"
+	"time"
+)
+
+// jitterEstimator measures the interarrival jitter of a RTP flow as described
+// in RFC 3550 (section 6.4.1), with the reception times reported by the agent.
+// It is thus the jitter between the camera and the agent, whatever the delays
+// between the agent and the hub.
+type jitterEstimator struct {
+	started     bool
+	lastTS      uint32
+	lastArrival time.Time
+
+	// In units of the RTP clock
+	jitter float64
+}
+
+func (j *jitterEstimator) update(ts uint32, arrival time.Time, clockRate uint32) {
+	if j.started {
+		arrivalDelta := arrival.Sub(j.lastArrival).Seconds() * float64(clockRate)
+		tsDelta := float64(int32(ts - j.lastTS))
+		j.jitter += (math.Abs(arrivalDelta-tsDelta) - j.jitter) / 16
+	}
+	j.started, j.lastTS, j.lastArrival = true, ts, arrival
+}
+
+func (j *jitterEstimator) duration(clockRate uint32) time.Duration {
+	if clockRate <= 0 {
+		return 0
+	}
+	return time.Duration(j.jitter / float64(clockRate) * float64(time.Second))
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJitter_Steady(t *testing.T) {
+	var j jitterEstimator
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		// 25 frames per second on a 90kHz clock, received on time
+		j.update(uint32(i*3600), start.Add(time.Duration(i)*40*time.Millisecond), 90000)
+	}
+	if d := j.duration(90000); d > time.Microsecond {
+		t.Fatal("unexpected jitter", d)
+	}
+}
+
+func TestJitter_Irregular(t *testing.T) {
+	var j jitterEstimator
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		// Every other frame is received 10ms late, across a wrap of the RTP clock
+		late := time.Duration(i%2) * 10 * time.Millisecond
+		j.update(uint32(math32Wrap+i*3600), start.Add(time.Duration(i)*40*time.Millisecond+late), 90000)
+	}
+	if d := j.duration(90000); d < 5*time.Millisecond || d > 15*time.Millisecond {
+		t.Fatal("unexpected jitter", d)
+	}
+}
+
+// Close to the wrap of a 32 bits RTP timestamp
+const math32Wrap = 1<<32 - 50*3600
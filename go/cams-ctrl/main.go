@@ -21,30 +21,55 @@ import (
 	"os"
 	"os/signal"
 	"sync"
+	"time"
 
 	"github.com/jfsmig/cams/go/api/pb"
 	"github.com/jfsmig/cams/go/utils"
-	"github.com/jfsmig/go-bags"
+	"github.com/juju/errors"
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 type Registrar interface {
 	Register(stream StreamRegistration) error
 
+	Get(streamID string) (StreamRecord, bool)
+
 	ListById(start string) ([]StreamRecord, error)
+
+	// Sync applies at once the changes to the streams of an agent. With a
+	// snapshot, the other streams of the agent are removed, otherwise they are
+	// refreshed. It returns the streams that could not be registered.
+	Sync(agent AgentID, sync StreamSync) map[string]error
+}
+
+// StreamSync is a set of changes to the streams of an agent
+type StreamSync struct {
+	Snapshot bool
+	Upserts  []StreamRegistration
+	Removed  []string
 }
 
 type StreamRecord struct {
 	StreamID string
 	User     string
+	Agent    AgentID
+	Metadata *pb.CameraMetadata
 }
 
 type StreamRegistration struct {
 	StreamID string
 	User     string
+	Agent    AgentID
+
+	// What the agent knows about the camera, nil if it knows nothing yet
+	Metadata *pb.CameraMetadata
 }
 
+// AgentID identifies an agent among all the agents of its user
 type AgentID string
 type StreamID string
 
@@ -57,6 +82,7 @@ type grpcHub struct {
 	pb.UnimplementedRegistrarServer
 	pb.UnimplementedControllerServer
 	pb.UnimplementedViewerServer
+	pb.UnimplementedUploaderServer
 
 	config utils.ServerConfig
 
@@ -67,11 +93,60 @@ type grpcHub struct {
 	// Gathers the known streams
 	registrar Registrar
 
-	// Gather the established connections to agents on the field
-	agents bags.SortedObj[AgentID, *AgentTwin]
+	// Gather the established connections to agents on the field, by agent ID
+	agents *agentRegistry
+
+	// Enforces the limits on the streams, the viewers, the bitrate and the storage
+	quotas *quotaManager
+
+	// What is expected from each stream, replayed to the agents on each connection
+	desired DesiredStore
+
+	// The latest events notified by the cameras
+	events *eventStore
+
+	// The rights granted to the viewers, beyond viewing
+	rights *accessRights
+
+	// The latest snapshot of each stream, the thumbnails of the listings
+	snapshots *snapshotStore
+
+	// How long a viewer session lasts without being renewed
+	viewerTTL time.Duration
+
+	// How long a stream keeps playing after its last viewer left
+	viewerLinger time.Duration
+}
+
+type HubConfig struct {
+	Server utils.ServerConfig
+
+	// Address of the HTTP endpoint exposing the metrics, disabled if empty
+	MetricsAddr string
+
+	// Path of the file where the desired states of the streams are persisted.
+	// The states are only kept in memory if empty.
+	StatePath string
+
+	// How long a viewer session lasts without being renewed by a Play
+	ViewerTTL time.Duration
+
+	// How long a stream keeps playing after its last viewer left
+	ViewerLinger time.Duration
+
+	Quotas QuotaConfig
+
+	Rights RightsConfig
 }
 
+const (
+	DefaultViewerTTL    = time.Minute
+	DefaultViewerLinger = 30 * time.Second
+)
+
 func main() {
+	var statePath, rightsPath string
+	var viewerTTL, viewerLinger time.Duration
 	cmd := &cobra.Command{
 		Use:   "hub",
 		Short: "Cams Hub",
@@ -79,10 +154,24 @@ func main() {
 		//Args:  cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// FIXME(jfs): load an external configuration file or CLI options
-			cfg := utils.ServerConfig{
-				ListenAddr: "127.0.0.1:6000",
-				PathCrt:    "",
-				PathKey:    "",
+			cfg := HubConfig{
+				Server: utils.ServerConfig{
+					ListenAddr: "127.0.0.1:6000",
+					PathCrt:    "",
+					PathKey:    "",
+				},
+				MetricsAddr:  "127.0.0.1:6001",
+				StatePath:    statePath,
+				ViewerTTL:    viewerTTL,
+				ViewerLinger: viewerLinger,
+				Quotas:       DefaultQuotaConfig(),
+			}
+			if len(rightsPath) > 0 {
+				rights, err := LoadRightsConfig(rightsPath)
+				if err != nil {
+					return errors.Annotate(err, "rights")
+				}
+				cfg.Rights = rights
 			}
 
 			ctx, cancel := signal.NotifyContext(context.Background(), os.Kill, os.Interrupt)
@@ -92,6 +181,11 @@ func main() {
 		},
 	}
 
+	cmd.Flags().StringVar(&statePath, "state", "", "File persisting the desired state of the streams")
+	cmd.Flags().StringVar(&rightsPath, "rights", "", "File granting the rights beyond viewing (e.g. PTZ) to the viewers")
+	cmd.Flags().DurationVar(&viewerTTL, "viewer-ttl", DefaultViewerTTL, "Expiration of the viewer sessions not renewed")
+	cmd.Flags().DurationVar(&viewerLinger, "linger", DefaultViewerLinger, "Delay before stopping a stream without viewers")
+
 	if err := cmd.Execute(); err != nil {
 		utils.Logger.Fatal().Err(err).Msg("Aborting")
 	} else {
@@ -99,9 +193,37 @@ func main() {
 	}
 }
 
-func runHub(ctx context.Context, config utils.ServerConfig) error {
+func runHub(ctx context.Context, hubConfig HubConfig) error {
+	config := hubConfig.Server
 	hub := &grpcHub{
-		config: config,
+		config:       config,
+		registrar:    NewRegistrarInMem(),
+		agents:       newAgentRegistry(),
+		quotas:       NewQuotaManager(hubConfig.Quotas),
+		events:       newEventStore(),
+		rights:       newAccessRights(hubConfig.Rights),
+		snapshots:    newSnapshotStore(),
+		viewerTTL:    hubConfig.ViewerTTL,
+		viewerLinger: hubConfig.ViewerLinger,
+	}
+	if hub.viewerTTL <= 0 {
+		hub.viewerTTL = DefaultViewerTTL
+	}
+
+	if len(hubConfig.StatePath) <= 0 {
+		hub.desired = NewDesiredStoreInMem()
+	} else {
+		desired, err := NewDesiredStoreFile(hubConfig.StatePath)
+		if err != nil {
+			return errors.Annotate(err, "desired state")
+		}
+		hub.desired = desired
+	}
+	// The viewers restored from the previous run still hold their quota
+	for _, state := range hub.desired.List() {
+		for range state.Viewers {
+			_ = hub.quotas.AcquireViewer(state.User, state.Agent)
+		}
 	}
 
 	utils.Logger.Info().Str("action", "start").Msg("hub")
@@ -153,14 +275,20 @@ func runHub(ctx context.Context, config utils.ServerConfig) error {
 			pb.RegisterRegistrarServer(serverCtrl, hub)
 			pb.RegisterControllerServer(serverCtrl, hub)
 			pb.RegisterViewerServer(serverCtrl, hub)
-			hub.registrar = NewRegistrarInMem()
 			if err := serverCtrl.Serve(listenerCtrl); err != nil {
 				utils.Logger.Warn().Err(err).Msg("controller error")
 			}
 		},
+		func(c context.Context) {
+			hub.runViewersJanitor(c)
+		},
+		func(c context.Context) {
+			if len(hubConfig.MetricsAddr) > 0 {
+				runMetrics(c, hubConfig.MetricsAddr)
+			}
+		},
 		func(c context.Context) {
 			pb.RegisterUploaderServer(serverStream, hub)
-			hub.registrar = NewRegistrarInMem()
 			if err := serverStream.Serve(listenerStream); err != nil {
 				utils.Logger.Warn().Err(err).Msg("upload error")
 			}
@@ -169,3 +297,17 @@ func runHub(ctx context.Context, config utils.ServerConfig) error {
 
 	return nil
 }
+
+// agentIdentity extracts from the metadata of the call the user and the agent
+// at the origin of the call.
+func agentIdentity(ctx context.Context) (string, AgentID, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", "", status.Error(codes.InvalidArgument, "missing metadata")
+	}
+	users, agents := md.Get(utils.KeyUser), md.Get(utils.KeyAgent)
+	if len(users) <= 0 || len(users[0]) <= 0 || len(agents) <= 0 || len(agents[0]) <= 0 {
+		return "", "", status.Error(codes.InvalidArgument, "missing user or agent")
+	}
+	return users[0], AgentID(agents[0]), nil
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"expvar"
+	"net"
+	"net/http"
+
+	"github.com/jfsmig/cams/go/utils"
+)
+
+// The metrics are exposed as expvar variables, i.e. as a JSON object
+// served on /debug/vars by the metrics endpoint of the hub.
+var (
+	metricQuotaRejections = expvar.NewMap("cams_quota_rejections")
+	metricIngestPackets   = expvar.NewMap("cams_ingest_packets")
+	metricIngestBytes     = expvar.NewInt("cams_ingest_bytes")
+	metricIngestGaps      = expvar.NewInt("cams_ingest_gaps")
+	metricIngestJitter    = expvar.NewMap("cams_ingest_jitter_us")
+	metricStoredBytes     = expvar.NewInt("cams_stored_bytes")
+	metricLiveStreams     = expvar.NewInt("cams_live_streams")
+	metricViewers         = expvar.NewInt("cams_viewers")
+)
+
+func runMetrics(ctx context.Context, addr string) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		utils.Logger.Warn().Err(err).Str("action", "listen").Msg("metrics")
+		return
+	}
+
+	srv := http.Server{Handler: expvar.Handler()}
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+
+	utils.Logger.Info().Str("addr", addr).Str("action", "start").Msg("metrics")
+	if err := srv.Serve(listener); err != nil && err != http.ErrServerClosed {
+		utils.Logger.Warn().Err(err).Msg("metrics error")
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+
+	"github.com/jfsmig/cams/go/api/pb"
+	"github.com/jfsmig/cams/go/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (hub *grpcHub) PTZMove(ctx context.Context, req *pb.PTZMoveRequest) (*pb.None, error) {
+	_, err := hub.ptz(ctx, req.Id, &pb.PTZCommand{
+		Action:  pb.PTZAction_PTZ_ACTION_MOVE,
+		Pan:     req.Pan,
+		Tilt:    req.Tilt,
+		Zoom:    req.Zoom,
+		Timeout: req.Timeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.None{}, nil
+}
+
+func (hub *grpcHub) PTZStop(ctx context.Context, req *pb.PTZStopRequest) (*pb.None, error) {
+	if _, err := hub.ptz(ctx, req.Id, &pb.PTZCommand{Action: pb.PTZAction_PTZ_ACTION_STOP}); err != nil {
+		return nil, err
+	}
+	return &pb.None{}, nil
+}
+
+func (hub *grpcHub) PTZGotoPreset(ctx context.Context, req *pb.PTZGotoPresetRequest) (*pb.None, error) {
+	if len(req.Preset) <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "no preset")
+	}
+	_, err := hub.ptz(ctx, req.Id, &pb.PTZCommand{Action: pb.PTZAction_PTZ_ACTION_GOTO_PRESET, Preset: req.Preset})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.None{}, nil
+}
+
+func (hub *grpcHub) PTZPresets(ctx context.Context, req *pb.PTZPresetsRequest) (*pb.PTZPresetsReply, error) {
+	presets, err := hub.ptz(ctx, req.Id, &pb.PTZCommand{Action: pb.PTZAction_PTZ_ACTION_LIST_PRESETS})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.PTZPresetsReply{Presets: presets}, nil
+}
+
+// ptz checks the right of the caller then forwards the command to the agent of the
+// stream. Unlike playing, steering a camera is pointless when the agent is offline.
+func (hub *grpcHub) ptz(ctx context.Context, id *pb.StreamId, cmd *pb.PTZCommand) ([]*pb.PTZPreset, error) {
+	utils.Logger.Info().Str("action", "ptz").Interface("cam", id).Str("ptz", cmd.Action.String()).Msg("view")
+
+	record, err := hub.lookupStream(id)
+	if err != nil {
+		return nil, err
+	}
+	if err = hub.checkRight(ctx, record.User, RightPTZ); err != nil {
+		return nil, err
+	}
+	agent, ok := hub.agents.Get(record.Agent)
+	if !ok {
+		return nil, status.Error(codes.Unavailable, "agent offline")
+	}
+	return agent.PTZ(ctx, id.Stream, cmd)
+}
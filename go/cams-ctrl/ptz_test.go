@@ -0,0 +1,97 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jfsmig/cams/go/api/pb"
+	"github.com/jfsmig/cams/go/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ptzViewers are the viewers known to the hub, by token
+var ptzViewers = map[string]string{"tok-u": "u", "tok-trusted": "trusted", "tok-viewer": "viewer"}
+
+func ptzContext(token string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(utils.KeyAuth, "Bearer "+token))
+}
+
+func TestPTZ_Rights(t *testing.T) {
+	hub := newTestHub()
+	hub.rights = newAccessRights(RightsConfig{Viewers: ptzViewers, PTZ: map[string][]string{"u": {"trusted"}}})
+	if err := hub.registrar.Register(StreamRegistration{StreamID: "s", User: "u", Agent: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	id := &pb.StreamId{User: "u", Stream: "s"}
+
+	// Viewing doesn't grant steering
+	_, err := hub.PTZStop(ptzContext("tok-viewer"), &pb.PTZStopRequest{Id: id})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatal("unexpected error", err)
+	}
+	_, err = hub.PTZStop(context.Background(), &pb.PTZStopRequest{Id: id})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatal("unexpected error", err)
+	}
+
+	// The identity claimed by the caller is ignored
+	forged := metadata.NewIncomingContext(context.Background(), metadata.Pairs("viewer", "u", utils.KeyAuth, "Bearer forged"))
+	_, err = hub.PTZStop(forged, &pb.PTZStopRequest{Id: id})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatal("unexpected error", err)
+	}
+
+	// Granted, but the agent is offline
+	_, err = hub.PTZStop(ptzContext("tok-trusted"), &pb.PTZStopRequest{Id: id})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatal("unexpected error", err)
+	}
+}
+
+func TestPTZ_Presets(t *testing.T) {
+	hub := newTestHub()
+	hub.rights = newAccessRights(RightsConfig{Viewers: ptzViewers})
+	if err := hub.registrar.Register(StreamRegistration{StreamID: "s", User: "u", Agent: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	agent := NewAgentTwin("a", "u", nil)
+	if _, err := hub.agents.Attach(agent); err != nil {
+		t.Fatal(err)
+	}
+
+	// Mimic the agent
+	go func() {
+		cmd := <-agent.requests
+		reply := &pb.UpstreamControlReply{RequestID: cmd.requestID, Status: pb.UpstreamReplyStatus_UPSTREAM_REPLY_STATUS_OK}
+		if cmd.cmdType == CtrlCommandType_PTZ && cmd.ptz.Action == pb.PTZAction_PTZ_ACTION_LIST_PRESETS {
+			reply.Presets = []*pb.PTZPreset{{Token: "1", Name: "door"}}
+		}
+		agent.onReply(reply)
+	}()
+
+	// The owner holds all the rights
+	reply, err := hub.PTZPresets(ptzContext("tok-u"), &pb.PTZPresetsRequest{Id: &pb.StreamId{User: "u", Stream: "s"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reply.Presets) != 1 || reply.Presets[0].Name != "door" {
+		t.Fatal("unexpected presets", reply.Presets)
+	}
+}
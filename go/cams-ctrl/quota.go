@@ -0,0 +1,286 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// QuotaLimits is a set of limits applied to a single user or a single agent.
+// A zero value means "unlimited".
+type QuotaLimits struct {
+	// Maximum number of concurrent live streams (i.e. open uploads)
+	MaxStreams uint32 `json:"max_streams"`
+
+	// Maximum number of concurrent viewers, all streams summed
+	MaxViewers uint32 `json:"max_viewers"`
+
+	// Maximum ingest bitrate, in bits per second, all streams summed
+	MaxBitrate uint64 `json:"max_bitrate"`
+
+	// Maximum ingest bitrate of the footage recorded by the agents and uploaded
+	// late, in bits per second, apart from the live streams
+	MaxRecordedBitrate uint64 `json:"max_recorded_bitrate"`
+
+	// Maximum number of bytes stored
+	MaxStored uint64 `json:"max_stored"`
+}
+
+type QuotaConfig struct {
+	User  QuotaLimits `json:"user"`
+	Agent QuotaLimits `json:"agent"`
+}
+
+const (
+	quotaKindStreams = "streams"
+	quotaKindViewers = "viewers"
+	quotaKindBitrate = "bitrate"
+	quotaKindStored  = "stored"
+)
+
+func DefaultQuotaConfig() QuotaConfig {
+	return QuotaConfig{
+		User: QuotaLimits{
+			MaxStreams:         16,
+			MaxViewers:         64,
+			MaxBitrate:         64 * 1024 * 1024,
+			MaxRecordedBitrate: 64 * 1024 * 1024,
+		},
+		Agent: QuotaLimits{
+			MaxStreams:         16,
+			MaxBitrate:         32 * 1024 * 1024,
+			MaxRecordedBitrate: 32 * 1024 * 1024,
+		},
+	}
+}
+
+// tokenBucket measures a bitrate with a burst capacity of one second.
+type tokenBucket struct {
+	rate   float64 // bytes per second
+	tokens float64
+	last   time.Time
+}
+
+func (tb *tokenBucket) refill(now time.Time) {
+	if tb.last.IsZero() {
+		tb.tokens = tb.rate
+	} else {
+		tb.tokens += now.Sub(tb.last).Seconds() * tb.rate
+		if tb.tokens > tb.rate {
+			tb.tokens = tb.rate
+		}
+	}
+	tb.last = now
+}
+
+// take consumes the tokens for a packet of the given size. The bucket is allowed
+// to go down to -floor before refusing the packet, this lets the keyframes use
+// an extra credit that the other packets cannot use.
+func (tb *tokenBucket) take(now time.Time, size int, floor float64) bool {
+	tb.refill(now)
+	if tb.tokens-float64(size) < -floor {
+		return false
+	}
+	tb.tokens -= float64(size)
+	return true
+}
+
+type quotaUsage struct {
+	streams uint32
+	viewers uint32
+	stored  uint64
+	bucket  tokenBucket
+
+	// The bitrate of the recorded footage
+	recorded tokenBucket
+}
+
+type quotaManager struct {
+	cfg QuotaConfig
+
+	lock   sync.Mutex
+	users  map[string]*quotaUsage
+	agents map[AgentID]*quotaUsage
+}
+
+func NewQuotaManager(cfg QuotaConfig) *quotaManager {
+	return &quotaManager{
+		cfg:    cfg,
+		users:  make(map[string]*quotaUsage),
+		agents: make(map[AgentID]*quotaUsage),
+	}
+}
+
+func errQuota(kind, scope string) error {
+	metricQuotaRejections.Add(kind, 1)
+	return status.Errorf(codes.ResourceExhausted, "%s quota exceeded for %s", kind, scope)
+}
+
+func newQuotaUsage(limits QuotaLimits) *quotaUsage {
+	return &quotaUsage{
+		bucket:   tokenBucket{rate: float64(limits.MaxBitrate) / 8},
+		recorded: tokenBucket{rate: float64(limits.MaxRecordedBitrate) / 8},
+	}
+}
+
+func (q *quotaManager) usage(user string, agent AgentID) (*quotaUsage, *quotaUsage) {
+	u, ok := q.users[user]
+	if !ok {
+		u = newQuotaUsage(q.cfg.User)
+		q.users[user] = u
+	}
+	a, ok := q.agents[agent]
+	if !ok {
+		a = newQuotaUsage(q.cfg.Agent)
+		q.agents[agent] = a
+	}
+	return u, a
+}
+
+func overLimit[T uint32 | uint64](current, max T) bool {
+	return max > 0 && current >= max
+}
+
+// AcquireStream accounts a new live stream, or fails with a ResourceExhausted error
+func (q *quotaManager) AcquireStream(user string, agent AgentID) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	u, a := q.usage(user, agent)
+	if overLimit(u.streams, q.cfg.User.MaxStreams) {
+		return errQuota(quotaKindStreams, "user")
+	}
+	if overLimit(a.streams, q.cfg.Agent.MaxStreams) {
+		return errQuota(quotaKindStreams, "agent")
+	}
+	u.streams++
+	a.streams++
+	metricLiveStreams.Add(1)
+	return nil
+}
+
+func (q *quotaManager) ReleaseStream(user string, agent AgentID) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	u, a := q.usage(user, agent)
+	if u.streams > 0 {
+		u.streams--
+	}
+	if a.streams > 0 {
+		a.streams--
+	}
+	metricLiveStreams.Add(-1)
+}
+
+// AcquireViewer accounts a new viewer, or fails with a ResourceExhausted error
+func (q *quotaManager) AcquireViewer(user string, agent AgentID) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	u, a := q.usage(user, agent)
+	if overLimit(u.viewers, q.cfg.User.MaxViewers) {
+		return errQuota(quotaKindViewers, "user")
+	}
+	if overLimit(a.viewers, q.cfg.Agent.MaxViewers) {
+		return errQuota(quotaKindViewers, "agent")
+	}
+	u.viewers++
+	a.viewers++
+	metricViewers.Add(1)
+	return nil
+}
+
+func (q *quotaManager) ReleaseViewer(user string, agent AgentID) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	u, a := q.usage(user, agent)
+	if u.viewers > 0 {
+		u.viewers--
+	}
+	if a.viewers > 0 {
+		a.viewers--
+	}
+	metricViewers.Add(-1)
+}
+
+// AdmitPacket tells if a packet of the given size fits in the bitrate allowed to
+// both the user and the agent. When the budget is exhausted, the keyframes still
+// get a credit of one extra second before being dropped.
+func (q *quotaManager) AdmitPacket(user string, agent AgentID, size int, keyframe bool) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	u, a := q.usage(user, agent)
+	return admitBoth(&u.bucket, &a.bucket, size, keyframe)
+}
+
+// AdmitRecorded is AdmitPacket for the footage recorded by the agent, within
+// its own bitrate
+func (q *quotaManager) AdmitRecorded(user string, agent AgentID, size int, keyframe bool) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	u, a := q.usage(user, agent)
+	return admitBoth(&u.recorded, &a.recorded, size, keyframe)
+}
+
+func admitBoth(user, agent *tokenBucket, size int, keyframe bool) bool {
+	now := time.Now()
+	if !admitPacket(user, now, size, keyframe) {
+		return false
+	}
+	if !admitPacket(agent, now, size, keyframe) {
+		// Give the tokens back to the user
+		user.tokens += float64(size)
+		return false
+	}
+	return true
+}
+
+func admitPacket(tb *tokenBucket, now time.Time, size int, keyframe bool) bool {
+	if tb.rate <= 0 {
+		return true
+	}
+	floor := float64(0)
+	if keyframe {
+		floor = tb.rate
+	}
+	return tb.take(now, size, floor)
+}
+
+// Store accounts bytes stored on behalf of a user and an agent
+func (q *quotaManager) Store(user string, agent AgentID, size uint64) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	u, a := q.usage(user, agent)
+	if q.cfg.User.MaxStored > 0 && u.stored+size > q.cfg.User.MaxStored {
+		return errQuota(quotaKindStored, "user")
+	}
+	if q.cfg.Agent.MaxStored > 0 && a.stored+size > q.cfg.Agent.MaxStored {
+		return errQuota(quotaKindStored, "agent")
+	}
+	u.stored += size
+	a.stored += size
+	metricStoredBytes.Add(int64(size))
+	return nil
+}
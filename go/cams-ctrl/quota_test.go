@@ -0,0 +1,94 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func assertExhausted(t *testing.T, err error) {
+	if err == nil {
+		t.Fatal("unexpected success")
+	}
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatal("unexpected error", err)
+	}
+}
+
+func TestQuota_Streams(t *testing.T) {
+	q := NewQuotaManager(QuotaConfig{
+		User:  QuotaLimits{MaxStreams: 2},
+		Agent: QuotaLimits{MaxStreams: 1},
+	})
+	if err := q.AcquireStream("u", "a0"); err != nil {
+		t.Fatal(err)
+	}
+	// The agent is full, the user is not
+	assertExhausted(t, q.AcquireStream("u", "a0"))
+	if err := q.AcquireStream("u", "a1"); err != nil {
+		t.Fatal(err)
+	}
+	// The user is full
+	assertExhausted(t, q.AcquireStream("u", "a2"))
+
+	q.ReleaseStream("u", "a0")
+	if err := q.AcquireStream("u", "a2"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestQuota_Viewers(t *testing.T) {
+	q := NewQuotaManager(QuotaConfig{User: QuotaLimits{MaxViewers: 1}})
+	if err := q.AcquireViewer("u", "a"); err != nil {
+		t.Fatal(err)
+	}
+	assertExhausted(t, q.AcquireViewer("u", "a"))
+	q.ReleaseViewer("u", "a")
+	if err := q.AcquireViewer("u", "a"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestQuota_Stored(t *testing.T) {
+	q := NewQuotaManager(QuotaConfig{Agent: QuotaLimits{MaxStored: 100}})
+	if err := q.Store("u", "a", 60); err != nil {
+		t.Fatal(err)
+	}
+	assertExhausted(t, q.Store("u", "a", 60))
+	if err := q.Store("u", "a", 40); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestQuota_BitrateKeyframesFirst(t *testing.T) {
+	// 8000 bits per second, i.e. a budget of 1000 bytes
+	q := NewQuotaManager(QuotaConfig{User: QuotaLimits{MaxBitrate: 8000}})
+	if !q.AdmitPacket("u", "a", 900, false) {
+		t.Fatal("packet dropped within the budget")
+	}
+	if q.AdmitPacket("u", "a", 200, false) {
+		t.Fatal("packet admitted out of the budget")
+	}
+	if !q.AdmitPacket("u", "a", 200, true) {
+		t.Fatal("keyframe dropped within the credit")
+	}
+	if q.AdmitPacket("u", "a", 2000, true) {
+		t.Fatal("keyframe admitted out of the credit")
+	}
+}
@@ -17,12 +17,16 @@ package main
 
 import (
 	"context"
+	"io"
 	"sync"
 	"time"
 
 	"github.com/jfsmig/cams/go/api/pb"
+	"github.com/jfsmig/cams/go/utils"
 	"github.com/jfsmig/go-bags"
 	"github.com/juju/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var (
@@ -48,20 +52,78 @@ func NewRegistrarInMem() Registrar {
 func (r *registrarInMem) Register(stream StreamRegistration) error {
 	r.lock.Lock()
 	defer r.lock.Unlock()
+	return r.register(stream, time.Now())
+}
 
+func (r *registrarInMem) register(stream StreamRegistration, now time.Time) error {
 	if sr0, ok := r.streams.Get(stream.StreamID); !ok {
 		// First discovery of the stream
-		sr := streamRecord{StreamRegistration: stream, latUpdate: time.Now()}
+		sr := streamRecord{StreamRegistration: stream, latUpdate: now}
 		r.streams.Add(&sr)
 		return nil
 	} else if sr0.User != stream.User {
 		return errors.New("device existing for another user")
 	} else {
-		sr0.latUpdate = time.Now()
+		// The camera may have moved to another agent of the same user
+		sr0.Agent = stream.Agent
+		sr0.latUpdate = now
+		if stream.Metadata != nil {
+			sr0.Metadata = stream.Metadata
+		}
 		return nil
 	}
 }
 
+func (r *registrarInMem) Sync(agent AgentID, sync StreamSync) map[string]error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := time.Now()
+	rejected := make(map[string]error)
+	registered := make(map[string]bool)
+	for _, stream := range sync.Upserts {
+		stream.Agent = agent
+		if err := r.register(stream, now); err != nil {
+			rejected[stream.StreamID] = err
+		} else {
+			registered[stream.StreamID] = true
+		}
+	}
+
+	// A stream is only removed by the agent it is bound to, it may have
+	// moved to another agent meanwhile.
+	gone := make([]string, 0)
+	for _, streamID := range sync.Removed {
+		if sr, ok := r.streams.Get(streamID); ok && sr.Agent == agent {
+			gone = append(gone, streamID)
+		}
+	}
+	for _, sr := range r.streams {
+		if sr.Agent != agent || registered[sr.StreamID] {
+			continue
+		}
+		if sync.Snapshot {
+			gone = append(gone, sr.StreamID)
+		} else {
+			sr.latUpdate = now
+		}
+	}
+	for _, streamID := range gone {
+		r.streams.Remove(streamID)
+	}
+	return rejected
+}
+
+func (r *registrarInMem) Get(streamID string) (StreamRecord, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if sr, ok := r.streams.Get(streamID); ok {
+		return StreamRecord{StreamID: sr.StreamID, User: sr.User, Agent: sr.Agent, Metadata: sr.Metadata}, true
+	}
+	return StreamRecord{}, false
+}
+
 func (r *registrarInMem) ListById(start string) ([]StreamRecord, error) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
@@ -71,13 +133,72 @@ func (r *registrarInMem) ListById(start string) ([]StreamRecord, error) {
 		out = append(out, StreamRecord{
 			StreamID: sr.StreamID,
 			User:     sr.User,
+			Agent:    sr.Agent,
+			Metadata: sr.Metadata,
 		})
 	}
 	return out, nil
 }
 
+// Sync applies the registrations of an agent as they come, and acknowledges
+// each of them. The streams are registered under the user of the agent.
+func (hub *grpcHub) Sync(stream pb.Registrar_SyncServer) error {
+	user, agentID, err := agentIdentity(stream.Context())
+	if err != nil {
+		return err
+	}
+	utils.Logger.Info().Str("user", user).Str("agent", string(agentID)).Str("action", "sync").Msg("registrar")
+
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		ack := &pb.RegistrationAck{Sequence: msg.Sequence}
+		sync := StreamSync{Snapshot: msg.Kind == pb.RegistrationKind_REGISTRATION_KIND_SNAPSHOT, Removed: msg.Removed}
+		for _, req := range append(msg.Added, msg.Updated...) {
+			if req.GetId().GetUser() != user {
+				ack.Rejected = append(ack.Rejected, &pb.RegistrationRejection{
+					StreamID: req.GetId().GetStream(),
+					Reason:   "user mismatch",
+				})
+				continue
+			}
+			sync.Upserts = append(sync.Upserts, StreamRegistration{
+				StreamID: req.Id.Stream,
+				User:     user,
+				Metadata: req.Metadata,
+			})
+		}
+		for streamID, err := range hub.registrar.Sync(agentID, sync) {
+			ack.Rejected = append(ack.Rejected, &pb.RegistrationRejection{StreamID: streamID, Reason: err.Error()})
+		}
+
+		if err = stream.Send(ack); err != nil {
+			return err
+		}
+	}
+}
+
+// Register registers a single stream under the user of the agent, like Sync
 func (hub *grpcHub) Register(ctx context.Context, req *pb.RegisterRequest) (*pb.None, error) {
-	err := hub.registrar.Register(StreamRegistration{req.Id.Stream, req.Id.User})
+	user, agentID, err := agentIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.GetId().GetUser() != user {
+		return nil, status.Error(codes.PermissionDenied, "user mismatch")
+	}
+	err = hub.registrar.Register(StreamRegistration{
+		StreamID: req.Id.Stream,
+		User:     user,
+		Agent:    agentID,
+		Metadata: req.Metadata,
+	})
 	if err != nil {
 		return nil, err
 	} else {
@@ -0,0 +1,122 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jfsmig/cams/go/api/pb"
+	"github.com/jfsmig/cams/go/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestRegistrar_BindAgent(t *testing.T) {
+	r := NewRegistrarInMem()
+	if err := r.Register(StreamRegistration{StreamID: "s", User: "u", Agent: "home"}); err != nil {
+		t.Fatal(err)
+	}
+	// The camera moved to another agent of the same user
+	if err := r.Register(StreamRegistration{StreamID: "s", User: "u", Agent: "shop"}); err != nil {
+		t.Fatal(err)
+	}
+	if record, ok := r.Get("s"); !ok || record.Agent != "shop" {
+		t.Fatal("unexpected record", record)
+	}
+	// The camera cannot be stolen by another user
+	if err := r.Register(StreamRegistration{StreamID: "s", User: "other", Agent: "x"}); err == nil {
+		t.Fatal("unexpected success")
+	}
+}
+
+func TestRegistrar_RegisterUser(t *testing.T) {
+	hub := newTestHub()
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(utils.KeyUser, "u", utils.KeyAgent, "home"))
+
+	// An agent cannot register a stream under another user
+	_, err := hub.Register(ctx, &pb.RegisterRequest{Id: &pb.StreamId{User: "other", Stream: "s"}})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatal("unexpected error", err)
+	}
+	if _, ok := hub.registrar.Get("s"); ok {
+		t.Fatal("unexpected registration")
+	}
+
+	if _, err = hub.Register(ctx, &pb.RegisterRequest{Id: &pb.StreamId{User: "u", Stream: "s"}}); err != nil {
+		t.Fatal(err)
+	}
+	if record, ok := hub.registrar.Get("s"); !ok || record.User != "u" || record.Agent != "home" {
+		t.Fatal("unexpected record", record)
+	}
+}
+
+func TestRegistrar_Metadata(t *testing.T) {
+	r := NewRegistrarInMem()
+	md := &pb.CameraMetadata{Manufacturer: "acme", Profiles: []*pb.CameraProfile{{Token: "p0", Codec: "H264", Width: 1280, Height: 960}}}
+	if err := r.Register(StreamRegistration{StreamID: "s", User: "u", Agent: "home", Metadata: md}); err != nil {
+		t.Fatal(err)
+	}
+	// An agent that couldn't describe the camera doesn't erase what is known
+	if err := r.Register(StreamRegistration{StreamID: "s", User: "u", Agent: "home"}); err != nil {
+		t.Fatal(err)
+	}
+	record, ok := r.Get("s")
+	if !ok || record.Metadata.GetManufacturer() != "acme" || record.Metadata.Profiles[0].Width != 1280 {
+		t.Fatal("unexpected record", record)
+	}
+}
+
+func TestRegistrar_Sync(t *testing.T) {
+	r := NewRegistrarInMem()
+	for _, s := range []string{"a", "b"} {
+		if err := r.Register(StreamRegistration{StreamID: s, User: "u", Agent: "home"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Register(StreamRegistration{StreamID: "x", User: "other", Agent: "x"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The streams missing from a snapshot are removed, a stolen one is rejected
+	rejected := r.Sync("home", StreamSync{Snapshot: true, Upserts: []StreamRegistration{
+		{StreamID: "a", User: "u"},
+		{StreamID: "c", User: "u"},
+		{StreamID: "x", User: "u"},
+	}})
+	if len(rejected) != 1 || rejected["x"] == nil {
+		t.Fatal("unexpected rejections", rejected)
+	}
+	if _, ok := r.Get("b"); ok {
+		t.Fatal("unexpected stream")
+	}
+	if record, ok := r.Get("c"); !ok || record.Agent != "home" {
+		t.Fatal("unexpected record", record)
+	}
+
+	// A camera moved to another agent is not removed by the former one
+	if err := r.Register(StreamRegistration{StreamID: "a", User: "u", Agent: "shop"}); err != nil {
+		t.Fatal(err)
+	}
+	r.Sync("home", StreamSync{Removed: []string{"a", "c"}})
+	if _, ok := r.Get("a"); !ok {
+		t.Fatal("missing stream")
+	}
+	if _, ok := r.Get("c"); ok {
+		t.Fatal("unexpected stream")
+	}
+}
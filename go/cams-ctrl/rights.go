@@ -0,0 +1,120 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/jfsmig/cams/go/utils"
+	"github.com/juju/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RightsConfig grants to the viewers the rights on the streams of a user,
+// beyond viewing them. The owner of the streams holds all the rights.
+type RightsConfig struct {
+	// The viewers known to the hub, by the bearer token they authenticate with
+	Viewers map[string]string `json:"viewers"`
+
+	// The viewers allowed to steer the cameras, by owner of the cameras
+	PTZ map[string][]string `json:"ptz"`
+}
+
+type Right uint32
+
+const (
+	RightPTZ Right = iota
+)
+
+type accessRights struct {
+	tokens map[string]string
+	ptz    map[string]map[string]bool
+}
+
+func LoadRightsConfig(path string) (RightsConfig, error) {
+	var cfg RightsConfig
+	encoded, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, errors.Annotate(err, "read")
+	}
+	err = json.Unmarshal(encoded, &cfg)
+	return cfg, errors.Annotate(err, "decode")
+}
+
+func newAccessRights(cfg RightsConfig) *accessRights {
+	ar := &accessRights{tokens: cfg.Viewers, ptz: make(map[string]map[string]bool)}
+	for owner, viewers := range cfg.PTZ {
+		ar.ptz[owner] = make(map[string]bool)
+		for _, viewer := range viewers {
+			ar.ptz[owner][viewer] = true
+		}
+	}
+	return ar
+}
+
+// Allowed tells if the viewer holds the right on the streams of the owner
+func (ar *accessRights) Allowed(owner, viewer string, right Right) bool {
+	if len(viewer) <= 0 {
+		return false
+	}
+	if viewer == owner {
+		return true
+	}
+	switch right {
+	case RightPTZ:
+		return ar.ptz[owner][viewer]
+	default:
+		return false
+	}
+}
+
+// authenticate identifies the viewer by the bearer token of the call, or
+// returns an empty string if the token is unknown.
+func (ar *accessRights) authenticate(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	for _, v := range md.Get(utils.KeyAuth) {
+		token, ok := strings.CutPrefix(v, "Bearer ")
+		if !ok || len(token) <= 0 {
+			continue
+		}
+		for known, viewer := range ar.tokens {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(known)) == 1 {
+				return viewer
+			}
+		}
+	}
+	return ""
+}
+
+// checkRight fails with PermissionDenied unless the caller, authenticated by
+// its bearer token, holds the right on the streams of the owner.
+func (hub *grpcHub) checkRight(ctx context.Context, owner string, right Right) error {
+	viewer := hub.rights.authenticate(ctx)
+	if !hub.rights.Allowed(owner, viewer, right) {
+		utils.Logger.Warn().Str("user", owner).Str("viewer", viewer).Str("action", "deny").Msg("rights")
+		return status.Error(codes.PermissionDenied, "right not granted")
+	}
+	return nil
+}
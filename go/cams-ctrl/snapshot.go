@@ -0,0 +1,171 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/jpeg"
+	"sync"
+
+	"github.com/jfsmig/cams/go/api/pb"
+	"github.com/jfsmig/cams/go/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// The box the thumbnails of the listings fit in, a page of listing remains far
+// below the size limit of the gRPC messages
+const (
+	thumbnailWidth  = 160
+	thumbnailHeight = 120
+)
+
+// snapshotStore keeps the latest snapshot of each stream, and its thumbnail
+type snapshotStore struct {
+	streams map[string]*pb.StreamSnapshot
+	thumbs  map[string]*pb.StreamSnapshot
+	lock    sync.Mutex
+}
+
+func newSnapshotStore() *snapshotStore {
+	return &snapshotStore{
+		streams: make(map[string]*pb.StreamSnapshot),
+		thumbs:  make(map[string]*pb.StreamSnapshot),
+	}
+}
+
+// Put replaces the snapshot of the stream, unless the known one is more recent
+func (s *snapshotStore) Put(streamID string, snap *pb.StreamSnapshot) {
+	thumb := &pb.StreamSnapshot{Jpeg: thumbnail(snap.Jpeg), Taken: snap.Taken}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if known, ok := s.streams[streamID]; ok && known.Taken > snap.Taken {
+		return
+	}
+	s.streams[streamID] = snap
+	s.thumbs[streamID] = thumb
+}
+
+// Thumbnail returns the downscaled snapshot of the stream
+func (s *snapshotStore) Thumbnail(streamID string) (*pb.StreamSnapshot, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	thumb, ok := s.thumbs[streamID]
+	return thumb, ok
+}
+
+// thumbnail downscales the JPEG image to fit in the thumbnail box. An image
+// that cannot be decoded gets no thumbnail, only the time of its snapshot
+// tells there is one.
+func thumbnail(encoded []byte) []byte {
+	img, err := jpeg.Decode(bytes.NewReader(encoded))
+	if err != nil {
+		return nil
+	}
+	b := img.Bounds()
+	if b.Dx() <= thumbnailWidth && b.Dy() <= thumbnailHeight {
+		return encoded
+	}
+
+	// Keep the aspect ratio, the nearest neighbour is enough for a thumbnail
+	w, h := thumbnailWidth, b.Dy()*thumbnailWidth/b.Dx()
+	if h > thumbnailHeight {
+		w, h = b.Dx()*thumbnailHeight/b.Dy(), thumbnailHeight
+	}
+	w, h = max(w, 1), max(h, 1)
+	thumb := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			thumb.Set(x, y, img.At(b.Min.X+x*b.Dx()/w, b.Min.Y+y*b.Dy()/h))
+		}
+	}
+	var out bytes.Buffer
+	if err = jpeg.Encode(&out, thumb, &jpeg.Options{Quality: 75}); err != nil {
+		return nil
+	}
+	return out.Bytes()
+}
+
+func (s *snapshotStore) Get(streamID string) (*pb.StreamSnapshot, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	snap, ok := s.streams[streamID]
+	return snap, ok
+}
+
+// Snapshot asks the agent of the stream for a fresh image of the camera, and
+// keeps it as the thumbnail of the stream. The latest known image is served
+// when the agent is offline.
+func (hub *grpcHub) Snapshot(ctx context.Context, req *pb.SnapshotRequest) (*pb.StreamSnapshot, error) {
+	utils.Logger.Info().Str("action", "snapshot").Interface("cam", req).Msg("view")
+
+	record, err := hub.lookupStream(req.Id)
+	if err != nil {
+		return nil, err
+	}
+	agent, ok := hub.agents.Get(record.Agent)
+	if !ok {
+		if snap, ok := hub.snapshots.Get(req.Id.Stream); ok {
+			return snap, nil
+		}
+		return nil, status.Error(codes.Unavailable, "agent offline")
+	}
+
+	snap, err := agent.Snapshot(ctx, req.Id.Stream)
+	if err != nil {
+		return nil, err
+	}
+	hub.snapshots.Put(req.Id.Stream, snap)
+	return snap, nil
+}
+
+// List returns a page of the streams of the user, with the thumbnail of their
+// latest snapshot
+func (hub *grpcHub) List(ctx context.Context, req *pb.ListRequest) (*pb.ListReply, error) {
+	out := &pb.ListReply{}
+	for marker := req.Start; len(out.Streams) < int(getSliceSize); {
+		records, err := hub.registrar.ListById(marker)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		if len(records) <= 0 {
+			break
+		}
+		for _, record := range records {
+			if len(out.Streams) >= int(getSliceSize) {
+				break
+			}
+			if record.User != req.User {
+				continue
+			}
+			_, online := hub.agents.Get(record.Agent)
+			summary := &pb.StreamSummary{
+				Id:       &pb.StreamId{User: record.User, Stream: record.StreamID},
+				Online:   online,
+				Metadata: record.Metadata,
+			}
+			if thumb, ok := hub.snapshots.Thumbnail(record.StreamID); ok {
+				summary.Thumbnail = thumb
+			}
+			out.Streams = append(out.Streams, summary)
+		}
+		marker = records[len(records)-1].StreamID
+	}
+	return out, nil
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"testing"
+
+	"github.com/jfsmig/cams/go/api/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSnapshot_Cache(t *testing.T) {
+	hub := newTestHub()
+	for _, s := range []string{"a", "b"} {
+		if err := hub.registrar.Register(StreamRegistration{StreamID: s, User: "u", Agent: "home"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := hub.registrar.Register(StreamRegistration{StreamID: "c", User: "other", Agent: "x"}); err != nil {
+		t.Fatal(err)
+	}
+	id := &pb.StreamId{User: "u", Stream: "a"}
+
+	// Nothing to serve yet
+	_, err := hub.Snapshot(context.Background(), &pb.SnapshotRequest{Id: id})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatal("unexpected error", err)
+	}
+
+	// Mimic the agent
+	agent := NewAgentTwin("home", "u", nil)
+	if _, err := hub.agents.Attach(agent); err != nil {
+		t.Fatal(err)
+	}
+	image := []byte{0xFF, 0xD8, 0xFF, 0xD9}
+	go func() {
+		cmd := <-agent.requests
+		agent.onReply(&pb.UpstreamControlReply{
+			RequestID: cmd.requestID,
+			Status:    pb.UpstreamReplyStatus_UPSTREAM_REPLY_STATUS_OK,
+			Snapshot:  image,
+			Taken:     42,
+		})
+	}()
+	snap, err := hub.Snapshot(context.Background(), &pb.SnapshotRequest{Id: id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(snap.Jpeg, image) || snap.Taken != 42 {
+		t.Fatal("unexpected snapshot", snap)
+	}
+
+	// The agent went offline, the latest snapshot is served
+	hub.agents.Detach(agent)
+	snap, err = hub.Snapshot(context.Background(), &pb.SnapshotRequest{Id: id})
+	if err != nil || snap.Taken != 42 {
+		t.Fatal("unexpected snapshot", snap, err)
+	}
+
+	// Only the streams of the user are listed, with their thumbnail
+	reply, err := hub.List(context.Background(), &pb.ListRequest{User: "u"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reply.Streams) != 2 {
+		t.Fatal("unexpected listing", reply.Streams)
+	}
+	if reply.Streams[0].Thumbnail.GetTaken() != 42 || reply.Streams[1].Thumbnail != nil {
+		t.Fatal("unexpected thumbnails", reply.Streams)
+	}
+	reply, err = hub.List(context.Background(), &pb.ListRequest{User: "u", Start: "a"})
+	if err != nil || len(reply.Streams) != 1 || reply.Streams[0].Id.Stream != "b" {
+		t.Fatal("unexpected listing", reply, err)
+	}
+}
+
+func TestSnapshot_Thumbnail(t *testing.T) {
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, image.NewRGBA(image.Rect(0, 0, 640, 360)), nil); err != nil {
+		t.Fatal(err)
+	}
+	store := newSnapshotStore()
+	store.Put("s", &pb.StreamSnapshot{Jpeg: encoded.Bytes(), Taken: 42})
+
+	// The snapshot is kept whole, the thumbnail fits in its box
+	snap, _ := store.Get("s")
+	thumb, ok := store.Thumbnail("s")
+	if !ok || !bytes.Equal(snap.Jpeg, encoded.Bytes()) || thumb.Taken != 42 {
+		t.Fatal("unexpected snapshot")
+	}
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(thumb.Jpeg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Width != thumbnailWidth || cfg.Height != 90 {
+		t.Fatal("unexpected thumbnail", cfg.Width, cfg.Height)
+	}
+}
+
+func TestSnapshot_ListPage(t *testing.T) {
+	hub := newTestHub()
+	total := int(getSliceSize) + 5
+	for i := 0; i < total; i++ {
+		for _, user := range []string{"u", "other"} {
+			id := fmt.Sprintf("%s-%03d", user, i)
+			if err := hub.registrar.Register(StreamRegistration{StreamID: id, User: user, Agent: "a"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// The pages never exceed the slice size
+	reply, err := hub.List(context.Background(), &pb.ListRequest{User: "u"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reply.Streams) != int(getSliceSize) {
+		t.Fatal("unexpected page", len(reply.Streams))
+	}
+	last := reply.Streams[len(reply.Streams)-1].Id.Stream
+	reply, err = hub.List(context.Background(), &pb.ListRequest{User: "u", Start: last})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reply.Streams) != total-int(getSliceSize) || reply.Streams[0].Id.Stream != fmt.Sprintf("u-%03d", getSliceSize) {
+		t.Fatal("unexpected page", len(reply.Streams))
+	}
+}
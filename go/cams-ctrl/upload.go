@@ -16,39 +16,265 @@
 package main
 
 import (
-	"context"
+	"expvar"
+	"fmt"
+	"io"
+	"time"
 
 	"github.com/jfsmig/cams/go/api/pb"
 	"github.com/jfsmig/cams/go/utils"
+	"github.com/pion/rtp"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
-func (hub *grpcHub) Play(ctx context.Context, req *pb.PlayRequest) (*pb.None, error) {
-	utils.Logger.Info().Str("action", "play").Interface("cam", req).Msg("view")
+// ingestSession holds the state of a streaming session of a single stream,
+// among the sessions multiplexed on the upload of an agent.
+type ingestSession struct {
+	user     string
+	agentID  AgentID
+	streamID string
 
-	return &pb.None{}, hub.viewerStreamAction(req.Id.User, func(a *AgentTwin) error {
-		return a.Play(req.Id.Stream)
-	})
+	// Set for a session of footage recorded by the agent, uploaded late
+	recorded bool
+
+	// Set when the quota refused the session: its frames are then ignored
+	rejected bool
+
+	// RTP payload types known as H264, and the clock rate of all the payload
+	// types, learned from the SDP banner
+	h264Types  map[uint8]bool
+	clockRates map[uint8]uint32
+
+	// The statistics of each media, by media index
+	medias map[uint32]*mediaStats
+
+	// Set when a packet has been dropped: all the packets are then dropped until
+	// the next keyframe, since they cannot be decoded anyway.
+	throttled bool
+
+	// The RTP timestamp of the latest keyframe: all the packets of its access
+	// unit, e.g. the fragments of the IDR slice, get the credit of a keyframe
+	keyUnit   uint32
+	inKeyUnit bool
 }
 
-func (hub *grpcHub) Pause(ctx context.Context, req *pb.PauseRequest) (*pb.None, error) {
-	utils.Logger.Info().Str("action", "pause").Interface("cam", req).Msg("view")
+// MediaUpload consumes the frames of all the streams of an agent. Each stream
+// is delimited by an OPEN and a CLOSE frame. Opening a stream registered to
+// another agent ends the upload.
+func (hub *grpcHub) MediaUpload(stream pb.Uploader_MediaUploadServer) error {
+	user, agentID, err := agentIdentity(stream.Context())
+	if err != nil {
+		utils.Logger.Warn().Str("action", "check").Err(err).Msg("hub upload")
+		return err
+	}
+
+	utils.Logger.Trace().Str("user", user).Str("agent", string(agentID)).Str("action", "start").Msg("hub upload")
+
+	// The sequence number of the last frame, to detect the frames dropped by the agent
+	var lastSequence uint64
+
+	sessions := make(map[ingestKey]*ingestSession)
 
-	return &pb.None{}, hub.viewerStreamAction(req.Id.User, func(a *AgentTwin) error {
-		return a.Stop(req.Id.Stream)
-	})
+	// The streams whose frames came unannounced, reported once
+	unknown := make(map[ingestKey]bool)
+	defer func() {
+		for _, sess := range sessions {
+			hub.closeIngest(sess)
+		}
+	}()
+
+	for {
+		frame, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&pb.None{})
+		}
+		if err != nil {
+			return err
+		}
+
+		if frame.Sequence > 0 {
+			if lastSequence > 0 && frame.Sequence > lastSequence+1 {
+				metricIngestGaps.Add(int64(frame.Sequence - lastSequence - 1))
+			}
+			lastSequence = frame.Sequence
+		}
+
+		key := ingestKey{streamID: frame.StreamID, recorded: frame.Recorded}
+		switch frame.Type {
+		case pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_OPEN:
+			if err = hub.checkIngestOwner(user, agentID, frame.StreamID); err != nil {
+				utils.Logger.Warn().Str("user", user).Str("agent", string(agentID)).Str("stream", frame.StreamID).Err(err).Msg("hub upload")
+				return err
+			}
+			if sess, ok := sessions[key]; ok {
+				hub.closeIngest(sess)
+			}
+			sessions[key] = hub.openIngest(user, agentID, frame.StreamID, frame.Recorded)
+			delete(unknown, key)
+		case pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_CLOSE:
+			if sess, ok := sessions[key]; ok {
+				hub.closeIngest(sess)
+				delete(sessions, key)
+			}
+		default:
+			// A faulty stream must not tear down the others multiplexed on the upload
+			sess, ok := sessions[key]
+			if !ok {
+				if !unknown[key] {
+					unknown[key] = true
+					utils.Logger.Warn().Str("user", user).Str("stream", frame.StreamID).Str("action", "drop").Msg("hub upload: stream not open")
+				}
+				metricIngestPackets.Add("dropped", 1)
+				continue
+			}
+			if sess.rejected {
+				metricIngestPackets.Add("dropped", 1)
+				continue
+			}
+			hub.onMediaFrame(sess, frame)
+		}
+	}
+}
+
+// ingestKey identifies a session in an upload: the live and the recorded
+// sessions of a stream run side by side.
+type ingestKey struct {
+	streamID string
+	recorded bool
 }
 
-func (hub *grpcHub) viewerStreamAction(agentId string, action func(*AgentTwin) error) error {
-	if !hub.agents.Has(AgentID(agentId)) {
-		return status.Error(codes.NotFound, "agents not found")
+// checkIngestOwner refuses the upload of a stream registered to another user or
+// another agent. A stream not registered yet is accepted, the registration of
+// the agent may still be on its way.
+func (hub *grpcHub) checkIngestOwner(user string, agentID AgentID, streamID string) error {
+	record, ok := hub.registrar.Get(streamID)
+	if ok && (record.User != user || record.Agent != agentID) {
+		return status.Error(codes.PermissionDenied, "stream owned by another agent")
 	}
-	agent, _ := hub.agents.Get(AgentID(agentId))
+	return nil
+}
 
-	if err := action(agent); err != nil {
-		return err
+// openIngest starts a streaming session. A live session holds a quota slot until
+// it is closed, and a live session refused by the quota remains known, to ignore
+// its frames.
+func (hub *grpcHub) openIngest(user string, agentID AgentID, streamID string, recorded bool) *ingestSession {
+	sess := &ingestSession{
+		user:       user,
+		agentID:    agentID,
+		streamID:   streamID,
+		recorded:   recorded,
+		h264Types:  map[uint8]bool{},
+		clockRates: map[uint8]uint32{},
+		medias:     map[uint32]*mediaStats{},
+	}
+	if recorded {
+		utils.Logger.Trace().Str("user", user).Str("stream", streamID).Str("action", "open recorded").Msg("hub upload")
+	} else if err := hub.quotas.AcquireStream(user, agentID); err != nil {
+		utils.Logger.Warn().Str("user", user).Str("stream", streamID).Err(err).Msg("hub upload")
+		sess.rejected = true
 	} else {
-		return nil
+		utils.Logger.Trace().Str("user", user).Str("stream", streamID).Str("action", "open").Msg("hub upload")
+	}
+	return sess
+}
+
+func (hub *grpcHub) closeIngest(sess *ingestSession) {
+	if !sess.rejected && !sess.recorded {
+		hub.quotas.ReleaseStream(sess.user, sess.agentID)
+	}
+	for idx := range sess.medias {
+		metricIngestJitter.Delete(sess.mediaKey(idx))
+	}
+	utils.Logger.Trace().Str("user", sess.user).Str("stream", sess.streamID).Str("action", "close").Msg("hub upload")
+}
+
+// onMediaFrame accounts a frame of an open session. The frames beyond the
+// quotas are dropped.
+func (hub *grpcHub) onMediaFrame(sess *ingestSession, frame *pb.DownstreamMediaFrame) {
+	size := len(frame.Payload)
+	metricIngestBytes.Add(int64(size))
+
+	switch frame.Type {
+	case pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_SDP:
+		if types, err := utils.H264PayloadTypes(string(frame.Payload)); err != nil {
+			utils.Logger.Debug().Str("stream", sess.streamID).Err(err).Msg("hub upload sdp")
+		} else {
+			sess.h264Types = types
+		}
+		if rates, err := utils.ClockRates(string(frame.Payload)); err == nil {
+			sess.clockRates = rates
+		}
+	case pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_RTP:
+		// Trust the agent to flag the keyframes, and check by ourselves otherwise
+		keyframe := frame.Keyframe
+		var pkt rtp.Packet
+		if err := pkt.Unmarshal(frame.Payload); err == nil {
+			if !keyframe && sess.h264Types[pkt.PayloadType] {
+				keyframe = utils.IsKeyFrameH264(pkt.Payload)
+			}
+			keyframe = sess.keyframeUnit(pkt.Timestamp, keyframe)
+			if frame.ReceivedAt > 0 && !sess.recorded {
+				sess.observe(frame.MediaIndex, &pkt, time.UnixMicro(frame.ReceivedAt))
+			}
+		} else if keyframe {
+			sess.throttled = false
+		}
+		admit, admitted := hub.quotas.AdmitPacket, "admitted"
+		if sess.recorded {
+			// The agent paces the upload of its recordings, within their own budget
+			admit, admitted = hub.quotas.AdmitRecorded, "recorded"
+		}
+		if sess.throttled || !admit(sess.user, sess.agentID, size, keyframe) {
+			sess.throttled = true
+			metricIngestPackets.Add("dropped", 1)
+			metricQuotaRejections.Add(quotaKindBitrate, 1)
+			return
+		}
+		metricIngestPackets.Add(admitted, 1)
+	}
+
+	// The hub has no retention policy yet: every admitted frame is accounted as
+	// stored, and the frames beyond the quota are dropped.
+	if err := hub.quotas.Store(sess.user, sess.agentID, uint64(size)); err != nil {
+		metricIngestPackets.Add("dropped", 1)
+	}
+}
+
+// keyframeUnit tells if the RTP packet gets the credit of a keyframe, given its
+// timestamp and whether it starts a keyframe. A new keyframe ends the throttling,
+// while the rest of its access unit stays dropped once one of its packets was.
+func (sess *ingestSession) keyframeUnit(timestamp uint32, start bool) bool {
+	if start && (!sess.inKeyUnit || timestamp != sess.keyUnit) {
+		sess.keyUnit, sess.inKeyUnit = timestamp, true
+		sess.throttled = false
+		return true
+	}
+	return sess.inKeyUnit && timestamp == sess.keyUnit
+}
+
+// mediaStats gathers the statistics of a media of a stream
+type mediaStats struct {
+	jitter jitterEstimator
+	metric *expvar.Int
+}
+
+func (sess *ingestSession) mediaKey(mediaIndex uint32) string {
+	return fmt.Sprintf("%s/%d", sess.streamID, mediaIndex)
+}
+
+// observe accounts the reception of a RTP packet by the agent
+func (sess *ingestSession) observe(mediaIndex uint32, pkt *rtp.Packet, receivedAt time.Time) {
+	clockRate := sess.clockRates[pkt.PayloadType]
+	if clockRate <= 0 {
+		return
+	}
+	stats, ok := sess.medias[mediaIndex]
+	if !ok {
+		stats = &mediaStats{metric: new(expvar.Int)}
+		sess.medias[mediaIndex] = stats
+		metricIngestJitter.Set(sess.mediaKey(mediaIndex), stats.metric)
 	}
+	stats.jitter.update(pkt.Timestamp, receivedAt, clockRate)
+	stats.metric.Set(stats.jitter.duration(clockRate).Microseconds())
 }
@@ -0,0 +1,288 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"expvar"
+	"io"
+	"testing"
+
+	"github.com/jfsmig/cams/go/api/pb"
+	"github.com/jfsmig/cams/go/utils"
+	"github.com/pion/rtp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// fakeUpload replays a fixed sequence of frames to MediaUpload
+type fakeUpload struct {
+	grpc.ServerStream
+	ctx    context.Context
+	frames []*pb.DownstreamMediaFrame
+}
+
+func (f *fakeUpload) Context() context.Context { return f.ctx }
+
+func (f *fakeUpload) SendAndClose(*pb.None) error { return nil }
+
+func (f *fakeUpload) Recv() (*pb.DownstreamMediaFrame, error) {
+	if len(f.frames) <= 0 {
+		return nil, io.EOF
+	}
+	frame := f.frames[0]
+	f.frames = f.frames[1:]
+	return frame, nil
+}
+
+func newFakeUpload(frames ...*pb.DownstreamMediaFrame) *fakeUpload {
+	md := metadata.Pairs(utils.KeyUser, "u", utils.KeyAgent, "a")
+	return &fakeUpload{ctx: metadata.NewIncomingContext(context.Background(), md), frames: frames}
+}
+
+// ingestPackets reads the counter of the packets of the given kind
+func ingestPackets(kind string) int64 {
+	if v, ok := metricIngestPackets.Get(kind).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+func mediaFrame(frameType pb.DownstreamMediaFrameType, streamID string) *pb.DownstreamMediaFrame {
+	return &pb.DownstreamMediaFrame{Type: frameType, StreamID: streamID, Payload: []byte{0}}
+}
+
+func TestUpload_Multiplexed(t *testing.T) {
+	hub := newTestHub()
+	hub.quotas = NewQuotaManager(QuotaConfig{Agent: QuotaLimits{MaxStreams: 1}})
+
+	err := hub.MediaUpload(newFakeUpload(
+		mediaFrame(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_OPEN, "s0"),
+		// Refused by the quota, its frames are ignored
+		mediaFrame(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_OPEN, "s1"),
+		mediaFrame(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_RTP, "s0"),
+		mediaFrame(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_RTP, "s1"),
+		mediaFrame(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_CLOSE, "s0"),
+		// The slot of the closed stream is available again
+		mediaFrame(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_CLOSE, "s1"),
+		mediaFrame(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_OPEN, "s1"),
+		mediaFrame(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_RTP, "s1"),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// All the slots are released at the end of the upload
+	if err = hub.quotas.AcquireStream("u", "a"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpload_NotOpen(t *testing.T) {
+	hub := newTestHub()
+	dropped, admitted := ingestPackets("dropped"), ingestPackets("admitted")
+
+	// The frames of a stream not open are dropped, the other streams go on
+	err := hub.MediaUpload(newFakeUpload(
+		mediaFrame(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_OPEN, "s0"),
+		mediaFrame(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_RTP, "s1"),
+		mediaFrame(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_RTP, "s1"),
+		mediaFrame(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_RTP, "s0"),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ingestPackets("dropped")-dropped != 2 || ingestPackets("admitted")-admitted != 1 {
+		t.Fatal("unexpected packets")
+	}
+}
+
+func TestUpload_Owner(t *testing.T) {
+	hub := newTestHub()
+	for _, reg := range []StreamRegistration{
+		{StreamID: "mine", User: "u", Agent: "a"},
+		{StreamID: "shop", User: "u", Agent: "shop"},
+		{StreamID: "other", User: "other", Agent: "x"},
+	} {
+		if err := hub.registrar.Register(reg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The streams of the agent, and those not registered yet
+	err := hub.MediaUpload(newFakeUpload(
+		mediaFrame(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_OPEN, "mine"),
+		mediaFrame(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_OPEN, "new"),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Neither another agent of the user nor another user
+	for _, streamID := range []string{"shop", "other"} {
+		admitted := ingestPackets("admitted")
+		err = hub.MediaUpload(newFakeUpload(
+			mediaFrame(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_OPEN, streamID),
+			mediaFrame(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_RTP, streamID),
+		))
+		if status.Code(err) != codes.PermissionDenied {
+			t.Fatal("unexpected error", streamID, err)
+		}
+		if ingestPackets("admitted") != admitted {
+			t.Fatal("frame admitted", streamID)
+		}
+	}
+}
+
+func TestUpload_SequenceGaps(t *testing.T) {
+	hub := newTestHub()
+	frames := []*pb.DownstreamMediaFrame{
+		mediaFrame(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_OPEN, "s0"),
+		mediaFrame(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_RTP, "s0"),
+		mediaFrame(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_RTP, "s0"),
+	}
+	// The agent dropped the frames 3 and 4
+	for i, seq := range []uint64{1, 2, 5} {
+		frames[i].Sequence = seq
+	}
+
+	before := metricIngestGaps.Value()
+	if err := hub.MediaUpload(newFakeUpload(frames...)); err != nil {
+		t.Fatal(err)
+	}
+	if gaps := metricIngestGaps.Value() - before; gaps != 2 {
+		t.Fatal("unexpected gaps", gaps)
+	}
+}
+
+func TestUpload_Recorded(t *testing.T) {
+	hub := newTestHub()
+	hub.quotas = NewQuotaManager(QuotaConfig{Agent: QuotaLimits{MaxStreams: 1, MaxBitrate: 8}})
+
+	recorded := func(frameType pb.DownstreamMediaFrameType) *pb.DownstreamMediaFrame {
+		frame := mediaFrame(frameType, "s0")
+		frame.Recorded = true
+		return frame
+	}
+	before := ingestPackets("recorded")
+	err := hub.MediaUpload(newFakeUpload(
+		mediaFrame(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_OPEN, "s0"),
+		// The recorded session runs aside the live one, without its own stream slot
+		recorded(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_OPEN),
+		// ... and is not throttled by the budget of the live streams
+		recorded(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_RTP),
+		recorded(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_RTP),
+		recorded(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_CLOSE),
+		mediaFrame(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_CLOSE, "s0"),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count := ingestPackets("recorded") - before; count != 2 {
+		t.Fatal("unexpected recorded packets", count)
+	}
+}
+
+func TestUpload_RecordedQuota(t *testing.T) {
+	hub := newTestHub()
+	// 8000 bits per second, i.e. a budget of 1000 bytes
+	hub.quotas = NewQuotaManager(QuotaConfig{Agent: QuotaLimits{MaxRecordedBitrate: 8000}})
+
+	recorded := func(frameType pb.DownstreamMediaFrameType, size int) *pb.DownstreamMediaFrame {
+		frame := mediaFrame(frameType, "s0")
+		frame.Recorded = true
+		frame.Payload = make([]byte, size)
+		return frame
+	}
+	before := ingestPackets("recorded")
+	err := hub.MediaUpload(newFakeUpload(
+		recorded(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_OPEN, 1),
+		recorded(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_RTP, 900),
+		// Flagging live media as recorded doesn't evade the quota
+		recorded(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_RTP, 900),
+		recorded(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_CLOSE, 1),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count := ingestPackets("recorded") - before; count != 1 {
+		t.Fatal("unexpected recorded packets", count)
+	}
+}
+
+// h264Frame is a RTP frame of H264 of the given size
+func h264Frame(streamID string, timestamp uint32, payload []byte, size int) *pb.DownstreamMediaFrame {
+	pkt := rtp.Packet{
+		Header:  rtp.Header{Version: 2, PayloadType: 96, Timestamp: timestamp},
+		Payload: append(payload, make([]byte, size-len(payload))...),
+	}
+	raw, _ := pkt.Marshal()
+	return &pb.DownstreamMediaFrame{Type: pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_RTP, StreamID: streamID, Payload: raw}
+}
+
+func TestUpload_KeyframeFragments(t *testing.T) {
+	hub := newTestHub()
+	// 8000 bits per second, i.e. a budget of 1000 bytes and as much for the keyframes
+	hub.quotas = NewQuotaManager(QuotaConfig{Agent: QuotaLimits{MaxBitrate: 8000}})
+
+	sdp := mediaFrame(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_SDP, "s0")
+	sdp.Payload = []byte("v=0\r\no=- 0 0 IN IP4 127.0.0.1\r\ns=cam\r\nt=0 0\r\n" +
+		"m=video 0 RTP/AVP 96\r\na=rtpmap:96 H264/90000\r\na=fmtp:96 packetization-mode=1\r\n")
+
+	// FU-A fragments of an IDR slice: start, middle and end
+	fuaStart, fuaMiddle, fuaEnd := []byte{28, 0x85}, []byte{28, 0x05}, []byte{28, 0x45}
+	nonIDR := []byte{1}
+
+	before := ingestPackets("admitted")
+	err := hub.MediaUpload(newFakeUpload(
+		mediaFrame(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_OPEN, "s0"),
+		sdp,
+		h264Frame("s0", 1, nonIDR, 900),
+		// Out of the budget, the session is throttled
+		h264Frame("s0", 2, nonIDR, 200),
+		// The whole IDR slice is admitted with the credit of the keyframes
+		h264Frame("s0", 3, fuaStart, 300),
+		h264Frame("s0", 3, fuaMiddle, 300),
+		h264Frame("s0", 3, fuaEnd, 300),
+		// Beyond the credit, the rest of the slice is dropped with its start
+		h264Frame("s0", 4, fuaStart, 900),
+		h264Frame("s0", 4, fuaEnd, 10),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count := ingestPackets("admitted") - before; count != 4 {
+		t.Fatal("unexpected admitted packets", count)
+	}
+}
+
+func TestUpload_StoredQuota(t *testing.T) {
+	hub := newTestHub()
+	hub.quotas = NewQuotaManager(QuotaConfig{Agent: QuotaLimits{MaxStored: 1}})
+
+	// The frames beyond the quota are dropped, the upload goes on
+	err := hub.MediaUpload(newFakeUpload(
+		mediaFrame(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_OPEN, "s0"),
+		mediaFrame(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_RTP, "s0"),
+		mediaFrame(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_RTP, "s0"),
+		mediaFrame(pb.DownstreamMediaFrameType_DOWNSTREAM_MEDIA_FRAME_TYPE_CLOSE, "s0"),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
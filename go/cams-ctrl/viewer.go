@@ -17,34 +17,245 @@ package main
 
 import (
 	"context"
+	"time"
 
 	"github.com/jfsmig/cams/go/api/pb"
 	"github.com/jfsmig/cams/go/utils"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 )
 
+// How often the viewer sessions are checked for expiration
+const viewersSweepPeriod = time.Second
+
+// Play registers the calling viewer session on the stream, or renews it. The
+// session expires if it is not renewed within the viewer TTL. The agent is
+// asked to play the stream upon its first viewer only.
 func (hub *grpcHub) Play(ctx context.Context, req *pb.PlayRequest) (*pb.None, error) {
 	utils.Logger.Info().Str("action", "play").Interface("cam", req).Msg("view")
 
-	return &pb.None{}, hub.viewerStreamAction(req.Id.User, func(a *AgentTwin) error {
-		return a.Play(req.Id.Stream)
+	record, err := hub.lookupStream(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	viewer := viewerID(ctx)
+	deadline := time.Now().Add(hub.viewerTTL)
+	before, err := hub.desired.Update(req.Id.Stream, req.Id.User, func(state *DesiredState) error {
+		if len(state.Viewers) <= 0 {
+			state.Agent = record.Agent
+		}
+		if !state.HasViewer(viewer) {
+			if err := hub.quotas.AcquireViewer(state.User, state.Agent); err != nil {
+				return err
+			}
+		}
+		state.SetViewer(viewer, deadline)
+		state.IdleSince = time.Time{}
+		state.Playing = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if before.Playing {
+		return &pb.None{}, nil
+	}
+
+	err = hub.viewerStreamAction(req.Id, func(a *AgentTwin) error {
+		return a.Play(ctx, req.Id.Stream)
 	})
+	if err != nil {
+		hub.restoreDesired(viewer, before)
+	}
+	return &pb.None{}, err
 }
 
+// Pause ends the calling viewer session. The stream keeps playing for the other
+// viewers, and is stopped once it lingered without any viewer.
 func (hub *grpcHub) Pause(ctx context.Context, req *pb.PauseRequest) (*pb.None, error) {
 	utils.Logger.Info().Str("action", "pause").Interface("cam", req).Msg("view")
 
-	return &pb.None{}, hub.viewerStreamAction(req.Id.User, func(a *AgentTwin) error {
-		return a.Stop(req.Id.Stream)
+	viewer := viewerID(ctx)
+	_, err := hub.desired.Update(req.Id.Stream, req.Id.User, func(state *DesiredState) error {
+		if state.RemoveViewer(viewer) {
+			hub.quotas.ReleaseViewer(state.User, state.Agent)
+			if len(state.Viewers) <= 0 && state.Playing {
+				state.IdleSince = time.Now()
+			}
+		}
+		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	if hub.viewerLinger <= 0 {
+		hub.sweepViewers(ctx, time.Now())
+	}
+	return &pb.None{}, nil
 }
 
-func (hub *grpcHub) viewerStreamAction(agentId string, action func(*AgentTwin) error) error {
-	if !hub.agents.Has(AgentID(agentId)) {
-		return status.Error(codes.NotFound, "agents not found")
+// restoreDesired cancels the effect of a Play refused by the agent
+func (hub *grpcHub) restoreDesired(viewer string, before DesiredState) {
+	_, _ = hub.desired.Update(before.StreamID, before.User, func(state *DesiredState) error {
+		if !before.HasViewer(viewer) && state.RemoveViewer(viewer) {
+			hub.quotas.ReleaseViewer(state.User, state.Agent)
+		}
+		state.Playing = before.Playing
+		state.IdleSince = before.IdleSince
+		state.Agent = before.Agent
+		return nil
+	})
+}
+
+// runViewersJanitor periodically expires the viewer sessions and stops the idle streams
+func (hub *grpcHub) runViewersJanitor(ctx context.Context) {
+	ticker := time.NewTicker(viewersSweepPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			hub.sweepViewers(ctx, now)
+		}
+	}
+}
+
+// sweepViewers drops the viewer sessions that have not been renewed in time, then
+// stops the streams that have been left without viewers for the linger period.
+func (hub *grpcHub) sweepViewers(ctx context.Context, now time.Time) {
+	for _, candidate := range hub.desired.List() {
+		if !hub.needsSweep(candidate, now) {
+			continue
+		}
+
+		stop := false
+		_, _ = hub.desired.Update(candidate.StreamID, candidate.User, func(state *DesiredState) error {
+			for viewer, deadline := range state.Viewers {
+				if now.After(deadline) {
+					utils.Logger.Info().Str("stream", state.StreamID).Str("viewer", viewer).Str("action", "expire").Msg("view")
+					state.RemoveViewer(viewer)
+					hub.quotas.ReleaseViewer(state.User, state.Agent)
+				}
+			}
+			if state.Playing && len(state.Viewers) <= 0 {
+				if state.IdleSince.IsZero() {
+					state.IdleSince = now
+				}
+				if now.Sub(state.IdleSince) >= hub.viewerLinger {
+					state.Playing = false
+					state.IdleSince = time.Time{}
+					stop = true
+				}
+			}
+			return nil
+		})
+
+		if stop {
+			utils.Logger.Info().Str("stream", candidate.StreamID).Str("action", "idle").Msg("view")
+			id := &pb.StreamId{User: candidate.User, Stream: candidate.StreamID}
+			err := hub.viewerStreamAction(id, func(a *AgentTwin) error {
+				return a.Stop(ctx, candidate.StreamID)
+			})
+			if err != nil {
+				utils.Logger.Warn().Str("stream", candidate.StreamID).Str("action", "idle").Err(err).Msg("view")
+			}
+		}
+	}
+}
+
+func (hub *grpcHub) needsSweep(state DesiredState, now time.Time) bool {
+	for _, deadline := range state.Viewers {
+		if now.After(deadline) {
+			return true
+		}
+	}
+	return state.Playing && len(state.Viewers) <= 0
+}
+
+// Trigger asks the agent of the stream to upload its pre-event buffer, then its
+// live media for the given duration. It requires the agent to be connected.
+func (hub *grpcHub) Trigger(ctx context.Context, req *pb.TriggerRequest) (*pb.None, error) {
+	utils.Logger.Info().Str("action", "trigger").Interface("cam", req).Msg("view")
+
+	record, err := hub.lookupStream(req.Id)
+	if err != nil {
+		return nil, err
+	}
+	agent, ok := hub.agents.Get(record.Agent)
+	if !ok {
+		return nil, status.Error(codes.Unavailable, "agent offline")
+	}
+	if err := agent.Trigger(ctx, req.Id.Stream, req.Duration); err != nil {
+		return nil, err
+	}
+	return &pb.None{}, nil
+}
+
+// ReloadAgent asks the agent to reload its configuration
+func (hub *grpcHub) ReloadAgent(ctx context.Context, req *pb.ReloadAgentRequest) (*pb.None, error) {
+	utils.Logger.Info().Str("action", "reload").Str("user", req.User).Str("agent", req.Agent).Msg("view")
+
+	agent, ok := hub.agents.Get(AgentID(req.Agent))
+	if !ok || agent.user != req.User {
+		return nil, status.Error(codes.Unavailable, "agent offline")
+	}
+	if err := agent.Reload(ctx); err != nil {
+		return nil, err
+	}
+	return &pb.None{}, nil
+}
+
+func (hub *grpcHub) Status(ctx context.Context, req *pb.StatusRequest) (*pb.StreamStatus, error) {
+	out := &pb.StreamStatus{Id: req.Id}
+
+	record, err := hub.lookupStream(req.Id)
+	if err != nil {
+		return nil, err
+	}
+	out.Metadata = record.Metadata
+	agent, ok := hub.agents.Get(record.Agent)
+	if !ok {
+		return out, nil
+	}
+	out.Online = true
+
+	// The agent may not have reported the camera yet
+	reported, ok := agent.CameraStatus(req.Id.Stream)
+	if !ok {
+		return out, nil
+	}
+	out.Camera = reported.status
+	out.Updated = reported.updated.UnixMilli()
+	return out, nil
+}
+
+// lookupStream locates the registration of the stream, that tells the agent owning it
+func (hub *grpcHub) lookupStream(id *pb.StreamId) (StreamRecord, error) {
+	record, ok := hub.registrar.Get(id.Stream)
+	if !ok || record.User != id.User {
+		return StreamRecord{}, status.Error(codes.NotFound, "stream not found")
+	}
+	return record, nil
+}
+
+// viewerStreamAction applies the action to the agent owning the stream, if it is connected.
+// Otherwise, the desired state of the stream will be replayed on its reconnection.
+func (hub *grpcHub) viewerStreamAction(id *pb.StreamId, action func(*AgentTwin) error) error {
+	record, err := hub.lookupStream(id)
+	if err != nil {
+		return err
+	}
+
+	agent, ok := hub.agents.Get(record.Agent)
+	if !ok {
+		utils.Logger.Info().Str("user", id.User).Str("agent", string(record.Agent)).Str("stream", id.Stream).Str("action", "defer").Msg("view")
+		return nil
 	}
-	agent, _ := hub.agents.Get(AgentID(agentId))
 
 	if err := action(agent); err != nil {
 		return err
@@ -52,3 +263,17 @@ func (hub *grpcHub) viewerStreamAction(agentId string, action func(*AgentTwin) e
 		return nil
 	}
 }
+
+// viewerID identifies the client calling the Viewer service, with the
+// session ID it provided or its network address as a fallback.
+func viewerID(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if sessionID := md.Get("session-id"); len(sessionID) > 0 {
+			return sessionID[0]
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		return p.Addr.String()
+	}
+	return ""
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jfsmig/cams/go/api/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func newTestHub() *grpcHub {
+	return &grpcHub{
+		registrar:    NewRegistrarInMem(),
+		agents:       newAgentRegistry(),
+		quotas:       NewQuotaManager(QuotaConfig{}),
+		desired:      NewDesiredStoreInMem(),
+		events:       newEventStore(),
+		rights:       newAccessRights(RightsConfig{}),
+		snapshots:    newSnapshotStore(),
+		viewerTTL:    time.Minute,
+		viewerLinger: 10 * time.Second,
+	}
+}
+
+func viewerContext(session string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("session-id", session))
+}
+
+func TestViewers_RefCount(t *testing.T) {
+	hub := newTestHub()
+	id := &pb.StreamId{User: "u", Stream: "s"}
+	if err := hub.registrar.Register(StreamRegistration{StreamID: "s", User: "u", Agent: "a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, session := range []string{"v0", "v1"} {
+		if _, err := hub.Play(viewerContext(session), &pb.PlayRequest{Id: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// One viewer leaves, the stream still plays for the other
+	if _, err := hub.Pause(viewerContext("v0"), &pb.PauseRequest{Id: id}); err != nil {
+		t.Fatal(err)
+	}
+	hub.sweepViewers(context.Background(), time.Now().Add(hub.viewerLinger))
+	if _, ok := hub.desired.Get("s"); !ok {
+		t.Fatal("stream stopped with a viewer left")
+	}
+
+	// The other viewer vanished: its session expired, the stream then lingers
+	now := time.Now().Add(2 * time.Minute)
+	hub.sweepViewers(context.Background(), now)
+	state, ok := hub.desired.Get("s")
+	if !ok || !state.Playing || len(state.Viewers) > 0 {
+		t.Fatal("unexpected state", state)
+	}
+
+	// Once the linger period elapsed, the stream is stopped
+	hub.sweepViewers(context.Background(), now.Add(hub.viewerLinger))
+	if state, ok := hub.desired.Get("s"); ok {
+		t.Fatal("idle stream still desired", state)
+	}
+}
+
+func TestViewers_Status(t *testing.T) {
+	hub := newTestHub()
+	id := &pb.StreamId{User: "u", Stream: "s"}
+	req := &pb.StatusRequest{Id: id}
+
+	if _, err := hub.Status(context.Background(), req); status.Code(err) != codes.NotFound {
+		t.Fatal("unexpected error", err)
+	}
+
+	md := &pb.CameraMetadata{Manufacturer: "acme"}
+	if err := hub.registrar.Register(StreamRegistration{StreamID: "s", User: "u", Agent: "a", Metadata: md}); err != nil {
+		t.Fatal(err)
+	}
+	// Another user cannot see the stream
+	if _, err := hub.Status(context.Background(), &pb.StatusRequest{Id: &pb.StreamId{User: "other", Stream: "s"}}); status.Code(err) != codes.NotFound {
+		t.Fatal("unexpected error", err)
+	}
+
+	// The agent is offline, the registration is still known
+	out, err := hub.Status(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Online || out.Camera != nil || out.Metadata.GetManufacturer() != "acme" {
+		t.Fatal("unexpected status", out)
+	}
+
+	agent := NewAgentTwin("a", "u", nil)
+	if _, err = hub.agents.Attach(agent); err != nil {
+		t.Fatal(err)
+	}
+	// Online, nothing reported yet
+	if out, err = hub.Status(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if !out.Online || out.Camera != nil || out.Updated != 0 {
+		t.Fatal("unexpected status", out)
+	}
+
+	// The last status reported by the agent
+	agent.onStatus(&pb.CameraStatus{StreamID: "s", State: pb.CameraState_CAMERA_STATE_PLAYING, LastError: "describe: timeout"})
+	agent.onStatus(&pb.CameraStatus{StreamID: "s", State: pb.CameraState_CAMERA_STATE_PLAYING})
+	if out, err = hub.Status(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if !out.Online || out.Camera.State != pb.CameraState_CAMERA_STATE_PLAYING || len(out.Camera.LastError) != 0 || out.Updated <= 0 {
+		t.Fatal("unexpected status", out)
+	}
+}
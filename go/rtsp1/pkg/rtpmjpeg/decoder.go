@@ -0,0 +1,164 @@
+package rtpmjpeg
+
+import (
+	"fmt"
+
+	"github.com/pion/rtp"
+
+	"github.com/jfsmig/cams/go/rtsp1/pkg/codecs/jpeg"
+)
+
+// Decoder is a RTP/M-JPEG decoder.
+// Specification: https://datatracker.ietf.org/doc/html/rfc2435
+type Decoder struct {
+	firstPacketReceived bool
+	fragments           [][]byte
+	fragmentsSize       int
+	firstJpegHeader     *headerJPEG
+	firstRestartHeader  *headerRestart
+	firstQTables        []byte
+}
+
+// Init initializes the decoder.
+func (d *Decoder) Init() {
+}
+
+func (d *Decoder) resetFragments() {
+	d.fragments = d.fragments[:0]
+	d.fragmentsSize = 0
+}
+
+// Decode decodes an image from a RTP packet. The image is returned as a
+// complete JPEG/JFIF file once its last fragment is received.
+func (d *Decoder) Decode(pkt *rtp.Packet) ([]byte, error) {
+	byts := pkt.Payload
+
+	var jh headerJPEG
+	n, err := jh.unmarshal(byts)
+	if err != nil {
+		d.resetFragments()
+		return nil, err
+	}
+	byts = byts[n:]
+
+	var rh *headerRestart
+	if jh.Type >= 64 {
+		rh = &headerRestart{}
+		n, err = rh.unmarshal(byts)
+		if err != nil {
+			d.resetFragments()
+			return nil, err
+		}
+		byts = byts[n:]
+	}
+
+	if jh.FragmentOffset == 0 {
+		d.resetFragments()
+		d.firstPacketReceived = true
+
+		if jh.Quantization >= 128 {
+			var qh headerQTable
+			n, err = qh.unmarshal(byts)
+			if err != nil {
+				return nil, err
+			}
+			byts = byts[n:]
+			d.firstQTables = qh.Tables
+		} else {
+			d.firstQTables = makeTables(jh.Quantization)
+		}
+
+		d.firstJpegHeader = &jh
+		d.firstRestartHeader = rh
+	} else {
+		if len(d.fragments) == 0 {
+			if !d.firstPacketReceived {
+				return nil, ErrNonStartingPacketAndNoPrevious
+			}
+			return nil, fmt.Errorf("received a non-starting fragment")
+		}
+
+		if int(jh.FragmentOffset) != d.fragmentsSize {
+			d.resetFragments()
+			return nil, fmt.Errorf("received wrong fragment")
+		}
+	}
+
+	// Copy the payload, since the packet buffer may be reused
+	d.fragments = append(d.fragments, append([]byte(nil), byts...))
+	d.fragmentsSize += len(byts)
+
+	if !pkt.Marker {
+		return nil, ErrMorePacketsNeeded
+	}
+
+	data := make([]byte, 0, d.fragmentsSize)
+	for _, frag := range d.fragments {
+		data = append(data, frag...)
+	}
+	d.resetFragments()
+
+	return d.image(data), nil
+}
+
+// image wraps the entropy-coded data with the headers implied by the RTP headers
+func (d *Decoder) image(data []byte) []byte {
+	var buf []byte
+
+	buf = jpeg.StartOfImage{}.Marshal(buf)
+
+	var dqt jpeg.DefineQuantizationTable
+	for id := 0; id*64 < len(d.firstQTables); id++ {
+		dqt.Tables = append(dqt.Tables, jpeg.QuantizationTable{
+			ID:   uint8(id),
+			Data: d.firstQTables[id*64 : (id+1)*64],
+		})
+	}
+	buf = dqt.Marshal(buf)
+
+	if d.firstRestartHeader != nil {
+		buf = jpeg.DefineRestartInterval{Interval: d.firstRestartHeader.Interval}.Marshal(buf)
+	}
+
+	buf = jpeg.StartOfFrame1{
+		Type:                   d.firstJpegHeader.Type,
+		Width:                  d.firstJpegHeader.Width,
+		Height:                 d.firstJpegHeader.Height,
+		QuantizationTableCount: uint8(len(dqt.Tables)),
+	}.Marshal(buf)
+
+	buf = jpeg.DefineHuffmanTable{
+		Codes:       lumDcCodelens,
+		Symbols:     lumDcSymbols,
+		TableNumber: 0,
+		TableClass:  0,
+	}.Marshal(buf)
+	buf = jpeg.DefineHuffmanTable{
+		Codes:       lumAcCodelens,
+		Symbols:     lumAcSymbols,
+		TableNumber: 0,
+		TableClass:  1,
+	}.Marshal(buf)
+	buf = jpeg.DefineHuffmanTable{
+		Codes:       chmDcCodelens,
+		Symbols:     chmDcSymbols,
+		TableNumber: 1,
+		TableClass:  0,
+	}.Marshal(buf)
+	buf = jpeg.DefineHuffmanTable{
+		Codes:       chmAcCodelens,
+		Symbols:     chmAcSymbols,
+		TableNumber: 1,
+		TableClass:  1,
+	}.Marshal(buf)
+
+	buf = jpeg.StartOfScan{}.Marshal(buf)
+
+	buf = append(buf, data...)
+
+	if len(data) < 2 || data[len(data)-2] != 0xFF || data[len(data)-1] != jpeg.MarkerEndOfImage {
+		buf = append(buf, []byte{0xFF, jpeg.MarkerEndOfImage}...)
+	}
+
+	return buf
+}
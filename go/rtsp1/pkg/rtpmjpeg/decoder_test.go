@@ -0,0 +1,115 @@
+package rtpmjpeg
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	stdjpeg "image/jpeg"
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+// splitJPEG extracts the quantization tables and the entropy-coded data of a
+// baseline JPEG produced by the standard library.
+func splitJPEG(t *testing.T, byts []byte) ([]byte, []byte) {
+	var tables []byte
+	for i := 2; i < len(byts); {
+		require.Equal(t, byte(0xFF), byts[i])
+		marker := byts[i+1]
+		length := int(byts[i+2])<<8 | int(byts[i+3])
+		segment := byts[i+4 : i+2+length]
+		switch marker {
+		case 0xDB:
+			for len(segment) > 0 {
+				tables = append(tables, segment[1:65]...)
+				segment = segment[65:]
+			}
+		case 0xDA:
+			data := byts[i+2+length:]
+			return tables, data[:len(data)-2]
+		}
+		i += 2 + length
+	}
+	t.Fatal("no scan")
+	return nil, nil
+}
+
+func testImage(t *testing.T, quality int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 48))
+	for x := 0; x < 64; x++ {
+		for y := 0; y < 48; y++ {
+			img.Set(x, y, color.RGBA{uint8(x * 4), uint8(y * 5), 128, 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, stdjpeg.Encode(&buf, img, &stdjpeg.Options{Quality: quality}))
+	return buf.Bytes()
+}
+
+func packetize(jh headerJPEG, qtables []byte, data []byte, size int) []*rtp.Packet {
+	var pkts []*rtp.Packet
+	for offset := 0; offset < len(data); offset += size {
+		end := offset + size
+		if end > len(data) {
+			end = len(data)
+		}
+		jh.FragmentOffset = uint32(offset)
+		payload := jh.marshal(nil)
+		if offset == 0 && qtables != nil {
+			payload = headerQTable{Tables: qtables}.marshal(payload)
+		}
+		payload = append(payload, data[offset:end]...)
+		pkts = append(pkts, &rtp.Packet{
+			Header:  rtp.Header{Version: 2, PayloadType: 26, Marker: end == len(data)},
+			Payload: payload,
+		})
+	}
+	return pkts
+}
+
+func decodeAll(t *testing.T, pkts []*rtp.Packet) []byte {
+	var d Decoder
+	d.Init()
+	for i, pkt := range pkts {
+		img, err := d.Decode(pkt)
+		if i < len(pkts)-1 {
+			require.Equal(t, ErrMorePacketsNeeded, err)
+		} else {
+			require.NoError(t, err)
+			return img
+		}
+	}
+	return nil
+}
+
+func TestDecodeInBandTables(t *testing.T) {
+	tables, data := splitJPEG(t, testImage(t, 90))
+	pkts := packetize(headerJPEG{Type: 1, Quantization: 255, Width: 64, Height: 48}, tables, data, 100)
+	require.Greater(t, len(pkts), 1)
+
+	img, err := stdjpeg.Decode(bytes.NewReader(decodeAll(t, pkts)))
+	require.NoError(t, err)
+	require.Equal(t, image.Rect(0, 0, 64, 48), img.Bounds())
+}
+
+func TestDecodeImpliedTables(t *testing.T) {
+	// The quality 50 of the standard library matches the unscaled tables
+	_, data := splitJPEG(t, testImage(t, 50))
+	pkts := packetize(headerJPEG{Type: 1, Quantization: 50, Width: 64, Height: 48}, nil, data, 100)
+
+	img, err := stdjpeg.Decode(bytes.NewReader(decodeAll(t, pkts)))
+	require.NoError(t, err)
+	require.Equal(t, image.Rect(0, 0, 64, 48), img.Bounds())
+}
+
+func TestDecodeMissingStart(t *testing.T) {
+	_, data := splitJPEG(t, testImage(t, 50))
+	pkts := packetize(headerJPEG{Type: 1, Quantization: 50, Width: 64, Height: 48}, nil, data, 100)
+
+	var d Decoder
+	d.Init()
+	_, err := d.Decode(pkts[1])
+	require.Equal(t, ErrNonStartingPacketAndNoPrevious, err)
+}
@@ -0,0 +1,194 @@
+package rtpmjpeg
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/jfsmig/cams/go/rtsp1/pkg/codecs/jpeg"
+)
+
+const (
+	rtpVersion            = 2
+	defaultPayloadMaxSize = 1460 // 1500 (UDP MTU) - 20 (IP header) - 8 (UDP header) - 12 (RTP header)
+	payloadType           = 26
+	clockRate             = 90000
+)
+
+func randUint32() uint32 {
+	var b [4]byte
+	_, _ = rand.Read(b[:])
+	return binary.BigEndian.Uint32(b[:])
+}
+
+// Encoder is a RTP/M-JPEG encoder.
+// It packetizes baseline JPEG images that use the standard Huffman tables,
+// like those of the image/jpeg package, and sends their quantization tables
+// in band.
+// Specification: https://datatracker.ietf.org/doc/html/rfc2435
+type Encoder struct {
+	// SSRC of packets (optional).
+	// It defaults to a random value.
+	SSRC *uint32
+
+	// initial sequence number of packets (optional).
+	// It defaults to a random value.
+	InitialSequenceNumber *uint16
+
+	// initial timestamp of packets (optional).
+	// It defaults to a random value.
+	InitialTimestamp *uint32
+
+	// maximum size of packet payloads (optional).
+	// It defaults to 1460.
+	PayloadMaxSize int
+
+	sequenceNumber uint16
+}
+
+// Init initializes the encoder.
+func (e *Encoder) Init() {
+	if e.SSRC == nil {
+		v := randUint32()
+		e.SSRC = &v
+	}
+	if e.InitialSequenceNumber == nil {
+		v := uint16(randUint32())
+		e.InitialSequenceNumber = &v
+	}
+	if e.InitialTimestamp == nil {
+		v := randUint32()
+		e.InitialTimestamp = &v
+	}
+	if e.PayloadMaxSize == 0 {
+		e.PayloadMaxSize = defaultPayloadMaxSize
+	}
+
+	e.sequenceNumber = *e.InitialSequenceNumber
+}
+
+// Encode encodes an image into RTP/M-JPEG packets.
+func (e *Encoder) Encode(image []byte, pts time.Duration) ([]*rtp.Packet, error) {
+	sof, tables, data, err := parseJPEG(image)
+	if err != nil {
+		return nil, err
+	}
+
+	if sof.Width > 2040 || sof.Height > 2040 || sof.Width%8 != 0 || sof.Height%8 != 0 {
+		return nil, fmt.Errorf("size %dx%d is not supported", sof.Width, sof.Height)
+	}
+
+	jh := headerJPEG{
+		Type:         sof.Type,
+		Quantization: 255,
+		Width:        sof.Width,
+		Height:       sof.Height,
+	}
+	ts := *e.InitialTimestamp + uint32(uint64(pts.Seconds()*clockRate))
+
+	var packets []*rtp.Packet
+	for offset, first := 0, true; first || offset < len(data); first = false {
+		jh.FragmentOffset = uint32(offset)
+		payload := jh.marshal(nil)
+		if first {
+			payload = headerQTable{Tables: tables}.marshal(payload)
+		}
+
+		n := e.PayloadMaxSize - len(payload)
+		if n <= 0 {
+			return nil, fmt.Errorf("payload max size %d is too small", e.PayloadMaxSize)
+		}
+		if n > len(data)-offset {
+			n = len(data) - offset
+		}
+		payload = append(payload, data[offset:offset+n]...)
+		offset += n
+
+		packets = append(packets, &rtp.Packet{
+			Header: rtp.Header{
+				Version:        rtpVersion,
+				PayloadType:    payloadType,
+				SequenceNumber: e.sequenceNumber,
+				Timestamp:      ts,
+				SSRC:           *e.SSRC,
+				Marker:         offset >= len(data),
+			},
+			Payload: payload,
+		})
+		e.sequenceNumber++
+	}
+
+	return packets, nil
+}
+
+// parseJPEG extracts the frame header, the quantization tables (luminance
+// then chrominance) and the entropy-coded data of a JPEG image.
+func parseJPEG(image []byte) (*jpeg.StartOfFrame1, []byte, []byte, error) {
+	if len(image) < 2 || image[0] != 0xFF || image[1] != jpeg.MarkerStartOfImage {
+		return nil, nil, nil, fmt.Errorf("SOI not found")
+	}
+	buf := image[2:]
+
+	var sof *jpeg.StartOfFrame1
+	qtables := make(map[uint8][]byte)
+
+	for {
+		if len(buf) < 4 || buf[0] != 0xFF {
+			return nil, nil, nil, fmt.Errorf("marker not found")
+		}
+		marker := buf[1]
+		length := int(buf[2])<<8 | int(buf[3])
+		if length < 2 || len(buf) < 2+length {
+			return nil, nil, nil, fmt.Errorf("marker %x is truncated", marker)
+		}
+		body := buf[4 : 2+length]
+		buf = buf[2+length:]
+
+		switch marker {
+		case jpeg.MarkerDefineQuantizationTable:
+			var dqt jpeg.DefineQuantizationTable
+			if err := dqt.Unmarshal(body); err != nil {
+				return nil, nil, nil, err
+			}
+			for _, t := range dqt.Tables {
+				qtables[t.ID] = t.Data
+			}
+
+		case jpeg.MarkerStartOfFrame1:
+			sof = &jpeg.StartOfFrame1{}
+			if err := sof.Unmarshal(body); err != nil {
+				return nil, nil, nil, err
+			}
+
+		case jpeg.MarkerDefineRestartInterval:
+			return nil, nil, nil, fmt.Errorf("restart markers are not supported")
+
+		case jpeg.MarkerStartOfScan:
+			var sos jpeg.StartOfScan
+			if err := sos.Unmarshal(body); err != nil {
+				return nil, nil, nil, err
+			}
+			if sof == nil {
+				return nil, nil, nil, fmt.Errorf("SOF not found")
+			}
+			if len(buf) < 2 || buf[len(buf)-2] != 0xFF || buf[len(buf)-1] != jpeg.MarkerEndOfImage {
+				return nil, nil, nil, fmt.Errorf("EOI not found")
+			}
+			lum, ok := qtables[0]
+			if !ok {
+				return nil, nil, nil, fmt.Errorf("quantization table not found")
+			}
+			tables := append([]byte{}, lum...)
+			if chm, ok := qtables[1]; ok {
+				tables = append(tables, chm...)
+			}
+			return sof, tables, buf[:len(buf)-2], nil
+
+		default:
+			// DHT (the standard tables are assumed), APPn, COM
+		}
+	}
+}
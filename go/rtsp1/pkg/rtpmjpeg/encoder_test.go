@@ -0,0 +1,56 @@
+package rtpmjpeg
+
+import (
+	"bytes"
+	stdjpeg "image/jpeg"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncoder(t *testing.T) {
+	src := testImage(t, 80)
+
+	ssrc := uint32(0x12345678)
+	seq := uint16(65534)
+	ts := uint32(1000)
+	e := Encoder{SSRC: &ssrc, InitialSequenceNumber: &seq, InitialTimestamp: &ts, PayloadMaxSize: 200}
+	e.Init()
+
+	pkts, err := e.Encode(src, time.Second)
+	require.NoError(t, err)
+	require.Greater(t, len(pkts), 2)
+	for i, pkt := range pkts {
+		require.Equal(t, uint8(26), pkt.PayloadType)
+		require.Equal(t, ssrc, pkt.SSRC)
+		require.Equal(t, seq+uint16(i), pkt.SequenceNumber)
+		require.Equal(t, ts+90000, pkt.Timestamp)
+		require.Equal(t, i == len(pkts)-1, pkt.Marker)
+		require.LessOrEqual(t, len(pkt.Payload), 200)
+	}
+
+	// The decoder rebuilds the same scan, with the same tables
+	img := decodeAll(t, pkts)
+	tables, data := splitJPEG(t, src)
+	tables2, data2 := splitJPEG(t, img)
+	require.Equal(t, tables, tables2)
+	require.Equal(t, data, data2)
+
+	decoded, err := stdjpeg.Decode(bytes.NewReader(img))
+	require.NoError(t, err)
+	require.Equal(t, 64, decoded.Bounds().Dx())
+	require.Equal(t, 48, decoded.Bounds().Dy())
+}
+
+func TestEncoderErrors(t *testing.T) {
+	var e Encoder
+	e.Init()
+
+	_, err := e.Encode([]byte{1, 2, 3}, 0)
+	require.Error(t, err)
+
+	src := testImage(t, 80)
+	_, err = e.Encode(src[:len(src)-2], 0)
+	require.EqualError(t, err, "EOI not found")
+}
@@ -0,0 +1,118 @@
+// Package rtpmjpeg contains a RTP/M-JPEG decoder and encoder, as in RFC 2435.
+package rtpmjpeg
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrMorePacketsNeeded is returned when more packets are needed.
+var ErrMorePacketsNeeded = errors.New("need more packets")
+
+// ErrNonStartingPacketAndNoPrevious is returned when we received a non-starting
+// packet of a fragmented frame and we didn't received anything before.
+// It's normal to receive this when we are decoding a stream that has been already
+// running for some time.
+var ErrNonStartingPacketAndNoPrevious = errors.New(
+	"received a non-starting fragment without any previous starting fragment")
+
+// the JPEG header of each RTP packet.
+type headerJPEG struct {
+	TypeSpecific   uint8
+	FragmentOffset uint32
+	Type           uint8
+	Quantization   uint8
+	Width          int
+	Height         int
+}
+
+func (h *headerJPEG) unmarshal(byts []byte) (int, error) {
+	if len(byts) < 8 {
+		return 0, fmt.Errorf("buffer is too short")
+	}
+
+	h.TypeSpecific = byts[0]
+	h.FragmentOffset = uint32(byts[1])<<16 | uint32(byts[2])<<8 | uint32(byts[3])
+
+	h.Type = byts[4]
+	if h.Type&0x3f > 1 {
+		return 0, fmt.Errorf("type %d is not supported", h.Type)
+	}
+
+	h.Quantization = byts[5]
+	if h.Quantization == 0 {
+		return 0, fmt.Errorf("quantization %d is invalid", h.Quantization)
+	}
+
+	h.Width = int(byts[6]) * 8
+	h.Height = int(byts[7]) * 8
+	if h.Width == 0 || h.Height == 0 {
+		return 0, fmt.Errorf("width and height above 2040 are not supported")
+	}
+
+	return 8, nil
+}
+
+func (h headerJPEG) marshal(byts []byte) []byte {
+	byts = append(byts, h.TypeSpecific)
+	byts = append(byts, []byte{byte(h.FragmentOffset >> 16), byte(h.FragmentOffset >> 8), byte(h.FragmentOffset)}...)
+	byts = append(byts, h.Type)
+	byts = append(byts, h.Quantization)
+	byts = append(byts, byte(h.Width/8))
+	byts = append(byts, byte(h.Height/8))
+	return byts
+}
+
+// the restart marker header, present with the types 64 to 127.
+type headerRestart struct {
+	Interval uint16
+}
+
+func (h *headerRestart) unmarshal(byts []byte) (int, error) {
+	if len(byts) < 4 {
+		return 0, fmt.Errorf("buffer is too short")
+	}
+	h.Interval = uint16(byts[0])<<8 | uint16(byts[1])
+	return 4, nil
+}
+
+// the quantization table header, present in the first packet of a frame
+// when the quantization is 128 or above.
+type headerQTable struct {
+	MBZ       uint8
+	Precision uint8
+	Tables    []byte
+}
+
+func (h *headerQTable) unmarshal(byts []byte) (int, error) {
+	if len(byts) < 4 {
+		return 0, fmt.Errorf("buffer is too short")
+	}
+
+	h.MBZ = byts[0]
+	h.Precision = byts[1]
+	if h.Precision != 0 {
+		return 0, fmt.Errorf("precision %d is not supported", h.Precision)
+	}
+
+	length := int(byts[2])<<8 | int(byts[3])
+	switch length {
+	case 64, 128:
+	default:
+		return 0, fmt.Errorf("quantization table length %d is not supported", length)
+	}
+
+	if len(byts) < 4+length {
+		return 0, fmt.Errorf("buffer is too short")
+	}
+	h.Tables = byts[4 : 4+length]
+	return 4 + length, nil
+}
+
+func (h headerQTable) marshal(byts []byte) []byte {
+	byts = append(byts, h.MBZ)
+	byts = append(byts, h.Precision)
+	byts = append(byts, []byte{byte(len(h.Tables) >> 8), byte(len(h.Tables))}...)
+	byts = append(byts, h.Tables...)
+	return byts
+}
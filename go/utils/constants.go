@@ -17,5 +17,7 @@ package utils
 
 const (
 	KeyUser   string = "user"
+	KeyAgent         = "agent"
+	KeyAuth          = "authorization"
 	KeyStream        = "stream"
 )
@@ -0,0 +1,114 @@
+// Copyright (c) 2022-2024 The authors (see the AUTHORS file)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"github.com/jfsmig/cams/go/rtsp1/pkg/codecs/h264"
+	"github.com/jfsmig/cams/go/rtsp1/pkg/format"
+	"github.com/jfsmig/cams/go/rtsp1/pkg/media"
+	"github.com/jfsmig/cams/go/rtsp1/pkg/sdp"
+	"github.com/juju/errors"
+)
+
+func parseMedias(encoded string) (media.Medias, error) {
+	var sd sdp.SessionDescription
+	if err := sd.Unmarshal([]byte(encoded)); err != nil {
+		return nil, errors.Annotate(err, "sdp")
+	}
+	var medias media.Medias
+	if err := medias.Unmarshal(sd.MediaDescriptions); err != nil {
+		return nil, errors.Annotate(err, "medias")
+	}
+	return medias, nil
+}
+
+// ClockRates returns the clock rate of each RTP payload type of the given SDP
+func ClockRates(encoded string) (map[uint8]uint32, error) {
+	medias, err := parseMedias(encoded)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[uint8]uint32)
+	for _, m := range medias {
+		for _, f := range m.Formats {
+			if rate := f.ClockRate(); rate > 0 {
+				out[f.PayloadType()] = uint32(rate)
+			}
+		}
+	}
+	return out, nil
+}
+
+// H264PayloadTypes returns the set of RTP payload types that carry H264 in the given SDP
+func H264PayloadTypes(encoded string) (map[uint8]bool, error) {
+	medias, err := parseMedias(encoded)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[uint8]bool)
+	for _, m := range medias {
+		for _, f := range m.Formats {
+			if _, ok := f.(*format.H264); ok {
+				out[f.PayloadType()] = true
+			}
+		}
+	}
+	return out, nil
+}
+
+// IsKeyFrameH264 tells if a RTP payload carries (the beginning of) an IDR slice or
+// one of the parameter sets that precede it.
+func IsKeyFrameH264(payload []byte) bool {
+	if len(payload) < 1 {
+		return false
+	}
+	switch typ := h264.NALUType(payload[0] & 0x1F); typ {
+	case h264.NALUTypeSTAPA:
+		// Aggregation of NALUs, each one prefixed with its 16 bits size
+		for buf := payload[1:]; len(buf) > 2; {
+			size := int(buf[0])<<8 | int(buf[1])
+			buf = buf[2:]
+			if size <= 0 || size > len(buf) {
+				return false
+			}
+			if isKeyNALU(h264.NALUType(buf[0] & 0x1F)) {
+				return true
+			}
+			buf = buf[size:]
+		}
+		return false
+	case h264.NALUTypeFUA:
+		// Only the first fragment matters, the others cannot be decoded without it
+		if len(payload) < 2 {
+			return false
+		}
+		start := payload[1]&0x80 != 0
+		return start && isKeyNALU(h264.NALUType(payload[1]&0x1F))
+	default:
+		return isKeyNALU(typ)
+	}
+}
+
+func isKeyNALU(typ h264.NALUType) bool {
+	return typ == h264.NALUTypeIDR || typ == h264.NALUTypeSPS || typ == h264.NALUTypePPS
+}
+
+// IsFrameStartMJPEG tells if a RTP/JPEG payload (RFC 2435) carries the first
+// fragment of an image. Each image stands on its own, it is a keyframe.
+func IsFrameStartMJPEG(payload []byte) bool {
+	// The main JPEG header: a type-specific byte then the 24 bits fragment offset
+	return len(payload) >= 8 && payload[1] == 0 && payload[2] == 0 && payload[3] == 0
+}